@@ -0,0 +1,267 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const autoscalingPrefix = "/as/autoscaling-api/v1/*"
+
+func (s *Server) autoscalingRoutes() {
+	s.serveAS("scaling_configuration", s.createASConfiguration, nil)
+	s.serveAS("scaling_group", s.createASGroup, s.updateASGroup)
+	s.serveAS("scaling_policy", s.createASPolicy, nil)
+
+	s.handle("POST", autoscalingPrefix+"/scaling_group/*/action", s.asGroupAction)
+	s.handle("GET", autoscalingPrefix+"/scaling_group_instance/*/list", s.listASInstances)
+	s.handle("POST", autoscalingPrefix+"/scaling_group_instance/*/action", s.asInstancesAction)
+	s.handle("DELETE", autoscalingPrefix+"/scaling_group_instance/*", s.deleteASInstance)
+}
+
+// serveAS registers the routes of an AutoScaling resource. Unlike most APIs,
+// AutoScaling takes unwrapped resources, names their IDs after the resource
+// and only returns the ID when a resource is created or updated.
+func (s *Server) serveAS(resource string, create func(obj object) error, update func(obj, patch object) error) {
+	prefix := autoscalingPrefix + "/" + resource
+	idKey := resource + "_id"
+
+	view := func(obj object) object {
+		view := public(obj)
+		delete(view, "id")
+		return view
+	}
+
+	s.handle("GET", prefix, func(w http.ResponseWriter, r *http.Request, params []string) {
+		items := []object{}
+		for _, obj := range s.list(resource, queryFilter(r, "start_number", "limit")) {
+			items = append(items, view(obj))
+		}
+		writeJSON(w, http.StatusOK, object{
+			resource + "s": items,
+			"total_number": len(items),
+			"start_number": 0,
+			"limit":        len(items),
+		})
+	})
+
+	s.handle("POST", prefix, func(w http.ResponseWriter, r *http.Request, params []string) {
+		obj, err := readJSON(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		obj["id"] = s.newID()
+		obj[idKey] = obj["id"]
+		obj["create_time"] = now()
+		if err := create(obj); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		s.add(resource, obj)
+		writeJSON(w, http.StatusOK, object{idKey: obj["id"]})
+	})
+
+	s.handle("GET", prefix+"/*", func(w http.ResponseWriter, r *http.Request, params []string) {
+		obj, ok := s.get(resource, params[1])
+		if !ok {
+			writeNotFound(w, resource, params[1])
+			return
+		}
+		writeJSON(w, http.StatusOK, object{resource: view(obj)})
+	})
+
+	s.handle("PUT", prefix+"/*", func(w http.ResponseWriter, r *http.Request, params []string) {
+		obj, ok := s.get(resource, params[1])
+		if !ok {
+			writeNotFound(w, resource, params[1])
+			return
+		}
+		patch, err := readJSON(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		delete(patch, idKey)
+		if update != nil {
+			if err := update(obj, patch); err != nil {
+				writeAPIError(w, err)
+				return
+			}
+		} else {
+			merge(obj, patch)
+		}
+		writeJSON(w, http.StatusOK, object{idKey: obj["id"]})
+	})
+
+	s.handle("DELETE", prefix+"/*", func(w http.ResponseWriter, r *http.Request, params []string) {
+		obj, ok := s.get(resource, params[1])
+		if !ok {
+			writeNotFound(w, resource, params[1])
+			return
+		}
+		if resource == "scaling_group" && len(s.asInstances(params[1])) > 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Scaling group %s still has instances", params[1]))
+			return
+		}
+		s.remove(resource, obj["id"].(string))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (s *Server) createASConfiguration(obj object) error {
+	config, _ := obj["instance_config"].(map[string]interface{})
+	if config == nil {
+		return badRequest("instance_config is required")
+	}
+	if flavorID, _ := config["flavorRef"].(string); flavorID != "" {
+		if _, ok := s.get("flavor", flavorID); !ok {
+			return badRequest("Flavor %s could not be found.", flavorID)
+		}
+	}
+	if imageID, _ := config["imageRef"].(string); imageID != "" {
+		if _, ok := s.get("image", imageID); !ok {
+			return badRequest("Image %s could not be found.", imageID)
+		}
+	}
+	obj["tenant"] = ProjectID
+	return nil
+}
+
+func (s *Server) createASGroup(obj object) error {
+	if _, ok := s.get("router", fmt.Sprint(obj["vpc_id"])); !ok {
+		return badRequest("VPC %v could not be found.", obj["vpc_id"])
+	}
+	setDefault(obj, "desire_instance_number", 0.0)
+	setDefault(obj, "min_instance_number", 0.0)
+	setDefault(obj, "max_instance_number", 0.0)
+	setDefault(obj, "cool_down_time", 900.0)
+	setDefault(obj, "available_zones", []interface{}{AvailabilityZone})
+	setDefault(obj, "health_periodic_audit_method", "NOVA_AUDIT")
+	setDefault(obj, "health_periodic_audit_time", 5.0)
+	setDefault(obj, "instance_terminate_policy", "OLD_CONFIG_OLD_INSTANCE")
+	setDefault(obj, "notifications", []interface{}{})
+	setDefault(obj, "delete_publicip", false)
+	setDefault(obj, "lbaas_listeners", []interface{}{})
+	obj["scaling_group_status"] = "PAUSED"
+	obj["is_scaling"] = false
+	obj["detail"] = ""
+	return s.updateASGroup(obj, object{})
+}
+
+// updateASGroup applies an update and adds or removes instances so that
+// the group has as many as it desires.
+func (s *Server) updateASGroup(obj, patch object) error {
+	merge(obj, patch)
+	if config, ok := s.get("scaling_configuration", fmt.Sprint(obj["scaling_configuration_id"])); ok {
+		obj["scaling_configuration_name"] = config["scaling_configuration_name"]
+	}
+
+	desired := int(obj["desire_instance_number"].(float64))
+	instances := s.asInstances(obj["id"].(string))
+	for i := len(instances); i < desired; i++ {
+		id := s.newID()
+		s.add("scaling_group_instance", object{
+			"id":                         id,
+			"instance_id":                id,
+			"instance_name":              fmt.Sprintf("%v-%d", obj["scaling_group_name"], i),
+			"scaling_group_id":           obj["id"],
+			"scaling_group_name":         obj["scaling_group_name"],
+			"scaling_configuration_id":   obj["scaling_configuration_id"],
+			"scaling_configuration_name": obj["scaling_configuration_name"],
+			"life_cycle_state":           "INSERVICE",
+			"health_status":              "NORMAL",
+			"create_time":                now(),
+		})
+	}
+	for i := desired; i < len(instances); i++ {
+		s.remove("scaling_group_instance", instances[i]["id"].(string))
+	}
+	obj["current_instance_number"] = len(s.asInstances(obj["id"].(string)))
+	return nil
+}
+
+func (s *Server) asInstances(groupID string) []object {
+	return s.list("scaling_group_instance", func(i object) bool { return i["scaling_group_id"] == groupID })
+}
+
+func (s *Server) asGroupAction(w http.ResponseWriter, r *http.Request, params []string) {
+	group, ok := s.get("scaling_group", params[1])
+	if !ok {
+		writeNotFound(w, "scaling_group", params[1])
+		return
+	}
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch body["action"] {
+	case "resume":
+		group["scaling_group_status"] = "INSERVICE"
+	case "pause":
+		group["scaling_group_status"] = "PAUSED"
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown action %v", body["action"]))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listASInstances(w http.ResponseWriter, r *http.Request, params []string) {
+	if _, ok := s.get("scaling_group", params[1]); !ok {
+		writeNotFound(w, "scaling_group", params[1])
+		return
+	}
+	instances := []object{}
+	for _, i := range s.asInstances(params[1]) {
+		if queryFilter(r, "start_number", "limit")(i) {
+			instances = append(instances, public(i))
+		}
+	}
+	writeJSON(w, http.StatusOK, object{
+		"scaling_group_instances": instances,
+		"total_number":            len(instances),
+		"start_number":            0,
+		"limit":                   len(instances),
+	})
+}
+
+func (s *Server) asInstancesAction(w http.ResponseWriter, r *http.Request, params []string) {
+	group, ok := s.get("scaling_group", params[1])
+	if !ok {
+		writeNotFound(w, "scaling_group", params[1])
+		return
+	}
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ids, _ := body["instances_id"].([]interface{})
+	if body["action"] != "REMOVE" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("The fake cloud doesn't implement the %v action", body["action"]))
+		return
+	}
+	for _, id := range ids {
+		s.remove("scaling_group_instance", fmt.Sprint(id))
+	}
+	group["current_instance_number"] = len(s.asInstances(params[1]))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteASInstance(w http.ResponseWriter, r *http.Request, params []string) {
+	if !s.remove("scaling_group_instance", params[1]) {
+		writeNotFound(w, "scaling_group_instance", params[1])
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createASPolicy(obj object) error {
+	if _, ok := s.get("scaling_group", fmt.Sprint(obj["scaling_group_id"])); !ok {
+		return badRequest("Scaling group %v could not be found.", obj["scaling_group_id"])
+	}
+	setDefault(obj, "cool_down_time", 900.0)
+	obj["policy_status"] = "INSERVICE"
+	return nil
+}
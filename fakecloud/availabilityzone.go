@@ -0,0 +1,26 @@
+package fakecloud
+
+import "net/http"
+
+// viewAvailabilityZone returns an availability zone the way Nova and Cinder
+// list them. The fake cloud has no hosts to show.
+func viewAvailabilityZone(name string, available bool) object {
+	return object{
+		"zoneName":  name,
+		"zoneState": object{"available": available},
+		"hosts":     nil,
+	}
+}
+
+func (s *Server) listComputeAvailabilityZones(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, object{"availabilityZoneInfo": []object{
+		viewAvailabilityZone(AvailabilityZone, true),
+		viewAvailabilityZone(UnavailableZone, false),
+	}})
+}
+
+func (s *Server) listVolumeAvailabilityZones(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, object{"availabilityZoneInfo": []object{
+		viewAvailabilityZone(AvailabilityZone, true),
+	}})
+}
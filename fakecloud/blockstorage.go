@@ -0,0 +1,281 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const blockStoragePrefix = "/evs/v2/*"
+
+// volumeTypeBackends maps the volume types of the fake cloud to the backend
+// which stores volumes of that type.
+var volumeTypeBackends = map[string]string{
+	"SATA": "sata",
+	"SAS":  "sas",
+	"SSD":  "ssd",
+}
+
+func (s *Server) blockStorageRoutes() {
+	s.handle("GET", blockStoragePrefix+"/volumes/detail", s.listVolumes)
+	s.handle("GET", blockStoragePrefix+"/os-availability-zone", s.listVolumeAvailabilityZones)
+	s.handle("POST", blockStoragePrefix+"/volumes/*/action", s.volumeAction)
+	s.serve(blockStoragePrefix+"/volumes", &collection{
+		kind:         "volume",
+		singular:     "volume",
+		plural:       "volumes",
+		createStatus: http.StatusAccepted,
+		deleteStatus: http.StatusAccepted,
+		ignore:       []string{"limit", "marker", "all_tenants"},
+		create:       s.createVolume,
+		update:       updateVolume,
+		remove:       s.removeVolume,
+	})
+	s.handle("PUT", blockStoragePrefix+"/snapshots/*/metadata", s.setSnapshotMetadata)
+	s.serve(blockStoragePrefix+"/snapshots", &collection{
+		kind:         "snapshot",
+		singular:     "snapshot",
+		plural:       "snapshots",
+		createStatus: http.StatusAccepted,
+		deleteStatus: http.StatusAccepted,
+		ignore:       []string{"limit", "marker", "all_tenants"},
+		create:       s.createSnapshot,
+		update:       updateSnapshot,
+	})
+}
+
+func (s *Server) listVolumes(w http.ResponseWriter, r *http.Request, params []string) {
+	volumes := []object{}
+	for _, volume := range s.list("volume", queryFilter(r, "limit", "marker", "all_tenants")) {
+		volumes = append(volumes, public(volume))
+	}
+	writeJSON(w, http.StatusOK, object{"volumes": volumes})
+}
+
+// createVolume fills in the defaults of a new volume, which is available
+// immediately.
+func (s *Server) createVolume(obj object) error {
+	if size, _ := obj["size"].(float64); size < 1 {
+		return badRequest("Invalid input received: volume size %v must be an integer greater than 0", obj["size"])
+	}
+
+	bootable := "false"
+	if imageID, _ := obj["imageRef"].(string); imageID != "" {
+		if _, ok := s.get("image", imageID); !ok {
+			return badRequest("Image %s could not be found.", imageID)
+		}
+		bootable = "true"
+		obj["volume_image_metadata"] = map[string]interface{}{"image_id": imageID}
+	}
+	delete(obj, "imageRef")
+
+	if snapshotID, _ := obj["snapshot_id"].(string); snapshotID != "" {
+		if _, ok := s.get("snapshot", snapshotID); !ok {
+			return notFound("Snapshot %s could not be found.", snapshotID)
+		}
+	}
+
+	if sourceID, _ := obj["source_volid"].(string); sourceID != "" {
+		if _, ok := s.get("volume", sourceID); !ok {
+			return notFound("Volume %s could not be found.", sourceID)
+		}
+	}
+
+	setDefault(obj, "volume_type", "SATA")
+	if volumeType, _ := obj["volume_type"].(string); volumeTypeBackends[volumeType] == "" {
+		return notFound("Volume type %s could not be found.", volumeType)
+	}
+
+	setDefault(obj, "name", "")
+	setDefault(obj, "description", "")
+	setDefault(obj, "availability_zone", AvailabilityZone)
+	setDefault(obj, "metadata", map[string]interface{}{})
+	setDefault(obj, "snapshot_id", "")
+	setDefault(obj, "source_volid", "")
+	setDefault(obj, "multiattach", false)
+	obj["status"] = "available"
+	obj["bootable"] = bootable
+	obj["encrypted"] = false
+	obj["attachments"] = []interface{}{}
+	obj["user_id"] = UserID
+	obj["os-vol-tenant-attr:tenant_id"] = ProjectID
+	obj["created_at"] = now()
+	obj["updated_at"] = now()
+	return nil
+}
+
+func updateVolume(obj, patch object) error {
+	for _, k := range []string{"name", "description", "metadata"} {
+		if v, ok := patch[k]; ok {
+			obj[k] = v
+		}
+	}
+	obj["updated_at"] = now()
+	return nil
+}
+
+func (s *Server) removeVolume(obj object) error {
+	if obj["status"] != "available" && obj["status"] != "error" {
+		return badRequest("Invalid volume: Volume %s status must be available or error, but current status is: %s", obj["id"], obj["status"])
+	}
+	if snapshots := s.list("snapshot", func(snapshot object) bool { return snapshot["volume_id"] == obj["id"] }); len(snapshots) > 0 {
+		return badRequest("Invalid volume: Volume %s still has %d dependent snapshots.", obj["id"], len(snapshots))
+	}
+	return nil
+}
+
+// createSnapshot snapshots a volume, which is available immediately. Like
+// Cinder, the fake only snapshots an attached volume when forced to.
+func (s *Server) createSnapshot(obj object) error {
+	volumeID, _ := obj["volume_id"].(string)
+	volume, ok := s.get("volume", volumeID)
+	if !ok {
+		return notFound("Volume %s could not be found.", volumeID)
+	}
+	if volume["status"] != "available" && !(volume["status"] == "in-use" && obj["force"] == true) {
+		return badRequest("Invalid volume: Volume %s status must be available, but current status is: %s", volumeID, volume["status"])
+	}
+	delete(obj, "force")
+
+	setDefault(obj, "name", "")
+	setDefault(obj, "description", "")
+	setDefault(obj, "metadata", map[string]interface{}{})
+	obj["size"] = volume["size"]
+	obj["status"] = "available"
+	obj["os-extended-snapshot-attributes:project_id"] = ProjectID
+	obj["created_at"] = now()
+	obj["updated_at"] = now()
+	return nil
+}
+
+func updateSnapshot(obj, patch object) error {
+	for _, k := range []string{"name", "description"} {
+		if v, ok := patch[k]; ok {
+			obj[k] = v
+		}
+	}
+	obj["updated_at"] = now()
+	return nil
+}
+
+// setSnapshotMetadata replaces the metadata of a snapshot.
+func (s *Server) setSnapshotMetadata(w http.ResponseWriter, r *http.Request, params []string) {
+	snapshot, ok := s.get("snapshot", params[1])
+	if !ok {
+		writeNotFound(w, "Snapshot", params[1])
+		return
+	}
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	metadata, ok := body["metadata"].(map[string]interface{})
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Malformed request body: metadata is missing")
+		return
+	}
+
+	snapshot["metadata"] = metadata
+	snapshot["updated_at"] = now()
+	writeJSON(w, http.StatusOK, object{"metadata": metadata})
+}
+
+// volumeAction serves the actions of a volume. Every action takes effect
+// immediately.
+func (s *Server) volumeAction(w http.ResponseWriter, r *http.Request, params []string) {
+	volume, ok := s.get("volume", params[1])
+	if !ok {
+		writeNotFound(w, "Volume", params[1])
+		return
+	}
+	body, err := readJSON(r)
+	if err != nil || len(body) != 1 {
+		writeError(w, http.StatusBadRequest, "The request body must contain exactly one action")
+		return
+	}
+
+	for action, v := range body {
+		args, _ := v.(map[string]interface{})
+		switch action {
+		case "os-extend":
+			err = extendVolume(volume, args)
+		case "os-retype":
+			err = retypeVolume(volume, args)
+		default:
+			writeError(w, http.StatusNotImplemented, fmt.Sprintf("Volume action %s is not implemented", action))
+			return
+		}
+	}
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	volume["updated_at"] = now()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// extendVolume grows a volume. Like Cinder from microversion 3.42 on, the
+// fake extends attached volumes as well.
+func extendVolume(volume object, args map[string]interface{}) error {
+	if volume["status"] != "available" && volume["status"] != "in-use" {
+		return badRequest("Invalid volume: Volume %s status must be available or in-use, but current status is: %s", volume["id"], volume["status"])
+	}
+	size, _ := args["new_size"].(float64)
+	if size <= volume["size"].(float64) {
+		return badRequest("Invalid input received: New size for extend must be greater than current size. (current: %v, extended: %v).", volume["size"], args["new_size"])
+	}
+	volume["size"] = size
+	return nil
+}
+
+// retypeVolume changes the type of a volume. Since every volume type has its
+// own backend, the volume has to be migrated, which Cinder only does if the
+// migration policy allows it.
+func retypeVolume(volume object, args map[string]interface{}) error {
+	if volume["status"] != "available" && volume["status"] != "in-use" {
+		return badRequest("Invalid volume: Volume %s status must be available or in-use, but current status is: %s", volume["id"], volume["status"])
+	}
+	newType, _ := args["new_type"].(string)
+	if _, ok := volumeTypeBackends[newType]; !ok {
+		return notFound("Volume type %s could not be found.", newType)
+	}
+	if newType == volume["volume_type"] {
+		return badRequest("Invalid input received: New volume type must be different from the current type %s.", newType)
+	}
+	policy, _ := args["migration_policy"].(string)
+	switch policy {
+	case "", "never":
+		if volumeTypeBackends[newType] != volumeTypeBackends[volume["volume_type"].(string)] {
+			return badRequest("Invalid volume: Retype of volume %s to %s requires migration, but the migration policy is never.", volume["id"], newType)
+		}
+	case "on-demand":
+	default:
+		return badRequest("Invalid input received: migration_policy must be never or on-demand, not %s.", policy)
+	}
+	volume["volume_type"] = newType
+	return nil
+}
+
+// attachVolumeTo records the attachment of a volume to a server.
+func (s *Server) attachVolumeTo(volume object, serverID, device string) {
+	volume["attachments"] = append(volume["attachments"].([]interface{}), map[string]interface{}{
+		"id":            volume["id"],
+		"attachment_id": s.newID(),
+		"volume_id":     volume["id"],
+		"server_id":     serverID,
+		"device":        device,
+		"attached_at":   now(),
+	})
+	volume["status"] = "in-use"
+}
+
+// detachVolumeFrom removes all attachments of a volume.
+func (s *Server) detachVolumeFrom(volume object) {
+	if volume == nil {
+		return
+	}
+	volume["attachments"] = []interface{}{}
+	volume["status"] = "available"
+	delete(volume, "_delete_on_termination")
+}
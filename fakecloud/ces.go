@@ -0,0 +1,77 @@
+package fakecloud
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const cesPrefix = "/ces/V1.0/*"
+
+func (s *Server) cesRoutes() {
+	s.handle("POST", cesPrefix+"/alarms", s.createAlarm)
+	s.handle("GET", cesPrefix+"/alarms/*", s.getAlarm)
+	s.handle("PUT", cesPrefix+"/alarms/*/action", s.alarmAction)
+	s.handle("DELETE", cesPrefix+"/alarms/*", s.deleteAlarm)
+}
+
+func (s *Server) createAlarm(w http.ResponseWriter, r *http.Request, params []string) {
+	alarm, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if name, _ := alarm["alarm_name"].(string); name == "" {
+		writeError(w, http.StatusBadRequest, "alarm_name is required")
+		return
+	}
+
+	id := "al" + strings.Replace(s.newID(), "-", "", -1)
+	setDefault(alarm, "alarm_description", "")
+	setDefault(alarm, "alarm_actions", []interface{}{})
+	setDefault(alarm, "insufficientdata_actions", []interface{}{})
+	setDefault(alarm, "ok_actions", []interface{}{})
+	alarm["id"] = id
+	alarm["alarm_id"] = id
+	alarm["alarm_state"] = "ok"
+	alarm["update_time"] = time.Now().UnixNano() / int64(time.Millisecond)
+	s.add("alarm", alarm)
+
+	writeJSON(w, http.StatusCreated, object{"alarm_id": id})
+}
+
+// getAlarm serves a single alarm, which CES returns as a list.
+func (s *Server) getAlarm(w http.ResponseWriter, r *http.Request, params []string) {
+	alarm, ok := s.get("alarm", params[1])
+	if !ok {
+		writeNotFound(w, "Alarm", params[1])
+		return
+	}
+	writeJSON(w, http.StatusOK, object{"metric_alarms": []object{public(alarm)}})
+}
+
+func (s *Server) alarmAction(w http.ResponseWriter, r *http.Request, params []string) {
+	alarm, ok := s.get("alarm", params[1])
+	if !ok {
+		writeNotFound(w, "Alarm", params[1])
+		return
+	}
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if enabled, ok := body["alarm_enabled"].(bool); ok {
+		alarm["alarm_enabled"] = enabled
+	}
+	alarm["update_time"] = time.Now().UnixNano() / int64(time.Millisecond)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteAlarm(w http.ResponseWriter, r *http.Request, params []string) {
+	if !s.remove("alarm", params[1]) {
+		writeNotFound(w, "Alarm", params[1])
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const dnsPrefix = "/dns/v2"
+
+func (s *Server) dnsRoutes() {
+	s.serve(dnsPrefix+"/zones", &collection{
+		kind:         "zone",
+		plural:       "zones",
+		createStatus: http.StatusAccepted,
+		updateStatus: http.StatusAccepted,
+		deleteStatus: http.StatusAccepted,
+		updateMethod: "PATCH",
+		ignore:       []string{"limit", "marker", "sort_key", "sort_dir"},
+		create:       s.createZone,
+		update:       updateZone,
+		remove:       s.removeZone,
+	})
+
+	s.serve(dnsPrefix+"/zones/*/recordsets", &collection{
+		kind:         "recordset",
+		plural:       "recordsets",
+		parent:       "zone_id",
+		createStatus: http.StatusAccepted,
+		updateStatus: http.StatusAccepted,
+		deleteStatus: http.StatusAccepted,
+		ignore:       []string{"limit", "marker", "sort_key", "sort_dir"},
+		create:       s.createRecordSet,
+		update:       updateRecordSet,
+	})
+}
+
+func (s *Server) createZone(obj object) error {
+	name, _ := obj["name"].(string)
+	if !strings.HasSuffix(name, ".") {
+		return badRequest("Zone name %q must end with a dot", name)
+	}
+	for _, zone := range s.list("zone", nil) {
+		if zone["name"] == name {
+			return conflict("Duplicate Zone")
+		}
+	}
+
+	setDefault(obj, "email", "")
+	setDefault(obj, "ttl", 300)
+	setDefault(obj, "description", "")
+	setDefault(obj, "type", "PRIMARY")
+	setDefault(obj, "masters", []interface{}{})
+	setDefault(obj, "attributes", map[string]interface{}{})
+	obj["status"] = "ACTIVE"
+	obj["action"] = "NONE"
+	obj["version"] = 1
+	obj["serial"] = 1
+	obj["pool_id"] = "fake-pool"
+	obj["project_id"] = ProjectID
+	obj["links"] = map[string]interface{}{}
+	obj["created_at"] = now()
+	obj["updated_at"] = now()
+	obj["transferred_at"] = nil
+	return nil
+}
+
+func updateZone(obj, patch object) error {
+	for _, k := range []string{"email", "ttl", "description", "masters"} {
+		if v, ok := patch[k]; ok {
+			obj[k] = v
+		}
+	}
+	obj["version"] = obj["version"].(int) + 1
+	obj["serial"] = obj["serial"].(int) + 1
+	obj["updated_at"] = now()
+	return nil
+}
+
+func (s *Server) removeZone(obj object) error {
+	for _, rs := range s.list("recordset", nil) {
+		if rs["zone_id"] == obj["id"] {
+			s.remove("recordset", rs["id"].(string))
+		}
+	}
+	obj["action"] = "DELETE"
+	return nil
+}
+
+func (s *Server) createRecordSet(obj object) error {
+	zone, ok := s.get("zone", fmt.Sprint(obj["zone_id"]))
+	if !ok {
+		return notFound("Could not find Zone")
+	}
+
+	name, _ := obj["name"].(string)
+	if !strings.HasSuffix(name, zone["name"].(string)) {
+		return badRequest("RecordSet %q is not in zone %v", name, zone["name"])
+	}
+
+	setDefault(obj, "ttl", nil)
+	setDefault(obj, "description", "")
+	setDefault(obj, "records", []interface{}{})
+	obj["zone_name"] = zone["name"]
+	obj["status"] = "ACTIVE"
+	obj["action"] = "NONE"
+	obj["version"] = 1
+	obj["project_id"] = ProjectID
+	obj["links"] = map[string]interface{}{}
+	obj["created_at"] = now()
+	obj["updated_at"] = now()
+	return nil
+}
+
+func updateRecordSet(obj, patch object) error {
+	for _, k := range []string{"ttl", "description", "records"} {
+		if v, ok := patch[k]; ok {
+			obj[k] = v
+		}
+	}
+	obj["version"] = obj["version"].(int) + 1
+	obj["updated_at"] = now()
+	return nil
+}
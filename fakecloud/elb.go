@@ -0,0 +1,312 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// The ELB client derives its endpoint from the compute one, so the ELB API
+// lives at /elb/v1.0/ next to /ecs/v2/.
+const elbPrefix = "/elb/v1.0/*"
+
+func (s *Server) elbRoutes() {
+	s.handle("GET", elbPrefix+"/jobs/*", s.getJob)
+
+	s.handle("GET", elbPrefix+"/elbaas/loadbalancers", s.listLoadBalancers)
+	s.handle("POST", elbPrefix+"/elbaas/loadbalancers", s.createLoadBalancer)
+	s.handle("GET", elbPrefix+"/elbaas/loadbalancers/*", s.getLoadBalancer)
+	s.handle("PUT", elbPrefix+"/elbaas/loadbalancers/*", s.updateLoadBalancer)
+	s.handle("DELETE", elbPrefix+"/elbaas/loadbalancers/*", s.deleteLoadBalancer)
+
+	s.handle("GET", elbPrefix+"/elbaas/listeners/*/members", s.listMembers)
+	s.handle("POST", elbPrefix+"/elbaas/listeners/*/members", s.addMembers)
+	s.handle("POST", elbPrefix+"/elbaas/listeners/*/members/action", s.removeMembers)
+
+	s.serve(elbPrefix+"/elbaas/listeners", &collection{
+		kind:         "listener",
+		plural:       "listeners",
+		createStatus: http.StatusOK,
+		create:       s.createListener,
+		remove:       s.removeListener,
+		view:         s.viewListener,
+	})
+
+	s.serve(elbPrefix+"/elbaas/healthcheck", &collection{
+		kind:         "healthcheck",
+		plural:       "healthchecks",
+		createStatus: http.StatusOK,
+		create:       s.createHealthCheck,
+	})
+}
+
+// newJob records an asynchronous ELB job, which has succeeded already, and
+// returns the response which refers to it.
+func (s *Server) newJob(jobType string, entities object) object {
+	job := s.add("job", object{
+		"job_type":    jobType,
+		"status":      "SUCCESS",
+		"entities":    entities,
+		"begin_time":  now(),
+		"end_time":    now(),
+		"error_code":  nil,
+		"fail_reason": nil,
+	})
+	job["job_id"] = job["id"]
+	return object{
+		"job_id": job["id"],
+		"uri":    fmt.Sprintf("/v1.0/%s/jobs/%s", ProjectID, job["id"]),
+	}
+}
+
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request, params []string) {
+	job, ok := s.get("job", params[1])
+	if !ok {
+		writeNotFound(w, "Job", params[1])
+		return
+	}
+	writeJSON(w, http.StatusOK, public(job))
+}
+
+func (s *Server) listLoadBalancers(w http.ResponseWriter, r *http.Request, params []string) {
+	loadBalancers := s.list("loadbalancer", queryFilter(r, "limit", "marker"))
+	writeJSON(w, http.StatusOK, object{
+		"loadbalancers": loadBalancers,
+		"instance_num":  fmt.Sprint(len(loadBalancers)),
+	})
+}
+
+func (s *Server) getLoadBalancer(w http.ResponseWriter, r *http.Request, params []string) {
+	lb, ok := s.get("loadbalancer", params[1])
+	if !ok {
+		writeNotFound(w, "Load balancer", params[1])
+		return
+	}
+	writeJSON(w, http.StatusOK, lb)
+}
+
+func (s *Server) createLoadBalancer(w http.ResponseWriter, r *http.Request, params []string) {
+	lb, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, ok := s.get("router", fmt.Sprint(lb["vpc_id"])); !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("VPC %v could not be found.", lb["vpc_id"]))
+		return
+	}
+
+	// Internal load balancers get an address of their subnet, external
+	// ones an EIP.
+	subnetID := fmt.Sprint(lb["vip_subnet_id"])
+	if lb["type"] == "External" {
+		network, _ := s.get("network", ExternalNetworkID)
+		subnetID = network["subnets"].([]interface{})[0].(string)
+	}
+	subnet, ok := s.get("subnet", subnetID)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Subnet %s could not be found.", subnetID))
+		return
+	}
+	if address, _ := lb["vip_address"].(string); address == "" {
+		lb["vip_address"] = s.allocateIP(subnet, "")
+	}
+
+	delete(lb, "id")
+	delete(lb, "tenantId")
+	setDefault(lb, "description", "")
+	setDefault(lb, "vip_subnet_id", "")
+	setDefault(lb, "security_group_id", "")
+	setDefault(lb, "bandwidth", 0)
+	lb["status"] = "ACTIVE"
+	lb["create_time"] = now()
+	lb["update_time"] = now()
+	s.add("loadbalancer", lb)
+
+	writeJSON(w, http.StatusOK, s.newJob("CREATE_ELB", object{"elb": lb}))
+}
+
+func (s *Server) updateLoadBalancer(w http.ResponseWriter, r *http.Request, params []string) {
+	lb, ok := s.get("loadbalancer", params[1])
+	if !ok {
+		writeNotFound(w, "Load balancer", params[1])
+		return
+	}
+	patch, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, k := range []string{"name", "description", "bandwidth", "admin_state_up"} {
+		if v, ok := patch[k]; ok {
+			lb[k] = v
+		}
+	}
+	lb["update_time"] = now()
+
+	writeJSON(w, http.StatusOK, s.newJob("UPDATE_ELB", object{"elb": lb}))
+}
+
+func (s *Server) deleteLoadBalancer(w http.ResponseWriter, r *http.Request, params []string) {
+	lb, ok := s.get("loadbalancer", params[1])
+	if !ok {
+		writeNotFound(w, "Load balancer", params[1])
+		return
+	}
+	for _, l := range s.list("listener", nil) {
+		if l["loadbalancer_id"] == lb["id"] {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Load balancer %s still has listeners", lb["id"]))
+			return
+		}
+	}
+	s.remove("loadbalancer", params[1])
+
+	writeJSON(w, http.StatusOK, s.newJob("DELETE_ELB", object{"elb": lb}))
+}
+
+func (s *Server) createListener(obj object) error {
+	if _, ok := s.get("loadbalancer", fmt.Sprint(obj["loadbalancer_id"])); !ok {
+		return badRequest("Load balancer %v could not be found.", obj["loadbalancer_id"])
+	}
+	setDefault(obj, "description", "")
+	setDefault(obj, "admin_state_up", true)
+	setDefault(obj, "session_sticky", false)
+	setDefault(obj, "sticky_session_type", "")
+	setDefault(obj, "cookie_timeout", 0)
+	setDefault(obj, "tcp_timeout", 0)
+	setDefault(obj, "udp_timeout", 0)
+	setDefault(obj, "tcp_draining", false)
+	setDefault(obj, "tcp_draining_timeout", 0)
+	setDefault(obj, "certificate_id", "")
+	setDefault(obj, "certificates", []interface{}{})
+	setDefault(obj, "ssl_protocols", "")
+	setDefault(obj, "ssl_ciphers", "")
+	obj["status"] = "ACTIVE"
+	obj["create_time"] = now()
+	obj["update_time"] = now()
+	return nil
+}
+
+func (s *Server) removeListener(obj object) error {
+	for _, m := range s.list("member", nil) {
+		if m["listener_id"] == obj["id"] {
+			s.remove("member", m["id"].(string))
+		}
+	}
+	for _, h := range s.list("healthcheck", nil) {
+		if h["listener_id"] == obj["id"] {
+			s.remove("healthcheck", h["id"].(string))
+		}
+	}
+	return nil
+}
+
+func (s *Server) viewListener(obj object) object {
+	view := merge(object{"healthcheck_id": "", "member_number": 0}, obj)
+	for _, h := range s.list("healthcheck", nil) {
+		if h["listener_id"] == obj["id"] {
+			view["healthcheck_id"] = h["id"]
+		}
+	}
+	for _, m := range s.list("member", nil) {
+		if m["listener_id"] == obj["id"] {
+			view["member_number"] = view["member_number"].(int) + 1
+		}
+	}
+	return view
+}
+
+func (s *Server) createHealthCheck(obj object) error {
+	if _, ok := s.get("listener", fmt.Sprint(obj["listener_id"])); !ok {
+		return badRequest("Listener %v could not be found.", obj["listener_id"])
+	}
+	setDefault(obj, "healthcheck_protocol", "HTTP")
+	setDefault(obj, "healthcheck_uri", "/")
+	setDefault(obj, "healthcheck_connect_port", 80)
+	setDefault(obj, "healthy_threshold", 3)
+	setDefault(obj, "unhealthy_threshold", 3)
+	setDefault(obj, "healthcheck_timeout", 10)
+	setDefault(obj, "healthcheck_interval", 5)
+	obj["create_time"] = now()
+	obj["update_time"] = now()
+	return nil
+}
+
+func viewMember(m object) object {
+	view := public(m)
+	view["listeners"] = []interface{}{map[string]interface{}{"id": m["listener_id"]}}
+	delete(view, "listener_id")
+	return view
+}
+
+// listMembers serves the members of a listener, which are returned as a
+// bare list.
+func (s *Server) listMembers(w http.ResponseWriter, r *http.Request, params []string) {
+	if _, ok := s.get("listener", params[1]); !ok {
+		writeNotFound(w, "Listener", params[1])
+		return
+	}
+	members := []object{}
+	for _, m := range s.list("member", queryFilter(r, "limit", "marker")) {
+		if m["listener_id"] == params[1] {
+			members = append(members, viewMember(m))
+		}
+	}
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (s *Server) addMembers(w http.ResponseWriter, r *http.Request, params []string) {
+	if _, ok := s.get("listener", params[1]); !ok {
+		writeNotFound(w, "Listener", params[1])
+		return
+	}
+	var requested []object
+	if !readList(w, r, &requested) {
+		return
+	}
+
+	members := []interface{}{}
+	for _, req := range requested {
+		server, ok := s.get("server", fmt.Sprint(req["server_id"]))
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Instance %v could not be found.", req["server_id"]))
+			return
+		}
+		m := s.add("member", object{
+			"listener_id":    params[1],
+			"server_id":      server["id"],
+			"server_name":    server["name"],
+			"address":        req["address"],
+			"server_address": req["address"],
+			"status":         "ACTIVE",
+			"health_status":  "NORMAL",
+			"create_time":    now(),
+			"update_time":    now(),
+		})
+		members = append(members, viewMember(m))
+	}
+
+	writeJSON(w, http.StatusOK, s.newJob("ADD_MEMBER", object{"members": members}))
+}
+
+func (s *Server) removeMembers(w http.ResponseWriter, r *http.Request, params []string) {
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	removed, _ := body["removeMember"].([]interface{})
+
+	members := []interface{}{}
+	for _, raw := range removed {
+		id := fmt.Sprint(raw.(map[string]interface{})["id"])
+		m, ok := s.get("member", id)
+		if !ok || m["listener_id"] != params[1] {
+			writeNotFound(w, "Member", id)
+			return
+		}
+		s.remove("member", id)
+		members = append(members, viewMember(m))
+	}
+
+	writeJSON(w, http.StatusOK, s.newJob("DELETE_MEMBER", object{"members": members}))
+}
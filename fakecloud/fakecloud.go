@@ -0,0 +1,569 @@
+// Package fakecloud implements an in-memory TelefonicaOpenCloud API, so that
+// the acceptance tests can run without credentials or network access.
+//
+// It serves Keystone v3 tokens with a service catalog and keeps the state of
+// the Nova, Neutron, Cinder, Glance, Designate, ELB, AutoScaling, SMN and
+// CES resources created through it. Everything becomes ready immediately.
+package fakecloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Region is the only region of the fake cloud.
+	Region = "fake-region-1"
+
+	// AvailabilityZone is the only available availability zone of the fake
+	// cloud.
+	AvailabilityZone = "fake-az-1"
+
+	// UnavailableZone is a compute availability zone which is listed, but
+	// not available.
+	UnavailableZone = "fake-az-2"
+
+	// DomainName, ProjectName, Username and Password are the credentials
+	// the fake cloud accepts.
+	DomainName  = "fake-domain"
+	ProjectName = "fake-project"
+	Username    = "admin"
+	Password    = "fake-password"
+
+	// ProjectID and UserID identify the project and user of every token.
+	ProjectID = "a6e1f5c9a0b84e2c9d3f7e8a1b2c3d4e"
+	UserID    = "d2f4b6a8c0e24f6a8b0c2d4e6f8a0b2c"
+
+	// The resources every fake cloud starts with.
+	ImageID           = "c0ffee00-0000-4000-8000-000000000001"
+	ImageName         = "Fake_CentOS_7"
+	FlavorID          = "s1.medium"
+	FlavorName        = "s1.medium"
+	ExternalNetworkID = "c0ffee00-0000-4000-8000-000000000002"
+	ExternalNetwork   = "admin_external_net"
+	NetworkID         = "c0ffee00-0000-4000-8000-000000000003"
+	VPCID             = "c0ffee00-0000-4000-8000-000000000004"
+)
+
+// object is a single resource as it's returned by the API.
+type object map[string]interface{}
+
+// Server is a running fake cloud.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	routes  []route
+	objects map[string]map[string]object
+	nextID  int
+	tokens  map[string]bool
+}
+
+// New starts a fake cloud. It must be closed when it's no longer needed.
+func New() *Server {
+	s := &Server{
+		objects: make(map[string]map[string]object),
+		tokens:  make(map[string]bool),
+	}
+
+	s.identityRoutes()
+	s.computeRoutes()
+	s.computeQuotaRoutes()
+	s.networkRoutes()
+	s.blockStorageRoutes()
+	s.imageRoutes()
+	s.dnsRoutes()
+	s.elbRoutes()
+	s.autoscalingRoutes()
+	s.smnRoutes()
+	s.cesRoutes()
+
+	s.seed()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Env returns the environment variables which point the provider and its
+// acceptance tests to the fake cloud.
+func (s *Server) Env() map[string]string {
+	return map[string]string{
+		"OS_AUTH_URL":          s.URL + "/v3",
+		"OS_REGION_NAME":       Region,
+		"OS_USERNAME":          Username,
+		"OS_PASSWORD":          Password,
+		"OS_TENANT_NAME":       ProjectName,
+		"OS_TENANT_ID":         ProjectID,
+		"OS_DOMAIN_NAME":       DomainName,
+		"OS_AVAILABILITY_ZONE": AvailabilityZone,
+		"OS_IMAGE_ID":          ImageID,
+		"OS_IMAGE_NAME":        ImageName,
+		"OS_FLAVOR_ID":         FlavorID,
+		"OS_FLAVOR_NAME":       FlavorName,
+		"OS_POOL_NAME":         ExternalNetwork,
+		"OS_EXTGW_ID":          ExternalNetworkID,
+		"OS_NETWORK_ID":        NetworkID,
+		"OS_VPC_ID":            VPCID,
+	}
+}
+
+// seed creates the resources every fake cloud starts with.
+func (s *Server) seed() {
+	s.seedCompute()
+	s.seedNetwork()
+}
+
+// route maps a method and a path pattern to a handler. A "*" segment of the
+// pattern matches any single path segment, which is passed to the handler.
+type route struct {
+	method  string
+	pattern []string
+	handler func(w http.ResponseWriter, r *http.Request, params []string)
+}
+
+func (s *Server) handle(method, pattern string, handler func(w http.ResponseWriter, r *http.Request, params []string)) {
+	s.routes = append(s.routes, route{
+		method:  method,
+		pattern: strings.Split(strings.Trim(pattern, "/"), "/"),
+		handler: handler,
+	})
+}
+
+func (rt *route) match(segments []string) ([]string, bool) {
+	if len(rt.pattern) != len(segments) {
+		return nil, false
+	}
+
+	var params []string
+	for i, p := range rt.pattern {
+		switch {
+		case p == "*":
+			params = append(params, segments[i])
+		case p != segments[i]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[DEBUG] Fake cloud request: %s %s", r.Method, r.URL)
+
+	if r.URL.Path != "/v3/auth/tokens" && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var pathMatched bool
+	for _, rt := range s.routes {
+		params, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			pathMatched = true
+			continue
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		rt.handler(w, r, params)
+		return
+	}
+
+	if pathMatched {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not allowed on %s", r.Method, r.URL.Path))
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("The fake cloud doesn't implement %s %s", r.Method, r.URL.Path))
+}
+
+// newID returns a new UUID. They are sequential so that runs are repeatable.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("fa4e0000-0000-4000-8000-%012d", s.nextID)
+}
+
+// add stores a new resource of the given kind and assigns it an ID unless
+// it has one already.
+func (s *Server) add(kind string, obj object) object {
+	id, _ := obj["id"].(string)
+	if id == "" {
+		id = s.newID()
+		obj["id"] = id
+	}
+
+	if s.objects[kind] == nil {
+		s.objects[kind] = make(map[string]object)
+	}
+	s.objects[kind][id] = obj
+	return obj
+}
+
+func (s *Server) get(kind, id string) (object, bool) {
+	obj, ok := s.objects[kind][id]
+	return obj, ok
+}
+
+func (s *Server) remove(kind, id string) bool {
+	if _, ok := s.objects[kind][id]; !ok {
+		return false
+	}
+	delete(s.objects[kind], id)
+	return true
+}
+
+// list returns the resources of a kind for which filter returns true, in the
+// order in which they were created.
+func (s *Server) list(kind string, filter func(object) bool) []object {
+	var ids []string
+	for id := range s.objects[kind] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	objects := []object{}
+	for _, id := range ids {
+		if obj := s.objects[kind][id]; filter == nil || filter(obj) {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+// queryFilter returns a filter which matches the resources whose fields have
+// the values of the query parameters. Parameters which aren't fields of the
+// resource, such as paging parameters, are ignored.
+func queryFilter(r *http.Request, ignore ...string) func(object) bool {
+	query := r.URL.Query()
+	return func(obj object) bool {
+	params:
+		for k, values := range query {
+			for _, i := range ignore {
+				if k == i {
+					continue params
+				}
+			}
+			v, ok := obj[k]
+			if !ok {
+				continue
+			}
+			if kind := reflect.ValueOf(v).Kind(); kind == reflect.Slice || kind == reflect.Map {
+				continue
+			}
+
+			actual := fmt.Sprint(v)
+			for _, value := range values {
+				if actual == value {
+					continue params
+				}
+			}
+			return false
+		}
+		return true
+	}
+}
+
+// readJSON decodes the body of a request into a map.
+func readJSON(r *http.Request) (object, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := object{}
+	if len(body) == 0 {
+		return obj, nil
+	}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// readWrapped decodes the body of a request and returns the object under
+// key, which is how most APIs wrap a resource.
+func readWrapped(w http.ResponseWriter, r *http.Request, key string) (object, bool) {
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	obj, ok := body[key].(map[string]interface{})
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("The request body must contain %q", key))
+		return nil, false
+	}
+	return object(obj), true
+}
+
+// readList decodes a request body which is a JSON array into v.
+func readList(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Openstack-Request-Id", fmt.Sprintf("req-%d", time.Now().UnixNano()))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, object{
+		"error": object{
+			"code":    status,
+			"message": message,
+		},
+	})
+}
+
+func writeNotFound(w http.ResponseWriter, kind, id string) {
+	writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s could not be found.", kind, id))
+}
+
+// merge copies the fields of src to dst.
+func merge(dst, src object) object {
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// public returns a resource without its fields starting with "_", which
+// only the fake cloud uses.
+func public(obj object) object {
+	view := object{}
+	for k, v := range obj {
+		if !strings.HasPrefix(k, "_") {
+			view[k] = v
+		}
+	}
+	return view
+}
+
+// setDefault sets a field unless it's set already.
+func setDefault(obj object, key string, value interface{}) {
+	if v, ok := obj[key]; !ok || v == nil {
+		obj[key] = value
+	}
+}
+
+// now returns the current time the way most APIs format it.
+func now() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000000")
+}
+
+// apiError is returned by the hooks of a collection to fail a request.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &apiError{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+}
+
+func conflict(format string, args ...interface{}) error {
+	return &apiError{http.StatusConflict, fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &apiError{http.StatusNotFound, fmt.Sprintf(format, args...)}
+}
+
+func writeAPIError(w http.ResponseWriter, err error) {
+	if e, ok := err.(*apiError); ok {
+		writeError(w, e.status, e.message)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+// collection describes a list of resources which are created, read,
+// updated and deleted the usual REST way, wrapped in the singular or plural
+// key. The hooks fill in defaults and keep related resources consistent.
+type collection struct {
+	kind   string
+	plural string
+
+	// singular is the key a single resource is wrapped in. If it's empty,
+	// the resource isn't wrapped.
+	singular string
+
+	// unwrappedRequests is set for APIs which take an unwrapped resource
+	// but return a wrapped one.
+	unwrappedRequests bool
+
+	// parent is the field which holds the first parameter of the prefix,
+	// for resources which belong to another one.
+	parent string
+
+	createStatus int
+	updateStatus int
+	deleteStatus int
+	updateMethod string
+
+	// ignore lists query parameters which aren't used to filter lists.
+	ignore []string
+
+	create func(obj object) error
+	update func(obj, patch object) error
+	remove func(obj object) error
+	view   func(obj object) object
+}
+
+// serve registers the routes of a collection below prefix. The parameters
+// of the prefix are not passed to the hooks.
+func (s *Server) serve(prefix string, c *collection) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if c.createStatus == 0 {
+		c.createStatus = http.StatusCreated
+	}
+	if c.deleteStatus == 0 {
+		c.deleteStatus = http.StatusNoContent
+	}
+	if c.updateStatus == 0 {
+		c.updateStatus = http.StatusOK
+	}
+	if c.updateMethod == "" {
+		c.updateMethod = "PUT"
+	}
+
+	view := func(obj object) object {
+		if c.view != nil {
+			obj = c.view(obj)
+		}
+		obj = public(obj)
+		if c.singular == "" {
+			return obj
+		}
+		return object{c.singular: obj}
+	}
+
+	read := func(w http.ResponseWriter, r *http.Request) (object, bool) {
+		if c.singular == "" || c.unwrappedRequests {
+			obj, err := readJSON(r)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return nil, false
+			}
+			return obj, true
+		}
+		return readWrapped(w, r, c.singular)
+	}
+
+	// get returns the resource of the last parameter, provided that it
+	// belongs to the parent of the first one.
+	get := func(w http.ResponseWriter, params []string) (object, bool) {
+		id := params[len(params)-1]
+		obj, ok := s.get(c.kind, id)
+		if ok && c.parent != "" && obj[c.parent] != params[0] {
+			ok = false
+		}
+		if !ok {
+			writeNotFound(w, c.kind, id)
+		}
+		return obj, ok
+	}
+
+	s.handle("GET", prefix, func(w http.ResponseWriter, r *http.Request, params []string) {
+		filter := queryFilter(r, c.ignore...)
+		items := []object{}
+		for _, obj := range s.list(c.kind, filter) {
+			if c.parent == "" || obj[c.parent] == params[0] {
+				item := view(obj)
+				if c.singular != "" {
+					item = item[c.singular].(object)
+				}
+				items = append(items, item)
+			}
+		}
+		writeJSON(w, http.StatusOK, object{c.plural: items})
+	})
+
+	s.handle("POST", prefix, func(w http.ResponseWriter, r *http.Request, params []string) {
+		obj, ok := read(w, r)
+		if !ok {
+			return
+		}
+		delete(obj, "id")
+		if c.parent != "" {
+			obj[c.parent] = params[0]
+		}
+		if c.create != nil {
+			if err := c.create(obj); err != nil {
+				writeAPIError(w, err)
+				return
+			}
+		}
+		s.add(c.kind, obj)
+		writeJSON(w, c.createStatus, view(obj))
+	})
+
+	s.handle("GET", prefix+"/*", func(w http.ResponseWriter, r *http.Request, params []string) {
+		if obj, ok := get(w, params); ok {
+			writeJSON(w, http.StatusOK, view(obj))
+		}
+	})
+
+	s.handle(c.updateMethod, prefix+"/*", func(w http.ResponseWriter, r *http.Request, params []string) {
+		obj, ok := get(w, params)
+		if !ok {
+			return
+		}
+		patch, ok := read(w, r)
+		if !ok {
+			return
+		}
+		delete(patch, "id")
+		if c.update != nil {
+			if err := c.update(obj, patch); err != nil {
+				writeAPIError(w, err)
+				return
+			}
+		} else {
+			merge(obj, patch)
+		}
+		writeJSON(w, c.updateStatus, view(obj))
+	})
+
+	s.handle("DELETE", prefix+"/*", func(w http.ResponseWriter, r *http.Request, params []string) {
+		obj, ok := get(w, params)
+		if !ok {
+			return
+		}
+		if c.remove != nil {
+			if err := c.remove(obj); err != nil {
+				writeAPIError(w, err)
+				return
+			}
+		}
+		s.remove(c.kind, obj["id"].(string))
+
+		// Unwrapped resources are returned once more as they are deleted.
+		if c.singular == "" && c.deleteStatus != http.StatusNoContent {
+			writeJSON(w, c.deleteStatus, view(obj))
+			return
+		}
+		w.WriteHeader(c.deleteStatus)
+	})
+}
@@ -0,0 +1,139 @@
+package fakecloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func testRequest(t *testing.T, method, url, token string, body interface{}, v interface{}) *http.Response {
+	var reader bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = *bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, url, &reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Auth-Token", token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("Error decoding the response of %s %s: %s", method, url, err)
+		}
+	}
+	return resp
+}
+
+func testToken(t *testing.T, s *Server) string {
+	body := object{
+		"auth": object{
+			"identity": object{
+				"methods": []string{"password"},
+				"password": object{
+					"user": object{
+						"name":     Username,
+						"password": Password,
+						"domain":   object{"name": DomainName},
+					},
+				},
+			},
+		},
+	}
+	resp := testRequest(t, "POST", s.URL+"/v3/auth/tokens", "", body, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a token, got %d", resp.StatusCode)
+	}
+	return resp.Header.Get("X-Subject-Token")
+}
+
+func TestServerAuth(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	url := s.URL + "/vpc/v2.0/networks"
+	if resp := testRequest(t, "GET", url, "", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without a token, got %d", resp.StatusCode)
+	}
+
+	var networks struct {
+		Networks []object `json:"networks"`
+	}
+	resp := testRequest(t, "GET", url, testToken(t, s), nil, &networks)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 with a token, got %d", resp.StatusCode)
+	}
+	if len(networks.Networks) != 2 {
+		t.Fatalf("Expected the 2 seeded networks, got %v", networks.Networks)
+	}
+}
+
+func TestServerNetworking(t *testing.T) {
+	s := New()
+	defer s.Close()
+	token := testToken(t, s)
+
+	var port struct {
+		Port struct {
+			ID       string                   `json:"id"`
+			FixedIPs []map[string]interface{} `json:"fixed_ips"`
+		} `json:"port"`
+	}
+	resp := testRequest(t, "POST", s.URL+"/vpc/v2.0/ports", token, object{
+		"port": object{"network_id": NetworkID},
+	}, &port)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a port, got %d", resp.StatusCode)
+	}
+	if len(port.Port.FixedIPs) != 1 || port.Port.FixedIPs[0]["ip_address"] != "192.168.0.2" {
+		t.Fatalf("Expected 192.168.0.2 to be allocated, got %v", port.Port.FixedIPs)
+	}
+
+	resp = testRequest(t, "DELETE", s.URL+"/vpc/v2.0/networks/"+NetworkID, token, nil, nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected 409 deleting a network in use, got %d", resp.StatusCode)
+	}
+
+	resp = testRequest(t, "DELETE", s.URL+"/vpc/v2.0/ports/"+port.Port.ID, token, nil, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected 204 deleting a port, got %d", resp.StatusCode)
+	}
+
+	resp = testRequest(t, "GET", s.URL+"/vpc/v2.0/ports/"+port.Port.ID, token, nil, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404 for a deleted port, got %d", resp.StatusCode)
+	}
+}
+
+func TestServerNotImplemented(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	resp := testRequest(t, "GET", s.URL+"/ecs/v2/"+ProjectID+"/os-hypervisors", testToken(t, s), nil, &body)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404 for an unknown API, got %d", resp.StatusCode)
+	}
+	if body.Error.Message == "" {
+		t.Fatal("Expected the error to say what isn't implemented")
+	}
+}
@@ -0,0 +1,158 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// catalogEndpoints are the paths of the services in the catalog. The ELB and
+// SMN clients derive their endpoints from the compute one by replacing "ecs".
+var catalogEndpoints = map[string]string{
+	"identity": "/v3/",
+	"compute":  "/ecs/v2/" + ProjectID + "/",
+	"network":  "/vpc/",
+	"volumev2": "/evs/v2/" + ProjectID + "/",
+	"image":    "/ims/",
+	"dns":      "/dns/",
+	"as":       "/as/autoscaling-api/v1/",
+	"ces":      "/ces/V1.0/",
+}
+
+func (s *Server) identityRoutes() {
+	s.handle("POST", "/v3/auth/tokens", s.createToken)
+	s.handle("GET", "/v3/auth/tokens", s.getToken)
+	s.handle("GET", "/v3/projects", s.listProjects)
+}
+
+// authorized reports whether a request carries a token issued by the fake
+// cloud or an AK/SK signature. Signatures aren't verified.
+func (s *Server) authorized(r *http.Request) bool {
+	if strings.HasPrefix(r.Header.Get("Authorization"), "SDK-HMAC-SHA256 ") {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[r.Header.Get("X-Auth-Token")]
+}
+
+func (s *Server) createToken(w http.ResponseWriter, r *http.Request, params []string) {
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	identity, _ := lookup(body, "auth", "identity").(map[string]interface{})
+	methods, _ := identity["methods"].([]interface{})
+	if len(methods) != 1 {
+		writeError(w, http.StatusBadRequest, "Exactly one authentication method is supported")
+		return
+	}
+
+	switch methods[0] {
+	case "password":
+		name, _ := lookup(identity, "password", "user", "name").(string)
+		id, _ := lookup(identity, "password", "user", "id").(string)
+		password, _ := lookup(identity, "password", "user", "password").(string)
+		if (name != Username && id != UserID) || password != Password {
+			writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+			return
+		}
+	case "token":
+		if id, _ := lookup(identity, "token", "id").(string); !s.tokens[id] {
+			writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported authentication method %v", methods[0]))
+		return
+	}
+
+	token := "fake-token-" + s.newID()
+	s.tokens[token] = true
+
+	w.Header().Set("X-Subject-Token", token)
+	writeJSON(w, http.StatusCreated, s.tokenBody(r))
+}
+
+func (s *Server) getToken(w http.ResponseWriter, r *http.Request, params []string) {
+	if !s.tokens[r.Header.Get("X-Subject-Token")] {
+		writeError(w, http.StatusNotFound, "The token could not be found.")
+		return
+	}
+
+	w.Header().Set("X-Subject-Token", r.Header.Get("X-Subject-Token"))
+	writeJSON(w, http.StatusOK, s.tokenBody(r))
+}
+
+func (s *Server) tokenBody(r *http.Request) object {
+	var catalog []object
+	for serviceType, path := range catalogEndpoints {
+		catalog = append(catalog, object{
+			"type": serviceType,
+			"name": serviceType,
+			"endpoints": []object{{
+				"id":        serviceType + "-public",
+				"interface": "public",
+				"region":    Region,
+				"region_id": Region,
+				"url":       "http://" + r.Host + path,
+			}},
+		})
+	}
+
+	return object{
+		"token": object{
+			"methods":    []string{"password"},
+			"expires_at": time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+			"issued_at":  time.Now().UTC().Format(time.RFC3339),
+			"user": object{
+				"id":     UserID,
+				"name":   Username,
+				"domain": object{"id": DomainName, "name": DomainName},
+			},
+			"project": object{
+				"id":     ProjectID,
+				"name":   ProjectName,
+				"domain": object{"id": DomainName, "name": DomainName},
+			},
+			"roles":   []object{{"id": "admin", "name": "admin"}},
+			"catalog": catalog,
+		},
+	}
+}
+
+// listProjects serves the lookup of the project of a region done with
+// AK/SK. The project of the region and ProjectName are the same.
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request, params []string) {
+	projects := []object{}
+	if name := r.URL.Query().Get("name"); name == "" || name == Region || name == ProjectName {
+		projects = append(projects, object{
+			"id":        ProjectID,
+			"name":      ProjectName,
+			"domain_id": DomainName,
+			"enabled":   true,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, object{"projects": projects})
+}
+
+// lookup returns the value at the path of nested JSON objects, or nil.
+func lookup(v interface{}, path ...string) interface{} {
+	for _, key := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			if o, isObject := v.(object); isObject {
+				m = o
+			} else {
+				return nil
+			}
+		}
+		v = m[key]
+	}
+	return v
+}
@@ -0,0 +1,399 @@
+package fakecloud
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const imagePrefix = "/ims/v2/images"
+
+func (s *Server) imageRoutes() {
+	s.handle("GET", imagePrefix, s.listImages)
+	s.handle("POST", imagePrefix, s.createImage)
+	s.handle("GET", imagePrefix+"/*", s.getImage)
+	s.handle("PATCH", imagePrefix+"/*", s.updateImage)
+	s.handle("DELETE", imagePrefix+"/*", s.deleteImage)
+	s.handle("PUT", imagePrefix+"/*/file", s.uploadImageData)
+	s.handle("GET", imagePrefix+"/*/file", s.downloadImageData)
+	s.handle("GET", imagePrefix+"/*/members", s.listImageMembers)
+	s.handle("POST", imagePrefix+"/*/members", s.createImageMember)
+	s.handle("GET", imagePrefix+"/*/members/*", s.getImageMember)
+	s.handle("PUT", imagePrefix+"/*/members/*", s.updateImageMember)
+	s.handle("DELETE", imagePrefix+"/*/members/*", s.deleteImageMember)
+}
+
+// imageReadOnly are the image attributes Glance manages itself. Everything
+// else can be set on create and patched, including custom properties.
+var imageReadOnly = map[string]bool{
+	"id": true, "status": true, "size": true, "virtual_size": true, "checksum": true,
+	"owner": true, "created_at": true, "updated_at": true, "file": true, "schema": true,
+	"self": true, "direct_url": true, "locations": true,
+}
+
+// viewImage returns an image the way Glance does, with its links.
+func viewImage(image object) object {
+	view := public(image)
+	view["self"] = "/v2/images/" + image["id"].(string)
+	view["file"] = "/v2/images/" + image["id"].(string) + "/file"
+	view["schema"] = "/v2/schemas/image"
+	return view
+}
+
+// imageTime returns the current time the way Glance formats it. The
+// fractional seconds keep images created in the same second in order.
+func imageTime() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+func (s *Server) listImages(w http.ResponseWriter, r *http.Request, params []string) {
+	query := r.URL.Query()
+	filter := queryFilter(r, "limit", "marker", "sort", "sort_key", "sort_dir",
+		"tag", "size_min", "size_max", "member_status")
+
+	images := []object{}
+	for _, image := range s.list("image", filter) {
+		if !imageHasTags(image, query["tag"]) {
+			continue
+		}
+		size, _ := image["size"].(int)
+		if v, err := strconv.Atoi(query.Get("size_min")); err == nil && size < v {
+			continue
+		}
+		if v, err := strconv.Atoi(query.Get("size_max")); err == nil && size > v {
+			continue
+		}
+		images = append(images, viewImage(image))
+	}
+
+	if key := query.Get("sort_key"); key != "" {
+		desc := query.Get("sort_dir") == "desc"
+		sort.SliceStable(images, func(i, j int) bool {
+			if desc {
+				i, j = j, i
+			}
+			return fmt.Sprint(images[i][key]) < fmt.Sprint(images[j][key])
+		})
+	}
+
+	writeJSON(w, http.StatusOK, object{"images": images, "schema": "/v2/schemas/images"})
+}
+
+func imageHasTags(image object, tags []string) bool {
+	have := map[interface{}]bool{}
+	for _, t := range image["tags"].([]interface{}) {
+		have[t] = true
+	}
+	for _, t := range tags {
+		if !have[t] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) createImage(w http.ResponseWriter, r *http.Request, params []string) {
+	req, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if name, _ := req["name"].(string); name == "" {
+		writeError(w, http.StatusBadRequest, "An image requires a name")
+		return
+	}
+	for k := range req {
+		if imageReadOnly[k] && k != "id" {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("Attribute '%s' is read-only.", k))
+			return
+		}
+	}
+
+	image := object{}
+	for k, v := range req {
+		image[k] = v
+	}
+	if id, _ := image["id"].(string); id != "" {
+		if _, ok := s.get("image", id); ok {
+			writeError(w, http.StatusConflict, fmt.Sprintf("Image with identifier %s already exists!", id))
+			return
+		}
+	}
+	setDefault(image, "visibility", "private")
+	setDefault(image, "protected", false)
+	setDefault(image, "tags", []interface{}{})
+	setDefault(image, "min_disk", 0)
+	setDefault(image, "min_ram", 0)
+	setDefault(image, "container_format", nil)
+	setDefault(image, "disk_format", nil)
+	image["status"] = "queued"
+	image["owner"] = ProjectID
+	image["size"] = nil
+	image["checksum"] = nil
+	image["created_at"] = imageTime()
+	image["updated_at"] = image["created_at"]
+
+	s.add("image", image)
+	writeJSON(w, http.StatusCreated, viewImage(image))
+}
+
+func (s *Server) getImage(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+	writeJSON(w, http.StatusOK, viewImage(image))
+}
+
+// updateImage applies a JSON patch to an image, which is how Glance v2
+// updates images.
+func (s *Server) updateImage(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+
+	var ops []map[string]interface{}
+	if !readList(w, r, &ops) {
+		return
+	}
+
+	patched := object{}
+	for k, v := range image {
+		patched[k] = v
+	}
+	for _, op := range ops {
+		path, _ := op["path"].(string)
+		key := strings.TrimPrefix(path, "/")
+		if key == "" || strings.Contains(key, "/") {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid JSON pointer for this resource: '%s'", path))
+			return
+		}
+		key = strings.NewReplacer("~1", "/", "~0", "~").Replace(key)
+		if imageReadOnly[key] {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("Attribute '%s' is read-only.", key))
+			return
+		}
+
+		switch op["op"] {
+		case "add":
+			patched[key] = op["value"]
+		case "replace":
+			if _, ok := patched[key]; !ok {
+				writeError(w, http.StatusConflict, fmt.Sprintf("Property %s does not exist.", key))
+				return
+			}
+			patched[key] = op["value"]
+		case "remove":
+			if _, ok := patched[key]; !ok {
+				writeError(w, http.StatusConflict, fmt.Sprintf("Property %s does not exist.", key))
+				return
+			}
+			delete(patched, key)
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported patch operation %v", op["op"]))
+			return
+		}
+	}
+	if patched["tags"] == nil {
+		patched["tags"] = []interface{}{}
+	}
+
+	for k := range image {
+		delete(image, k)
+	}
+	merge(image, patched)
+	image["updated_at"] = imageTime()
+	writeJSON(w, http.StatusOK, viewImage(image))
+}
+
+func (s *Server) deleteImage(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+	if image["protected"] == true {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("Image %s is protected and cannot be deleted.", params[0]))
+		return
+	}
+	s.remove("image", params[0])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// uploadImageData stores the data of a queued image and makes it active.
+func (s *Server) uploadImageData(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+	if image["status"] != "queued" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Image status transition from %s to saving is not allowed", image["status"]))
+		return
+	}
+	if image["container_format"] == nil || image["disk_format"] == nil {
+		writeError(w, http.StatusBadRequest, "Properties container_format and disk_format must be set prior to saving data.")
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	image["_data"] = data
+	image["size"] = len(data)
+	image["checksum"] = fmt.Sprintf("%x", md5.Sum(data))
+	image["status"] = "active"
+	image["updated_at"] = imageTime()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) downloadImageData(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+	data, ok := image["_data"].([]byte)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Md5", fmt.Sprint(image["checksum"]))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// imageMembers returns the members of an image by project ID. They are kept
+// on the image, so they go away with it. Every request is made by the one
+// project of the fake cloud, so it acts both as the owner of the image and
+// as its members.
+func imageMembers(image object) map[string]object {
+	members, ok := image["_members"].(map[string]object)
+	if !ok {
+		members = map[string]object{}
+		image["_members"] = members
+	}
+	return members
+}
+
+func (s *Server) listImageMembers(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+
+	members := []object{}
+	for _, member := range imageMembers(image) {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i]["member_id"].(string) < members[j]["member_id"].(string)
+	})
+	writeJSON(w, http.StatusOK, object{"members": members, "schema": "/v2/schemas/members"})
+}
+
+func (s *Server) createImageMember(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+	req, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	memberID, _ := req["member"].(string)
+	if memberID == "" {
+		writeError(w, http.StatusBadRequest, "Member to be added not specified")
+		return
+	}
+	if image["visibility"] != "private" && image["visibility"] != "shared" {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("Image %s is %s and can't be shared.", params[0], image["visibility"]))
+		return
+	}
+
+	members := imageMembers(image)
+	if _, ok := members[memberID]; ok {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Member %s already associated with image %s.", memberID, params[0]))
+		return
+	}
+	member := object{
+		"image_id":   params[0],
+		"member_id":  memberID,
+		"status":     "pending",
+		"schema":     "/v2/schemas/member",
+		"created_at": imageTime(),
+	}
+	member["updated_at"] = member["created_at"]
+	members[memberID] = member
+	writeJSON(w, http.StatusOK, member)
+}
+
+func (s *Server) getImageMember(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+	member, ok := imageMembers(image)[params[1]]
+	if !ok {
+		writeNotFound(w, "Member", params[1])
+		return
+	}
+	writeJSON(w, http.StatusOK, member)
+}
+
+func (s *Server) updateImageMember(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+	member, ok := imageMembers(image)[params[1]]
+	if !ok {
+		writeNotFound(w, "Member", params[1])
+		return
+	}
+	req, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch status := req["status"]; status {
+	case "pending", "accepted", "rejected":
+		member["status"] = status
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid status: %v", status))
+		return
+	}
+	member["updated_at"] = imageTime()
+	writeJSON(w, http.StatusOK, member)
+}
+
+func (s *Server) deleteImageMember(w http.ResponseWriter, r *http.Request, params []string) {
+	image, ok := s.get("image", params[0])
+	if !ok {
+		writeNotFound(w, "Image", params[0])
+		return
+	}
+	members := imageMembers(image)
+	if _, ok := members[params[1]]; !ok {
+		writeNotFound(w, "Member", params[1])
+		return
+	}
+	delete(members, params[1])
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,674 @@
+package fakecloud
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	networkPrefix = "/vpc/v2.0"
+	vpcPrefix     = "/vpc/v1/*"
+
+	externalSubnetID   = "c0ffee00-0000-4000-8000-000000000005"
+	subnetID           = "c0ffee00-0000-4000-8000-000000000006"
+	defaultSecGroupID  = "c0ffee00-0000-4000-8000-000000000007"
+	routerInterfaceOwn = "network:router_interface"
+)
+
+func (s *Server) networkRoutes() {
+	s.serve(networkPrefix+"/networks", &collection{
+		kind:     "network",
+		singular: "network",
+		plural:   "networks",
+		ignore:   []string{"fields", "limit", "marker"},
+		create:   s.createNetwork,
+		remove:   s.removeNetwork,
+	})
+
+	s.serve(networkPrefix+"/subnets", &collection{
+		kind:     "subnet",
+		singular: "subnet",
+		plural:   "subnets",
+		ignore:   []string{"fields", "limit", "marker"},
+		create:   s.createSubnet,
+		remove:   s.removeSubnet,
+	})
+
+	s.serve(networkPrefix+"/ports", &collection{
+		kind:     "port",
+		singular: "port",
+		plural:   "ports",
+		ignore:   []string{"fields", "limit", "marker"},
+		create:   s.createPort,
+		update:   s.updatePort,
+	})
+
+	s.serve(networkPrefix+"/routers", &collection{
+		kind:     "router",
+		singular: "router",
+		plural:   "routers",
+		ignore:   []string{"fields", "limit", "marker"},
+		create:   s.createRouter,
+		remove:   s.removeRouter,
+	})
+	s.handle("PUT", networkPrefix+"/routers/*/add_router_interface", s.addRouterInterface)
+	s.handle("PUT", networkPrefix+"/routers/*/remove_router_interface", s.removeRouterInterface)
+
+	s.serve(networkPrefix+"/security-groups", &collection{
+		kind:     "security_group",
+		singular: "security_group",
+		plural:   "security_groups",
+		ignore:   []string{"fields", "limit", "marker"},
+		create:   s.createSecurityGroup,
+		remove:   s.removeSecurityGroup,
+		view:     s.viewSecurityGroup,
+	})
+
+	s.serve(networkPrefix+"/security-group-rules", &collection{
+		kind:     "security_group_rule",
+		singular: "security_group_rule",
+		plural:   "security_group_rules",
+		ignore:   []string{"fields", "limit", "marker"},
+		create:   s.createSecurityGroupRule,
+	})
+
+	s.serve(networkPrefix+"/floatingips", &collection{
+		kind:     "floatingip",
+		singular: "floatingip",
+		plural:   "floatingips",
+		ignore:   []string{"fields", "limit", "marker"},
+		create:   s.createFloatingIP,
+		update:   s.updateFloatingIP,
+	})
+
+	s.handle("POST", vpcPrefix+"/publicips", s.applyPublicIP)
+	s.serve(vpcPrefix+"/publicips", &collection{
+		kind:         "publicip",
+		singular:     "publicip",
+		plural:       "publicips",
+		ignore:       []string{"limit", "marker"},
+		createStatus: http.StatusOK,
+		update:       s.updatePublicIP,
+	})
+	s.serve(vpcPrefix+"/bandwidths", &collection{
+		kind:     "bandwidth",
+		singular: "bandwidth",
+		plural:   "bandwidths",
+	})
+}
+
+// seedNetwork creates the external network, the network and the VPC the
+// acceptance tests expect, and the default security group.
+func (s *Server) seedNetwork() {
+	s.add("network", object{
+		"id":              ExternalNetworkID,
+		"name":            ExternalNetwork,
+		"router:external": true,
+		"shared":          true,
+	})
+	s.add("network", object{
+		"id":   NetworkID,
+		"name": "fake-network",
+	})
+	for _, n := range s.objects["network"] {
+		s.createNetwork(n)
+	}
+
+	for _, subnet := range []object{
+		{"id": externalSubnetID, "name": "fake-external-subnet", "network_id": ExternalNetworkID, "cidr": "100.64.0.0/16"},
+		{"id": subnetID, "name": "fake-subnet", "network_id": NetworkID, "cidr": "192.168.0.0/24"},
+	} {
+		s.createSubnet(subnet)
+		s.add("subnet", subnet)
+	}
+
+	router := object{"id": VPCID, "name": "fake-vpc"}
+	s.createRouter(router)
+	s.add("router", router)
+
+	sg := object{"id": defaultSecGroupID, "name": "default", "description": "Default security group"}
+	s.createSecurityGroup(sg)
+	s.add("security_group", sg)
+}
+
+func (s *Server) createNetwork(obj object) error {
+	setDefault(obj, "name", "")
+	setDefault(obj, "admin_state_up", true)
+	setDefault(obj, "shared", false)
+	setDefault(obj, "router:external", false)
+	setDefault(obj, "status", "ACTIVE")
+	setDefault(obj, "subnets", []interface{}{})
+	setDefault(obj, "availability_zone_hints", []interface{}{})
+	setDefault(obj, "availability_zones", []interface{}{AvailabilityZone})
+	setDefault(obj, "tenant_id", ProjectID)
+	setDefault(obj, "project_id", ProjectID)
+	return nil
+}
+
+func (s *Server) removeNetwork(obj object) error {
+	id := obj["id"].(string)
+	for _, p := range s.list("port", nil) {
+		if p["network_id"] == id && p["device_owner"] != "network:dhcp" {
+			return conflict("Unable to complete operation on network %s. There are one or more ports still in use on the network.", id)
+		}
+	}
+	for _, subnet := range s.list("subnet", nil) {
+		if subnet["network_id"] == id {
+			s.remove("subnet", subnet["id"].(string))
+		}
+	}
+	return nil
+}
+
+func (s *Server) createSubnet(obj object) error {
+	network, ok := s.get("network", fmt.Sprint(obj["network_id"]))
+	if !ok {
+		return notFound("Network %v could not be found.", obj["network_id"])
+	}
+
+	_, cidr, err := net.ParseCIDR(fmt.Sprint(obj["cidr"]))
+	if err != nil {
+		return badRequest("Invalid CIDR %v for subnet", obj["cidr"])
+	}
+	obj["cidr"] = cidr.String()
+
+	// An explicit null gateway means the subnet has none.
+	if _, ok := obj["gateway_ip"]; !ok {
+		obj["gateway_ip"] = ipAdd(cidr.IP, 1).String()
+	}
+	if pools, _ := obj["allocation_pools"].([]interface{}); len(pools) == 0 {
+		ones, bits := cidr.Mask.Size()
+		size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+		last := int64(2)
+		if size.IsInt64() && size.Int64() > 3 {
+			last = size.Int64() - 2
+		}
+		obj["allocation_pools"] = []interface{}{
+			map[string]interface{}{
+				"start": ipAdd(cidr.IP, 2).String(),
+				"end":   ipAdd(cidr.IP, last).String(),
+			},
+		}
+	}
+
+	ipVersion := 4
+	if cidr.IP.To4() == nil {
+		ipVersion = 6
+	}
+	setDefault(obj, "ip_version", ipVersion)
+	setDefault(obj, "name", "")
+	setDefault(obj, "enable_dhcp", true)
+	setDefault(obj, "dns_nameservers", []interface{}{})
+	setDefault(obj, "host_routes", []interface{}{})
+	setDefault(obj, "tenant_id", ProjectID)
+	setDefault(obj, "project_id", ProjectID)
+	setDefault(obj, "ipv6_address_mode", "")
+	setDefault(obj, "ipv6_ra_mode", "")
+	setDefault(obj, "subnetpool_id", "")
+
+	if obj["id"] == nil {
+		obj["id"] = s.newID()
+	}
+	network["subnets"] = append(network["subnets"].([]interface{}), obj["id"])
+	return nil
+}
+
+func (s *Server) removeSubnet(obj object) error {
+	id := obj["id"].(string)
+	for _, p := range s.list("port", nil) {
+		for _, ip := range p["fixed_ips"].([]interface{}) {
+			if ip.(map[string]interface{})["subnet_id"] == id {
+				return conflict("Unable to complete operation on subnet %s: One or more ports have an IP allocation from this subnet.", id)
+			}
+		}
+	}
+
+	if network, ok := s.get("network", fmt.Sprint(obj["network_id"])); ok {
+		network["subnets"] = without(network["subnets"].([]interface{}), id)
+	}
+	return nil
+}
+
+func (s *Server) createPort(obj object) error {
+	network, ok := s.get("network", fmt.Sprint(obj["network_id"]))
+	if !ok {
+		return notFound("Network %v could not be found.", obj["network_id"])
+	}
+
+	if obj["id"] == nil {
+		obj["id"] = s.newID()
+	}
+	setDefault(obj, "name", "")
+	setDefault(obj, "admin_state_up", true)
+	setDefault(obj, "status", "ACTIVE")
+	setDefault(obj, "mac_address", fmt.Sprintf("fa:16:3e:00:%02x:%02x", s.nextID/256%256, s.nextID%256))
+	setDefault(obj, "device_id", "")
+	setDefault(obj, "device_owner", "")
+	setDefault(obj, "allowed_address_pairs", []interface{}{})
+	setDefault(obj, "tenant_id", ProjectID)
+	setDefault(obj, "project_id", ProjectID)
+	setDefault(obj, "created_at", time.Now().UTC().Format(time.RFC3339))
+	if _, ok := obj["security_groups"]; !ok {
+		obj["security_groups"] = []interface{}{defaultSecGroupID}
+	}
+
+	// Without fixed IPs the port gets one from the first subnet, with an
+	// empty list it gets none.
+	fixedIPs, ok := obj["fixed_ips"].([]interface{})
+	if !ok {
+		subnets := network["subnets"].([]interface{})
+		if len(subnets) > 0 {
+			fixedIPs = []interface{}{map[string]interface{}{"subnet_id": subnets[0]}}
+		}
+	}
+	allocated, err := s.allocateFixedIPs(obj, fixedIPs)
+	if err != nil {
+		return err
+	}
+	obj["fixed_ips"] = allocated
+	return nil
+}
+
+func (s *Server) updatePort(obj, patch object) error {
+	if fixedIPs, ok := patch["fixed_ips"].([]interface{}); ok {
+		allocated, err := s.allocateFixedIPs(obj, fixedIPs)
+		if err != nil {
+			return err
+		}
+		patch["fixed_ips"] = allocated
+	}
+	merge(obj, patch)
+	return nil
+}
+
+// allocateFixedIPs fills in the subnet or the address of each fixed IP of a
+// port.
+func (s *Server) allocateFixedIPs(port object, fixedIPs []interface{}) ([]interface{}, error) {
+	allocated := []interface{}{}
+	for _, raw := range fixedIPs {
+		ip := raw.(map[string]interface{})
+		subnetID, _ := ip["subnet_id"].(string)
+		address, _ := ip["ip_address"].(string)
+
+		if subnetID == "" {
+			for _, subnet := range s.list("subnet", nil) {
+				_, cidr, _ := net.ParseCIDR(subnet["cidr"].(string))
+				if subnet["network_id"] == port["network_id"] && cidr.Contains(net.ParseIP(address)) {
+					subnetID = subnet["id"].(string)
+				}
+			}
+		}
+		subnet, ok := s.get("subnet", subnetID)
+		if !ok {
+			return nil, badRequest("No subnet of network %v contains the fixed IP %v", port["network_id"], raw)
+		}
+
+		if address == "" {
+			address = s.allocateIP(subnet, port["id"].(string))
+		} else if owner := s.ipOwner(subnetID, address); owner != "" && owner != port["id"] {
+			return nil, conflict("IP address %s already allocated in subnet %s", address, subnetID)
+		}
+
+		allocated = append(allocated, map[string]interface{}{
+			"subnet_id":  subnetID,
+			"ip_address": address,
+		})
+	}
+	return allocated, nil
+}
+
+// ipOwner returns the ID of the port which has an address of a subnet.
+func (s *Server) ipOwner(subnetID, address string) string {
+	for _, p := range s.list("port", nil) {
+		for _, raw := range p["fixed_ips"].([]interface{}) {
+			ip := raw.(map[string]interface{})
+			if ip["subnet_id"] == subnetID && ip["ip_address"] == address {
+				return p["id"].(string)
+			}
+		}
+	}
+	return ""
+}
+
+// allocateIP returns the first free address of a subnet's allocation pool.
+func (s *Server) allocateIP(subnet object, portID string) string {
+	pools := subnet["allocation_pools"].([]interface{})
+	for _, raw := range pools {
+		pool := raw.(map[string]interface{})
+		start := net.ParseIP(pool["start"].(string))
+		end := net.ParseIP(pool["end"].(string))
+		for ip := start; ipCompare(ip, end) <= 0; ip = ipAdd(ip, 1) {
+			if s.ipOwner(subnet["id"].(string), ip.String()) == "" && !s.floatingIPInUse(ip.String()) {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Server) floatingIPInUse(address string) bool {
+	for _, f := range s.list("floatingip", nil) {
+		if f["floating_ip_address"] == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) createRouter(obj object) error {
+	setDefault(obj, "name", "")
+	setDefault(obj, "admin_state_up", true)
+	setDefault(obj, "status", "ACTIVE")
+	setDefault(obj, "distributed", false)
+	setDefault(obj, "routes", []interface{}{})
+	setDefault(obj, "tenant_id", ProjectID)
+	setDefault(obj, "project_id", ProjectID)
+	setDefault(obj, "external_gateway_info", nil)
+	return nil
+}
+
+func (s *Server) removeRouter(obj object) error {
+	for _, p := range s.list("port", nil) {
+		if p["device_id"] == obj["id"] && p["device_owner"] == routerInterfaceOwn {
+			return conflict("Router %s still has ports", obj["id"])
+		}
+	}
+	return nil
+}
+
+func (s *Server) addRouterInterface(w http.ResponseWriter, r *http.Request, params []string) {
+	router, ok := s.get("router", params[0])
+	if !ok {
+		writeNotFound(w, "Router", params[0])
+		return
+	}
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var port object
+	if portID, _ := body["port_id"].(string); portID != "" {
+		port, ok = s.get("port", portID)
+		if !ok {
+			writeNotFound(w, "Port", portID)
+			return
+		}
+	} else {
+		subnet, ok := s.get("subnet", fmt.Sprint(body["subnet_id"]))
+		if !ok {
+			writeNotFound(w, "Subnet", fmt.Sprint(body["subnet_id"]))
+			return
+		}
+		fixedIP := map[string]interface{}{"subnet_id": subnet["id"]}
+		if gateway, ok := subnet["gateway_ip"].(string); ok {
+			fixedIP["ip_address"] = gateway
+		}
+		port = object{
+			"network_id": subnet["network_id"],
+			"fixed_ips":  []interface{}{fixedIP},
+		}
+		if err := s.createPort(port); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		s.add("port", port)
+	}
+
+	port["device_id"] = router["id"]
+	port["device_owner"] = routerInterfaceOwn
+
+	subnetID := port["fixed_ips"].([]interface{})[0].(map[string]interface{})["subnet_id"]
+	writeJSON(w, http.StatusOK, object{
+		"id":         router["id"],
+		"subnet_id":  subnetID,
+		"subnet_ids": []interface{}{subnetID},
+		"port_id":    port["id"],
+		"tenant_id":  ProjectID,
+	})
+}
+
+func (s *Server) removeRouterInterface(w http.ResponseWriter, r *http.Request, params []string) {
+	router, ok := s.get("router", params[0])
+	if !ok {
+		writeNotFound(w, "Router", params[0])
+		return
+	}
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, p := range s.list("port", nil) {
+		if p["device_id"] != router["id"] || p["device_owner"] != routerInterfaceOwn {
+			continue
+		}
+		subnetID := p["fixed_ips"].([]interface{})[0].(map[string]interface{})["subnet_id"]
+		if p["id"] == body["port_id"] || subnetID == body["subnet_id"] {
+			s.remove("port", p["id"].(string))
+			writeJSON(w, http.StatusOK, object{
+				"id":         router["id"],
+				"subnet_id":  subnetID,
+				"subnet_ids": []interface{}{subnetID},
+				"port_id":    p["id"],
+				"tenant_id":  ProjectID,
+			})
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Router %s has no interface %v", router["id"], body))
+}
+
+func (s *Server) createSecurityGroup(obj object) error {
+	setDefault(obj, "description", "")
+	setDefault(obj, "tenant_id", ProjectID)
+	setDefault(obj, "project_id", ProjectID)
+
+	if obj["id"] == nil {
+		obj["id"] = s.newID()
+	}
+
+	// Like Neutron, every new group allows all egress traffic.
+	for _, ethertype := range []string{"IPv4", "IPv6"} {
+		rule := object{
+			"security_group_id": obj["id"],
+			"direction":         "egress",
+			"ethertype":         ethertype,
+		}
+		s.createSecurityGroupRule(rule)
+		s.add("security_group_rule", rule)
+	}
+	return nil
+}
+
+func (s *Server) removeSecurityGroup(obj object) error {
+	for _, rule := range s.list("security_group_rule", nil) {
+		if rule["security_group_id"] == obj["id"] {
+			s.remove("security_group_rule", rule["id"].(string))
+		}
+	}
+	return nil
+}
+
+func (s *Server) viewSecurityGroup(obj object) object {
+	rules := []interface{}{}
+	for _, rule := range s.list("security_group_rule", nil) {
+		if rule["security_group_id"] == obj["id"] {
+			rules = append(rules, rule)
+		}
+	}
+	return merge(object{"security_group_rules": rules}, obj)
+}
+
+func (s *Server) createSecurityGroupRule(obj object) error {
+	if _, ok := s.get("security_group", fmt.Sprint(obj["security_group_id"])); !ok && obj["security_group_id"] == nil {
+		return badRequest("security_group_id is required")
+	}
+	setDefault(obj, "ethertype", "IPv4")
+	setDefault(obj, "protocol", nil)
+	setDefault(obj, "port_range_min", nil)
+	setDefault(obj, "port_range_max", nil)
+	setDefault(obj, "remote_ip_prefix", nil)
+	setDefault(obj, "remote_group_id", nil)
+	setDefault(obj, "description", "")
+	setDefault(obj, "tenant_id", ProjectID)
+	setDefault(obj, "project_id", ProjectID)
+	return nil
+}
+
+func (s *Server) createFloatingIP(obj object) error {
+	network, ok := s.get("network", fmt.Sprint(obj["floating_network_id"]))
+	if !ok {
+		return notFound("External network %v could not be found.", obj["floating_network_id"])
+	}
+	subnets := network["subnets"].([]interface{})
+	if len(subnets) == 0 {
+		return badRequest("External network %v has no subnet", network["id"])
+	}
+	subnet, _ := s.get("subnet", subnets[0].(string))
+
+	if address, _ := obj["floating_ip_address"].(string); address == "" {
+		obj["floating_ip_address"] = s.allocateIP(subnet, "")
+	}
+	setDefault(obj, "tenant_id", ProjectID)
+	setDefault(obj, "project_id", ProjectID)
+	setDefault(obj, "description", "")
+	return s.updateFloatingIP(obj, object{"port_id": obj["port_id"], "fixed_ip_address": obj["fixed_ip_address"]})
+}
+
+// updateFloatingIP associates a floating IP with a port or disassociates it.
+func (s *Server) updateFloatingIP(obj, patch object) error {
+	merge(obj, patch)
+
+	portID, _ := obj["port_id"].(string)
+	if portID == "" {
+		obj["port_id"] = nil
+		obj["fixed_ip_address"] = nil
+		obj["router_id"] = nil
+		obj["status"] = "DOWN"
+		return nil
+	}
+
+	port, ok := s.get("port", portID)
+	if !ok {
+		return notFound("Port %s could not be found.", portID)
+	}
+	if address, _ := obj["fixed_ip_address"].(string); address == "" {
+		if fixedIPs := port["fixed_ips"].([]interface{}); len(fixedIPs) > 0 {
+			obj["fixed_ip_address"] = fixedIPs[0].(map[string]interface{})["ip_address"]
+		}
+	}
+	obj["router_id"] = VPCID
+	obj["status"] = "ACTIVE"
+	return nil
+}
+
+// applyPublicIP serves the VPC v1 API, which takes the EIP and its
+// bandwidth at once.
+func (s *Server) applyPublicIP(w http.ResponseWriter, r *http.Request, params []string) {
+	body, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ip, _ := body["publicip"].(map[string]interface{})
+	bw, _ := body["bandwidth"].(map[string]interface{})
+	if ip == nil || bw == nil {
+		writeError(w, http.StatusBadRequest, "publicip and bandwidth are required")
+		return
+	}
+
+	bandwidth := s.add("bandwidth", object{
+		"name":           bw["name"],
+		"size":           bw["size"],
+		"share_type":     bw["share_type"],
+		"charge_mode":    bw["charge_mode"],
+		"bandwidth_type": "bgp",
+		"tenant_id":      ProjectID,
+	})
+
+	network, _ := s.get("network", ExternalNetworkID)
+	subnet, _ := s.get("subnet", network["subnets"].([]interface{})[0].(string))
+	address, _ := ip["ip_address"].(string)
+	if address == "" {
+		address = s.allocateIP(subnet, "")
+	}
+	floatingIP := s.add("floatingip", object{
+		"floating_network_id": ExternalNetworkID,
+		"floating_ip_address": address,
+		"status":              "DOWN",
+		"tenant_id":           ProjectID,
+	})
+
+	publicIP := s.add("publicip", object{
+		"id":                   floatingIP["id"],
+		"status":               "DOWN",
+		"type":                 ip["type"],
+		"public_ip_address":    address,
+		"private_ip_address":   "",
+		"port_id":              "",
+		"tenant_id":            ProjectID,
+		"create_time":          now(),
+		"bandwidth_id":         bandwidth["id"],
+		"bandwidth_size":       bandwidth["size"],
+		"bandwidth_share_type": bandwidth["share_type"],
+	})
+
+	writeJSON(w, http.StatusOK, object{"publicip": publicIP})
+}
+
+// updatePublicIP binds an EIP to a port or unbinds it, which the Neutron
+// API sees as an update of the floating IP.
+func (s *Server) updatePublicIP(obj, patch object) error {
+	portID, _ := patch["port_id"].(string)
+	if f, ok := s.get("floatingip", obj["id"].(string)); ok {
+		if err := s.updateFloatingIP(f, object{"port_id": portID, "fixed_ip_address": nil}); err != nil {
+			return err
+		}
+		obj["private_ip_address"] = f["fixed_ip_address"]
+	}
+
+	obj["port_id"] = portID
+	if portID == "" {
+		obj["status"] = "DOWN"
+		obj["private_ip_address"] = ""
+	} else {
+		obj["status"] = "ACTIVE"
+	}
+	return nil
+}
+
+// ipAdd returns ip plus n.
+func ipAdd(ip net.IP, n int64) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	i := new(big.Int).SetBytes(ip)
+	i.Add(i, big.NewInt(n))
+
+	b := i.Bytes()
+	result := make(net.IP, len(ip))
+	copy(result[len(result)-len(b):], b)
+	return result
+}
+
+func ipCompare(a, b net.IP) int {
+	return new(big.Int).SetBytes(a.To16()).Cmp(new(big.Int).SetBytes(b.To16()))
+}
+
+// without returns the list without the given value.
+func without(list []interface{}, value interface{}) []interface{} {
+	result := []interface{}{}
+	for _, v := range list {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,193 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// computeQuotaDefaults are the compute quotas of a project until they're
+// changed. -1 means unlimited.
+var computeQuotaDefaults = map[string]int{
+	"instances":                   10,
+	"cores":                       20,
+	"ram":                         51200,
+	"key_pairs":                   100,
+	"server_groups":               10,
+	"server_group_members":        10,
+	"floating_ips":                10,
+	"fixed_ips":                   -1,
+	"security_groups":             10,
+	"security_group_rules":        20,
+	"metadata_items":              128,
+	"injected_files":              5,
+	"injected_file_content_bytes": 10240,
+	"injected_file_path_bytes":    255,
+}
+
+// computeLimitNames are the names the limits API gives the quotas, and the
+// usage when it reports one.
+var computeLimitNames = map[string][2]string{
+	"instances":                   {"maxTotalInstances", "totalInstancesUsed"},
+	"cores":                       {"maxTotalCores", "totalCoresUsed"},
+	"ram":                         {"maxTotalRAMSize", "totalRAMUsed"},
+	"key_pairs":                   {"maxTotalKeypairs", ""},
+	"server_groups":               {"maxServerGroups", "totalServerGroupsUsed"},
+	"server_group_members":        {"maxServerGroupMembers", ""},
+	"floating_ips":                {"maxTotalFloatingIps", "totalFloatingIpsUsed"},
+	"security_groups":             {"maxSecurityGroups", "totalSecurityGroupsUsed"},
+	"security_group_rules":        {"maxSecurityGroupRules", ""},
+	"metadata_items":              {"maxServerMeta", ""},
+	"injected_files":              {"maxPersonality", ""},
+	"injected_file_content_bytes": {"maxPersonalitySize", ""},
+}
+
+func (s *Server) computeQuotaRoutes() {
+	s.handle("GET", computePrefix+"/limits", s.getLimits)
+	s.handle("GET", computePrefix+"/os-quota-sets/*", s.getComputeQuota)
+	s.handle("GET", computePrefix+"/os-quota-sets/*/detail", s.getComputeQuotaDetail)
+	s.handle("PUT", computePrefix+"/os-quota-sets/*", s.updateComputeQuota)
+	s.handle("DELETE", computePrefix+"/os-quota-sets/*", s.deleteComputeQuota)
+}
+
+// computeQuota returns the compute quotas of a project.
+func (s *Server) computeQuota(projectID string) map[string]int {
+	quota := map[string]int{}
+	for k, v := range computeQuotaDefaults {
+		quota[k] = v
+	}
+	if changed, ok := s.get("compute_quota", projectID); ok {
+		for k, v := range changed {
+			if n, ok := v.(int); ok {
+				quota[k] = n
+			}
+		}
+	}
+	return quota
+}
+
+// computeUsage returns what the resources of the fake cloud use of the
+// compute quotas. Every resource belongs to the one project.
+func (s *Server) computeUsage() map[string]int {
+	usage := map[string]int{}
+	for k := range computeQuotaDefaults {
+		usage[k] = 0
+	}
+
+	for _, server := range s.list("server", nil) {
+		usage["instances"]++
+		ref, _ := server["flavor"].(map[string]interface{})
+		if flavor, ok := s.get("flavor", fmt.Sprint(ref["id"])); ok {
+			usage["cores"] += flavor["vcpus"].(int)
+			usage["ram"] += flavor["ram"].(int)
+		}
+	}
+	usage["key_pairs"] = len(s.list("keypair", nil))
+	usage["server_groups"] = len(s.list("server_group", nil))
+	usage["floating_ips"] = len(s.list("floatingip", nil))
+	usage["security_groups"] = len(s.list("compute_secgroup", nil))
+	usage["security_group_rules"] = len(s.list("compute_secgroup_rule", nil))
+	return usage
+}
+
+func (s *Server) getLimits(w http.ResponseWriter, r *http.Request, params []string) {
+	projectID := params[0]
+	if v := r.URL.Query().Get("tenant_id"); v != "" {
+		projectID = v
+	}
+
+	quota := s.computeQuota(projectID)
+	usage := map[string]int{}
+	if projectID == ProjectID {
+		usage = s.computeUsage()
+	}
+
+	absolute := object{"maxImageMeta": 128}
+	for k, names := range computeLimitNames {
+		absolute[names[0]] = quota[k]
+		if names[1] != "" {
+			absolute[names[1]] = usage[k]
+		}
+	}
+	writeJSON(w, http.StatusOK, object{"limits": object{"absolute": absolute, "rate": []interface{}{}}})
+}
+
+func (s *Server) getComputeQuota(w http.ResponseWriter, r *http.Request, params []string) {
+	quotaSet := object{"id": params[1]}
+	for k, v := range s.computeQuota(params[1]) {
+		quotaSet[k] = v
+	}
+	writeJSON(w, http.StatusOK, object{"quota_set": quotaSet})
+}
+
+func (s *Server) getComputeQuotaDetail(w http.ResponseWriter, r *http.Request, params []string) {
+	usage := map[string]int{}
+	if params[1] == ProjectID {
+		usage = s.computeUsage()
+	}
+
+	quotaSet := object{"id": params[1]}
+	for k, v := range s.computeQuota(params[1]) {
+		quotaSet[k] = object{"limit": v, "in_use": usage[k], "reserved": 0}
+	}
+	writeJSON(w, http.StatusOK, object{"quota_set": quotaSet})
+}
+
+// updateComputeQuota changes the quotas of a project. Like Nova, it refuses
+// quotas below the usage unless forced.
+func (s *Server) updateComputeQuota(w http.ResponseWriter, r *http.Request, params []string) {
+	req, ok := readWrapped(w, r, "quota_set")
+	if !ok {
+		return
+	}
+	force, _ := req["force"].(bool)
+	delete(req, "force")
+
+	usage := map[string]int{}
+	if params[1] == ProjectID {
+		usage = s.computeUsage()
+	}
+
+	var keys []string
+	for k := range req {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	changed := object{}
+	for _, k := range keys {
+		if _, ok := computeQuotaDefaults[k]; !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Additional properties are not allowed ('%s' was unexpected)", k))
+			return
+		}
+		v, ok := req[k].(float64)
+		if !ok || v != float64(int(v)) || v < -1 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid input for field/attribute %s. Value: %v.", k, req[k]))
+			return
+		}
+		if !force && v != -1 && int(v) < usage[k] {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf(
+				"Quota value %d for %s is less than already used and reserved value %d", int(v), k, usage[k]))
+			return
+		}
+		changed[k] = int(v)
+	}
+
+	stored, ok := s.get("compute_quota", params[1])
+	if !ok {
+		stored = s.add("compute_quota", object{"id": params[1]})
+	}
+	merge(stored, changed)
+
+	quotaSet := object{}
+	for k, v := range s.computeQuota(params[1]) {
+		quotaSet[k] = v
+	}
+	writeJSON(w, http.StatusOK, object{"quota_set": quotaSet})
+}
+
+// deleteComputeQuota reverts the quotas of a project to the defaults.
+func (s *Server) deleteComputeQuota(w http.ResponseWriter, r *http.Request, params []string) {
+	s.remove("compute_quota", params[1])
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,157 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// The SMN client derives its endpoint from the compute one, so the SMN API
+// lives at /smn/v2/ next to /ecs/v2/.
+const smnPrefix = "/smn/v2/*/notifications"
+
+func (s *Server) smnRoutes() {
+	s.handle("GET", smnPrefix+"/topics", s.listTopics)
+	s.handle("POST", smnPrefix+"/topics", s.createTopic)
+	s.handle("GET", smnPrefix+"/topics/*", s.getTopic)
+	s.handle("PUT", smnPrefix+"/topics/*", s.updateTopic)
+	s.handle("DELETE", smnPrefix+"/topics/*", s.deleteTopic)
+
+	s.handle("GET", smnPrefix+"/topics/*/subscriptions", s.listSubscriptions)
+	s.handle("POST", smnPrefix+"/topics/*/subscriptions", s.createSubscription)
+	s.handle("GET", smnPrefix+"/subscriptions", s.listSubscriptions)
+	s.handle("DELETE", smnPrefix+"/subscriptions/*", s.deleteSubscription)
+}
+
+func (s *Server) smnRequestID() string {
+	return strings.Replace(s.newID(), "-", "", -1)
+}
+
+func (s *Server) listTopics(w http.ResponseWriter, r *http.Request, params []string) {
+	topics := []object{}
+	for _, topic := range s.list("topic", nil) {
+		topics = append(topics, public(topic))
+	}
+	writeJSON(w, http.StatusOK, object{
+		"request_id":  s.smnRequestID(),
+		"topic_count": len(topics),
+		"topics":      topics,
+	})
+}
+
+func (s *Server) createTopic(w http.ResponseWriter, r *http.Request, params []string) {
+	topic, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name, _ := topic["name"].(string)
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	urn := fmt.Sprintf("urn:smn:%s:%s:%s", Region, ProjectID, name)
+	if _, ok := s.get("topic", urn); ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Topic %s already exists", name))
+		return
+	}
+
+	setDefault(topic, "display_name", "")
+	topic["id"] = urn
+	topic["topic_urn"] = urn
+	topic["push_policy"] = 0
+	topic["create_time"] = now()
+	topic["update_time"] = now()
+	s.add("topic", topic)
+
+	writeJSON(w, http.StatusOK, object{"request_id": s.smnRequestID(), "topic_urn": urn})
+}
+
+func (s *Server) getTopic(w http.ResponseWriter, r *http.Request, params []string) {
+	topic, ok := s.get("topic", params[1])
+	if !ok {
+		writeNotFound(w, "Topic", params[1])
+		return
+	}
+	view := public(topic)
+	view["request_id"] = s.smnRequestID()
+	writeJSON(w, http.StatusOK, view)
+}
+
+func (s *Server) updateTopic(w http.ResponseWriter, r *http.Request, params []string) {
+	topic, ok := s.get("topic", params[1])
+	if !ok {
+		writeNotFound(w, "Topic", params[1])
+		return
+	}
+	patch, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if v, ok := patch["display_name"]; ok {
+		topic["display_name"] = v
+	}
+	topic["update_time"] = now()
+	writeJSON(w, http.StatusOK, object{"request_id": s.smnRequestID()})
+}
+
+func (s *Server) deleteTopic(w http.ResponseWriter, r *http.Request, params []string) {
+	if !s.remove("topic", params[1]) {
+		writeNotFound(w, "Topic", params[1])
+		return
+	}
+	for _, sub := range s.list("subscription", nil) {
+		if sub["topic_urn"] == params[1] {
+			s.remove("subscription", sub["id"].(string))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listSubscriptions serves the subscriptions of a topic or, without one,
+// all of them.
+func (s *Server) listSubscriptions(w http.ResponseWriter, r *http.Request, params []string) {
+	subscriptions := []object{}
+	for _, sub := range s.list("subscription", nil) {
+		if len(params) < 2 || sub["topic_urn"] == params[1] {
+			subscriptions = append(subscriptions, public(sub))
+		}
+	}
+	writeJSON(w, http.StatusOK, object{
+		"request_id":         s.smnRequestID(),
+		"subscription_count": len(subscriptions),
+		"subscriptions":      subscriptions,
+	})
+}
+
+func (s *Server) createSubscription(w http.ResponseWriter, r *http.Request, params []string) {
+	if _, ok := s.get("topic", params[1]); !ok {
+		writeNotFound(w, "Topic", params[1])
+		return
+	}
+	sub, err := readJSON(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	urn := params[1] + ":" + s.newID()
+	setDefault(sub, "remark", "")
+	sub["id"] = urn
+	sub["subscription_urn"] = urn
+	sub["topic_urn"] = params[1]
+	sub["owner"] = ProjectID
+	sub["status"] = 1
+	s.add("subscription", sub)
+
+	writeJSON(w, http.StatusOK, object{"request_id": s.smnRequestID(), "subscription_urn": urn})
+}
+
+func (s *Server) deleteSubscription(w http.ResponseWriter, r *http.Request, params []string) {
+	if !s.remove("subscription", params[1]) {
+		writeNotFound(w, "Subscription", params[1])
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
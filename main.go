@@ -8,4 +8,7 @@ import (
 func main() {
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: telefonicaopencloud.Provider})
+
+	// Terraform has shut the plugin down, complete the trace files.
+	telefonicaopencloud.CloseTraceFiles()
 }
@@ -0,0 +1,178 @@
+package telefonicaopencloud
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	akskSignAlgorithm  = "SDK-HMAC-SHA256"
+	akskDateHeader     = "X-Sdk-Date"
+	akskDateFormat     = "20060102T150405Z"
+	akskProjectHeader  = "X-Project-Id"
+	akskDefaultHeaders = "host;x-sdk-date"
+)
+
+// AKSKRoundTripper satisfies the http.RoundTripper interface and signs every
+// request with an access key and secret key instead of a Keystone token.
+type AKSKRoundTripper struct {
+	Rt        http.RoundTripper
+	AccessKey string
+	SecretKey string
+}
+
+// RoundTrip signs the request and passes it on to the wrapped RoundTripper.
+func (art *AKSKRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body []byte
+	if request.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	// A token would be ignored by the gateway at best, so never send one
+	// alongside a signature.
+	request.Header.Del("X-Auth-Token")
+	request.Header.Set(akskDateHeader, time.Now().UTC().Format(akskDateFormat))
+
+	signedHeaders := akskSignedHeaders(request)
+	canonicalRequest := akskCanonicalRequest(request, signedHeaders, body)
+	signature := akskSignature(art.SecretKey, request.Header.Get(akskDateHeader), canonicalRequest)
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Access=%s, SignedHeaders=%s, Signature=%s",
+		akskSignAlgorithm, art.AccessKey, strings.Join(signedHeaders, ";"), signature))
+
+	return art.Rt.RoundTrip(request)
+}
+
+// AKSKProjectRoundTripper satisfies the http.RoundTripper interface and sets
+// the project of AK/SK signed requests, since there is no token to scope
+// them. It has to wrap the AKSKRoundTripper so that the header is signed.
+type AKSKProjectRoundTripper struct {
+	Rt        http.RoundTripper
+	ProjectID string
+}
+
+// RoundTrip sets the project header and passes the request on to the wrapped
+// RoundTripper.
+func (aprt *AKSKProjectRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Header.Get(akskProjectHeader) == "" {
+		request.Header.Set(akskProjectHeader, aprt.ProjectID)
+	}
+
+	return aprt.Rt.RoundTrip(request)
+}
+
+// akskSignedHeaders returns the sorted, lower-cased names of the headers
+// which are covered by the signature.
+func akskSignedHeaders(request *http.Request) []string {
+	headers := strings.Split(akskDefaultHeaders, ";")
+	for _, h := range []string{"Content-Type", akskProjectHeader} {
+		if request.Header.Get(h) != "" {
+			headers = append(headers, strings.ToLower(h))
+		}
+	}
+	sort.Strings(headers)
+	return headers
+}
+
+// akskCanonicalRequest builds the canonical form of a request:
+//
+//	Method\nURI/\nQuery\nHeaders\nSignedHeaders\nHex(SHA256(Body))
+func akskCanonicalRequest(request *http.Request, signedHeaders []string, body []byte) string {
+	var headers []string
+	for _, h := range signedHeaders {
+		var v string
+		if h == "host" {
+			v = request.Host
+			if v == "" {
+				v = request.URL.Host
+			}
+		} else {
+			v = request.Header.Get(h)
+		}
+		headers = append(headers, h+":"+strings.TrimSpace(v))
+	}
+
+	hash := sha256.Sum256(body)
+
+	return strings.Join([]string{
+		request.Method,
+		akskCanonicalURI(request.URL),
+		akskCanonicalQuery(request.URL),
+		strings.Join(headers, "\n") + "\n",
+		strings.Join(signedHeaders, ";"),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+}
+
+// akskCanonicalURI escapes each path segment and makes sure the path ends
+// with a slash.
+func akskCanonicalURI(u *url.URL) string {
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, s := range segments {
+		if unescaped, err := url.PathUnescape(s); err == nil {
+			s = unescaped
+		}
+		segments[i] = akskEscape(s)
+	}
+
+	uri := strings.Join(segments, "/")
+	if !strings.HasSuffix(uri, "/") {
+		uri += "/"
+	}
+	return uri
+}
+
+// akskCanonicalQuery sorts and escapes the query parameters.
+func akskCanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var params []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, akskEscape(k)+"="+akskEscape(v))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// akskEscape percent-encodes everything except the RFC 3986 unreserved
+// characters.
+func akskEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// akskSignature computes the hex encoded signature of a canonical request.
+func akskSignature(secretKey, date, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		akskSignAlgorithm,
+		date,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,187 @@
+package telefonicaopencloud
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/huaweicloud/golangsdk/openstack/networking/v1/eips"
+)
+
+const (
+	testAccessKey = "QTWAOYTTINDUT2QVKYUC"
+	testSecretKey = "MFyfvK41ba2giqM7Uio6PznpdUKGpownRZlmVmHc"
+)
+
+var testAKSKAuthorization = regexp.MustCompile(`^SDK-HMAC-SHA256 Access=(\S+), SignedHeaders=(\S+), Signature=(\S+)$`)
+
+// testAKSKVerify recomputes the signature of a request the way the API
+// gateway does and reports why it doesn't match, if it doesn't.
+func testAKSKVerify(r *http.Request) error {
+	if r.Header.Get("X-Auth-Token") != "" {
+		return fmt.Errorf("unexpected X-Auth-Token header")
+	}
+
+	m := testAKSKAuthorization.FindStringSubmatch(r.Header.Get("Authorization"))
+	if m == nil {
+		return fmt.Errorf("malformed Authorization header: %q", r.Header.Get("Authorization"))
+	}
+	if m[1] != testAccessKey {
+		return fmt.Errorf("unknown access key %s", m[1])
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	canonicalRequest := akskCanonicalRequest(r, strings.Split(m[2], ";"), body)
+	expected := akskSignature(testSecretKey, r.Header.Get(akskDateHeader), canonicalRequest)
+	if m[3] != expected {
+		return fmt.Errorf("signature mismatch, canonical request:\n%s", canonicalRequest)
+	}
+
+	return nil
+}
+
+func TestAKSKRoundTripper_networkingV1Client(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if err := testAKSKVerify(r); err != nil {
+			t.Errorf("Request %s %s is not signed correctly: %s", r.Method, r.URL, err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v3/projects":
+			fmt.Fprintf(w, `{"projects": [{"id": "project-%s"}]}`, r.URL.Query().Get("name"))
+		case "/v1/project-eu-de/publicips/eip-1":
+			if project := r.Header.Get(akskProjectHeader); project != "project-eu-de" {
+				t.Errorf("Expected project header project-eu-de, got %q", project)
+			}
+			fmt.Fprint(w, `{"publicip": {"id": "eip-1", "status": "ACTIVE"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	network := akskEndpoints["network"]
+	akskEndpoints["network"] = server.URL + "/"
+	defer func() { akskEndpoints["network"] = network }()
+
+	config := &Config{
+		AccessKey:        testAccessKey,
+		SecretKey:        testSecretKey,
+		IdentityEndpoint: server.URL + "/v3",
+		Region:           "eu-de",
+	}
+	if err := config.authenticate(); err != nil {
+		t.Fatalf("Error creating AK/SK client: %s", err)
+	}
+
+	client, err := config.networkingV1Client("eu-de")
+	if err != nil {
+		t.Fatalf("Error creating networking v1 client: %s", err)
+	}
+
+	eip, err := eips.Get(client, "eip-1").Extract()
+	if err != nil {
+		t.Fatalf("Error retrieving EIP: %s", err)
+	}
+	if eip.Status != "ACTIVE" {
+		t.Fatalf("Expected ACTIVE EIP, got %s", eip.Status)
+	}
+
+	// The project ID is only looked up once per region.
+	if _, err := config.networkingV1Client("eu-de"); err != nil {
+		t.Fatalf("Error creating networking v1 client: %s", err)
+	}
+
+	expected := []string{
+		"GET /v3/projects?name=eu-de",
+		"GET /v1/project-eu-de/publicips/eip-1",
+	}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected requests %v, got %v", expected, requests)
+	}
+}
+
+// TestAKSKSignature_knownAnswer signs a fixed request, so a mistake in the
+// canonical request can't cancel itself out the way it does when the
+// signature is verified with the same code. The expected values were
+// computed apart from this code, following the API gateway signing rules.
+func TestAKSKSignature_knownAnswer(t *testing.T) {
+	body := []byte(`{"publicip":{"type":"5_bgp"}}`)
+	request, err := http.NewRequest("POST",
+		"https://vpc.eu-de.example.com/v1/0123456789abcdef/publicips?marker=abc+def&limit=10&fields=status&fields=id",
+		bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(akskProjectHeader, "0123456789abcdef")
+	request.Header.Set(akskDateHeader, "20180314T080000Z")
+
+	signedHeaders := akskSignedHeaders(request)
+	if v := strings.Join(signedHeaders, ";"); v != "content-type;host;x-project-id;x-sdk-date" {
+		t.Fatalf("Unexpected signed headers %s", v)
+	}
+
+	expectedRequest := "POST\n" +
+		"/v1/0123456789abcdef/publicips/\n" +
+		"fields=id&fields=status&limit=10&marker=abc%20def\n" +
+		"content-type:application/json\n" +
+		"host:vpc.eu-de.example.com\n" +
+		"x-project-id:0123456789abcdef\n" +
+		"x-sdk-date:20180314T080000Z\n" +
+		"\n" +
+		"content-type;host;x-project-id;x-sdk-date\n" +
+		"43c7f845fca452737d1db206ab6b4c42cc88dfabdabe4b0ee1dc9022cb4e7a8c"
+	canonicalRequest := akskCanonicalRequest(request, signedHeaders, body)
+	if canonicalRequest != expectedRequest {
+		t.Fatalf("Expected canonical request:\n%s\ngot:\n%s", expectedRequest, canonicalRequest)
+	}
+
+	expected := "3a37572e3fd002ce259d127b06f901740f28b9e9b02819e552351439568da5d3"
+	if signature := akskSignature(testSecretKey, "20180314T080000Z", canonicalRequest); signature != expected {
+		t.Fatalf("Expected signature %s, got %s", expected, signature)
+	}
+}
+
+func TestAKSKEndpointURL(t *testing.T) {
+	config := &Config{
+		IdentityEndpoint: "https://iam.sa-chile-1.telefonicaopencloud.com/v3",
+		Region:           "sa-chile-1",
+	}
+
+	url, err := config.akskEndpointURL("compute", "sa-argentina-1", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "https://ecs.sa-argentina-1.telefonicaopencloud.com/v2/abc/"
+	if url != expected {
+		t.Fatalf("Expected %s, got %s", expected, url)
+	}
+
+	if _, err := config.akskEndpointURL("dns", "sa-chile-1", "abc"); err == nil {
+		t.Fatalf("Expected an error for an unsupported service")
+	}
+
+	for _, authURL := range []string{"https://10.0.0.1:5000/v3", "https://keystone.example/v3", ""} {
+		config.IdentityEndpoint = authURL
+		_, err := config.akskEndpointURL("compute", "sa-chile-1", "abc")
+		if err == nil || !strings.Contains(err.Error(), "Unable to derive the cloud domain") {
+			t.Fatalf("Expected a domain error for auth_url %q, got: %v", authURL, err)
+		}
+	}
+}
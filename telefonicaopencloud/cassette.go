@@ -0,0 +1,538 @@
+package telefonicaopencloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// cassetteScrubbed replaces the secrets recorded in a cassette.
+const cassetteScrubbed = "SCRUBBED"
+
+// cassetteSecretFields are the JSON fields whose values are scrubbed from a
+// cassette wherever they appear.
+var cassetteSecretFields = []string{"adminPass", "password", "private_key", "secret", "secret_key"}
+
+// cassetteTokenHeaders are the request and response headers which carry
+// tokens. Their values are scrubbed from a cassette wherever they appear.
+var cassetteTokenHeaders = []string{"X-Auth-Token", "X-Subject-Token"}
+
+// cassetteResponseHeaders are the only response headers kept in a cassette.
+var cassetteResponseHeaders = []string{"Content-Type", "Location", "X-Subject-Token"}
+
+// cassetteInteraction is a recorded request and its response.
+type cassetteInteraction struct {
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	Status          int               `json:"status"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+
+	used bool
+}
+
+// cassette is a file of the API requests of an acceptance test and their
+// responses. It's recorded against a live cloud and can then be replayed
+// without one.
+type cassette struct {
+	Interactions []*cassetteInteraction `json:"interactions"`
+
+	mu        sync.Mutex
+	path      string
+	recording bool
+
+	// keep reports whether a recorded cassette is to be saved once ejected.
+	keep func() bool
+
+	// scrub maps the sensitive values, such as the project ID, to the
+	// placeholders which replace them in the recording.
+	scrub map[string]string
+
+	// substitutions maps the values which differ between the recording and
+	// the replay, such as random resource names, to their replayed value.
+	substitutions map[string]string
+}
+
+// newCassette returns an empty cassette to record to path, replacing every
+// key of scrub by its value.
+func newCassette(path string, scrub map[string]string) *cassette {
+	c := &cassette{
+		path:      path,
+		recording: true,
+		scrub:     make(map[string]string),
+	}
+	for k, v := range scrub {
+		c.scrub[k] = v
+	}
+	return c
+}
+
+// loadCassette reads the cassette at path to replay it.
+func loadCassette(path string) (*cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &cassette{
+		path:          path,
+		substitutions: make(map[string]string),
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("Error parsing cassette %s: %s", path, err)
+	}
+	return c, nil
+}
+
+// save writes a recorded cassette to its file.
+func (c *cassette) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(data, '\n'), 0644)
+}
+
+// record performs the request with rt and records it.
+func (c *cassette) record(request *http.Request, rt http.RoundTripper) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		var err error
+		requestBody, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	response, err := rt.RoundTrip(request)
+	if err != nil {
+		return response, err
+	}
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if request.Method == "POST" && strings.HasSuffix(request.URL.Path, "/auth/tokens") {
+		c.scrubToken(responseBody)
+		c.scrubAuthToken(requestBody)
+	}
+	for _, h := range cassetteTokenHeaders {
+		c.scrubSecret(request.Header.Get(h))
+		c.scrubSecret(response.Header.Get(h))
+	}
+
+	interaction := &cassetteInteraction{
+		Method:       request.Method,
+		URL:          c.scrubText(request.URL.String()),
+		Status:       response.StatusCode,
+		ResponseBody: c.scrubText(scrubCassetteJSON(responseBody)),
+	}
+	if strings.HasPrefix(request.Header.Get("Content-Type"), "application/json") {
+		interaction.RequestBody = c.scrubText(scrubCassetteJSON(requestBody))
+	}
+	for _, h := range cassetteResponseHeaders {
+		if v := response.Header.Get(h); v != "" {
+			if interaction.ResponseHeaders == nil {
+				interaction.ResponseHeaders = make(map[string]string)
+			}
+			interaction.ResponseHeaders[h] = c.scrubText(v)
+		}
+	}
+	c.Interactions = append(c.Interactions, interaction)
+
+	return response, nil
+}
+
+// scrubToken adds the IDs of the project, user and domain a token was
+// issued for to the values which are scrubbed.
+func (c *cassette) scrubToken(body []byte) {
+	var token struct {
+		Token struct {
+			Project struct {
+				ID     string `json:"id"`
+				Domain struct {
+					ID string `json:"id"`
+				} `json:"domain"`
+			} `json:"project"`
+			User struct {
+				ID     string `json:"id"`
+				Domain struct {
+					ID string `json:"id"`
+				} `json:"domain"`
+			} `json:"user"`
+		} `json:"token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return
+	}
+
+	ids := map[string]string{
+		token.Token.Project.ID:        "scrubbed-project-id",
+		token.Token.Project.Domain.ID: "scrubbed-domain-id",
+		token.Token.User.ID:           "scrubbed-user-id",
+		token.Token.User.Domain.ID:    "scrubbed-domain-id",
+	}
+	for id, placeholder := range ids {
+		if _, ok := c.scrub[id]; !ok && id != "" {
+			c.scrub[id] = placeholder
+		}
+	}
+}
+
+// scrubAuthToken scrubs the token a token request authenticates with.
+func (c *cassette) scrubAuthToken(body []byte) {
+	var auth struct {
+		Auth struct {
+			Identity struct {
+				Token struct {
+					ID string `json:"id"`
+				} `json:"token"`
+			} `json:"identity"`
+		} `json:"auth"`
+	}
+	if err := json.Unmarshal(body, &auth); err != nil {
+		return
+	}
+	c.scrubSecret(auth.Auth.Identity.Token.ID)
+}
+
+// scrubSecret adds a secret which isn't in a field of its own, such as a
+// token, to the values which are scrubbed.
+func (c *cassette) scrubSecret(v string) {
+	if v != "" {
+		c.scrub[v] = cassetteScrubbed
+	}
+}
+
+// scrubText replaces the sensitive values in s by their placeholders.
+func (c *cassette) scrubText(s string) string {
+	return scrubCassetteText(s, c.scrub)
+}
+
+// scrubCassetteText replaces every key of scrub found in s by its value,
+// unless it's only part of a longer name, such as a user name "admin" in
+// "admin_state_up".
+func scrubCassetteText(s string, scrub map[string]string) string {
+	values := make([]string, 0, len(scrub))
+	for v := range scrub {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	isNameByte := func(b byte) bool {
+		return b == '_' || b == '-' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+	}
+
+	for _, v := range values {
+		var buf bytes.Buffer
+		start := 0
+		for {
+			n := strings.Index(s[start:], v)
+			if n < 0 {
+				break
+			}
+			n += start
+			end := n + len(v)
+			if (n > 0 && isNameByte(s[n-1])) || (end < len(s) && isNameByte(s[end])) {
+				buf.WriteString(s[start:end])
+			} else {
+				buf.WriteString(s[start:n])
+				buf.WriteString(scrub[v])
+			}
+			start = end
+		}
+		buf.WriteString(s[start:])
+		s = buf.String()
+	}
+	return s
+}
+
+// replay returns the recorded response of the request. Requests are matched
+// by method and URL, in the order they were recorded, preferring the one
+// whose body is the most similar when several are pending.
+func (c *cassette) replay(request *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		var err error
+		requestBody, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	url := request.URL.String()
+	replacer := cassetteReplacer(c.substitutions)
+
+	var match *cassetteInteraction
+	best := -1
+	for _, i := range c.Interactions {
+		if i.used || i.Method != request.Method || replacer.Replace(i.URL) != url {
+			continue
+		}
+		if score := cassetteBodyScore(replacer.Replace(i.RequestBody), requestBody); score > best {
+			match, best = i, score
+		}
+	}
+
+	// Read requests may be repeated more often than when they were
+	// recorded, e.g. while waiting for a resource, so their last response
+	// is replayed again.
+	if match == nil && (request.Method == "GET" || request.Method == "HEAD") {
+		for n := len(c.Interactions) - 1; n >= 0; n-- {
+			if i := c.Interactions[n]; i.used && i.Method == request.Method && replacer.Replace(i.URL) == url {
+				match = i
+				break
+			}
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("The cassette %s has no recorded interaction for %s %s", c.path, request.Method, url)
+	}
+	match.used = true
+
+	c.learnSubstitutions(match.RequestBody, requestBody)
+	body := cassetteReplacer(c.substitutions).Replace(match.ResponseBody)
+
+	response := &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.Status, http.StatusText(match.Status)),
+		StatusCode:    match.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+	for k, v := range match.ResponseHeaders {
+		response.Header.Set(k, v)
+	}
+	response.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	return response, nil
+}
+
+// learnSubstitutions records the string values in which the replayed request
+// body differs from the recorded one, so that they're replaced in the
+// responses too.
+func (c *cassette) learnSubstitutions(recorded string, replayed []byte) {
+	recordedValues := cassetteJSONValues(recorded)
+	for path, v := range cassetteJSONValues(string(replayed)) {
+		old, ok := recordedValues[path].(string)
+		value, _ := v.(string)
+		if !ok || value == "" || old == value || len(old) < 4 || old == cassetteScrubbed || old == "***" {
+			continue
+		}
+		// Values which are escaped in JSON wouldn't be found in a body.
+		if strings.ContainsAny(old, "\"\\<>&") {
+			continue
+		}
+		c.substitutions[old] = value
+	}
+}
+
+// cassetteReplacer returns a strings.Replacer which replaces every key of
+// values by its value, preferring the longest keys.
+func cassetteReplacer(values map[string]string) *strings.Replacer {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+
+	oldnew := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		oldnew = append(oldnew, k, values[k])
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// cassetteBodyScore returns how many values of the replayed request body are
+// the same as in the recorded one.
+func cassetteBodyScore(recorded string, replayed []byte) int {
+	if recorded == string(replayed) {
+		return len(recorded) + 1
+	}
+
+	score := 0
+	recordedValues := cassetteJSONValues(recorded)
+	for path, v := range cassetteJSONValues(string(replayed)) {
+		if rv, ok := recordedValues[path]; ok && rv == v {
+			score++
+		}
+	}
+	return score
+}
+
+// cassetteJSONValues flattens a JSON body to its scalar values, keyed by
+// their path.
+func cassetteJSONValues(body string) map[string]interface{} {
+	values := make(map[string]interface{})
+
+	var data interface{}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return values
+	}
+
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, e := range v {
+				walk(path+"/"+k, e)
+			}
+		case []interface{}:
+			for n, e := range v {
+				walk(path+"/"+strconv.Itoa(n), e)
+			}
+		default:
+			values[path] = v
+		}
+	}
+	walk("", data)
+
+	return values
+}
+
+// scrubCassetteJSON masks the secret fields of a JSON body. Other bodies are
+// returned as they are.
+func scrubCassetteJSON(body []byte) string {
+	var data interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return string(body)
+	}
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, e := range v {
+				if _, ok := e.(string); ok && isCassetteSecretField(k) {
+					v[k] = cassetteScrubbed
+					continue
+				}
+				walk(e)
+			}
+		case []interface{}:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	walk(data)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return string(body)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func isCassetteSecretField(name string) bool {
+	for _, f := range cassetteSecretFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cassetteDeck holds the cassette the acceptance test being run records to
+// or replays from.
+type cassetteDeck struct {
+	mu       sync.Mutex
+	cassette *cassette
+}
+
+// cassettePlayer is set when the acceptance tests are run with cassettes.
+// It's nil otherwise.
+var cassettePlayer *cassetteDeck
+
+func (d *cassetteDeck) insert(c *cassette) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cassette = c
+}
+
+// eject removes the inserted cassette and returns it. It returns nil if
+// there's none.
+func (d *cassetteDeck) eject() *cassette {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c := d.cassette
+	d.cassette = nil
+	return c
+}
+
+func (d *cassetteDeck) current() *cassette {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cassette
+}
+
+// CassetteRoundTripper satisfies the http.RoundTripper interface and records
+// requests to, or replays them from, the cassette of the acceptance test
+// being run.
+type CassetteRoundTripper struct {
+	Rt http.RoundTripper
+}
+
+// RoundTrip records or replays a round-trip HTTP request. Without a cassette
+// the request is performed as usual.
+func (crt *CassetteRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	c := cassettePlayer.current()
+	if c == nil {
+		return crt.Rt.RoundTrip(request)
+	}
+	if c.recording {
+		return c.record(request, crt.Rt)
+	}
+	return c.replay(request)
+}
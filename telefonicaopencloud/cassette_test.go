@@ -0,0 +1,168 @@
+package telefonicaopencloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCassetteProjectID = "0123456789abcdef0123456789abcdef"
+
+// newTestCassetteServer returns a server which issues tokens and creates
+// networks, echoing their name.
+func newTestCassetteServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Subject-Token", "secret-token")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": {"project": {"id": %q, "domain": {"id": "domain-1234"}}, "user": {"id": "user-1234"}}}`,
+			testCassetteProjectID)
+	})
+	mux.HandleFunc("/v2.0/"+testCassetteProjectID+"/networks", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Network struct {
+				Name string `json:"name"`
+			} `json:"network"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Error decoding request: %s", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"network": {"id": "net-1", "name": %q, "admin_state_up": true, "secret": "hunter22"}}`,
+			body.Network.Name)
+	})
+	return httptest.NewServer(mux)
+}
+
+func testCassetteRequest(t *testing.T, client *http.Client, method, url, body string) (*http.Response, string) {
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("Error requesting %s %s: %s", method, url, err)
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response, string(data)
+}
+
+func TestCassetteRoundTripper(t *testing.T) {
+	server := newTestCassetteServer(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "TestCassette.json")
+
+	cassettePlayer = &cassetteDeck{}
+	defer func() { cassettePlayer = nil }()
+	client := &http.Client{Transport: cassetteRoundTripper(http.DefaultTransport)}
+
+	networksURL := server.URL + "/v2.0/" + testCassetteProjectID + "/networks"
+
+	// Record
+	cassettePlayer.insert(newCassette(path, map[string]string{"admin": "scrubbed-user-name"}))
+	testCassetteRequest(t, client, "POST", server.URL+"/v3/auth/tokens",
+		`{"auth": {"identity": {"password": {"user": {"name": "admin", "password": "pass"}}}}}`)
+	testCassetteRequest(t, client, "POST", server.URL+"/v3/auth/tokens",
+		`{"auth": {"identity": {"methods": ["token"], "token": {"id": "token-5678"}}}}`)
+	_, body := testCassetteRequest(t, client, "POST", networksURL, `{"network": {"name": "network-recorded"}}`)
+	if !strings.Contains(body, "hunter22") {
+		t.Fatalf("Expected the live response while recording, got %s", body)
+	}
+	recorded := cassettePlayer.current()
+	cassettePlayer.eject()
+	if err := recorded.save(); err != nil {
+		t.Fatalf("Error saving cassette: %s", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{testCassetteProjectID, "secret-token", "token-5678", "hunter22", `\"pass\"`, "domain-1234", "user-1234", `\"admin\"`} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("Expected %s to be scrubbed from the cassette:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "admin_state_up") {
+		t.Fatalf("Expected only whole values to be scrubbed:\n%s", data)
+	}
+
+	// Replay, with the server gone and a different random name.
+	server.Close()
+	replayed, err := loadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassettePlayer.insert(replayed)
+
+	response, _ := testCassetteRequest(t, client, "POST", server.URL+"/v3/auth/tokens",
+		`{"auth": {"identity": {"password": {"user": {"name": "scrubbed-user-name", "password": "SCRUBBED"}}}}}`)
+	if response.StatusCode != http.StatusCreated || response.Header.Get("X-Subject-Token") != cassetteScrubbed {
+		t.Fatalf("Unexpected token response: %d %v", response.StatusCode, response.Header)
+	}
+
+	networksURL = server.URL + "/v2.0/scrubbed-project-id/networks"
+	response, body = testCassetteRequest(t, client, "POST", networksURL, `{"network": {"name": "network-replayed"}}`)
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected the recorded status 201, got %d", response.StatusCode)
+	}
+
+	var network struct {
+		Network struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"network"`
+	}
+	if err := json.Unmarshal([]byte(body), &network); err != nil {
+		t.Fatalf("Error decoding replayed response %s: %s", body, err)
+	}
+	if network.Network.ID != "net-1" || network.Network.Name != "network-replayed" {
+		t.Fatalf("Expected net-1 named network-replayed, got %+v", network.Network)
+	}
+
+	if _, err := client.Post(networksURL, "application/json", strings.NewReader(`{}`)); err == nil {
+		t.Fatal("Expected an error for a request which wasn't recorded")
+	}
+}
+
+func TestScrubCassetteText(t *testing.T) {
+	scrub := map[string]string{"admin": "scrubbed-user-name", "0123abcd": "scrubbed-project-id"}
+
+	cases := map[string]string{
+		`{"name": "admin"}`:                   `{"name": "scrubbed-user-name"}`,
+		`{"admin_state_up": true}`:            `{"admin_state_up": true}`,
+		`https://ecs/v2/0123abcd/servers`:     `https://ecs/v2/scrubbed-project-id/servers`,
+		`admin admin-net admin`:               `scrubbed-user-name admin-net scrubbed-user-name`,
+		`https://ecs/v2/0123abcdef/servers`:   `https://ecs/v2/0123abcdef/servers`,
+		`{"pool": "admin_external_net"}`:      `{"pool": "admin_external_net"}`,
+		`/0123abcd/0123abcd`:                  `/scrubbed-project-id/scrubbed-project-id`,
+		`{"user": "admin", "role": "admins"}`: `{"user": "scrubbed-user-name", "role": "admins"}`,
+	}
+
+	for text, expected := range cases {
+		if actual := scrubCassetteText(text, scrub); actual != expected {
+			t.Errorf("Expected %s to be scrubbed to %s, got %s", text, expected, actual)
+		}
+	}
+}
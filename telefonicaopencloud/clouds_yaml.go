@@ -0,0 +1,235 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// cloudsFile represents the top level of a clouds.yaml or secure.yaml file.
+// The format is documented at
+// https://docs.openstack.org/os-client-config/latest/user/configuration.html.
+type cloudsFile struct {
+	Clouds map[string]cloudEntry `yaml:"clouds"`
+}
+
+// cloudEntry represents a single named profile in a clouds.yaml file.
+type cloudEntry struct {
+	Auth       cloudAuth `yaml:"auth"`
+	RegionName string    `yaml:"region_name"`
+	Interface  string    `yaml:"interface"`
+	CACertFile string    `yaml:"cacert"`
+	Verify     *bool     `yaml:"verify"`
+	ClientCert string    `yaml:"cert"`
+	ClientKey  string    `yaml:"key"`
+}
+
+// cloudAuth represents the auth section of a cloud entry.
+type cloudAuth struct {
+	AuthURL           string `yaml:"auth_url"`
+	Token             string `yaml:"token"`
+	Username          string `yaml:"username"`
+	UserID            string `yaml:"user_id"`
+	Password          string `yaml:"password"`
+	ProjectName       string `yaml:"project_name"`
+	ProjectID         string `yaml:"project_id"`
+	TenantName        string `yaml:"tenant_name"`
+	TenantID          string `yaml:"tenant_id"`
+	DomainName        string `yaml:"domain_name"`
+	DomainID          string `yaml:"domain_id"`
+	UserDomainName    string `yaml:"user_domain_name"`
+	UserDomainID      string `yaml:"user_domain_id"`
+	ProjectDomainName string `yaml:"project_domain_name"`
+	ProjectDomainID   string `yaml:"project_domain_id"`
+}
+
+// cloudsYAMLPaths returns the locations searched for a clouds.yaml style
+// file, in order of precedence:
+//
+// 1. The file named by envVar, if set.
+// 2. The current directory.
+// 3. The user config directory (~/.config/openstack).
+// 4. The site config directory (/etc/openstack).
+func cloudsYAMLPaths(name, envVar string) []string {
+	var paths []string
+
+	if v := os.Getenv(envVar); v != "" {
+		paths = append(paths, v)
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		paths = append(paths, filepath.Join(cwd, name))
+	}
+
+	if currentUser, err := user.Current(); err == nil && currentUser.HomeDir != "" {
+		paths = append(paths, filepath.Join(currentUser.HomeDir, ".config", "openstack", name))
+	}
+
+	return append(paths, filepath.Join("/etc", "openstack", name))
+}
+
+// readCloudsYAML parses the first file found in paths. It returns a nil map
+// and an empty path if none of the paths exist.
+func readCloudsYAML(paths []string) (map[string]cloudEntry, string, error) {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, path, fmt.Errorf("Error reading %s: %s", path, err)
+		}
+
+		var clouds cloudsFile
+		if err := yaml.Unmarshal(content, &clouds); err != nil {
+			return nil, path, fmt.Errorf("Error parsing %s: %s", path, err)
+		}
+
+		return clouds.Clouds, path, nil
+	}
+
+	return nil, "", nil
+}
+
+// getCloudFromYAML returns the named profile from clouds.yaml, with any
+// values from the matching profile in secure.yaml layered on top.
+func getCloudFromYAML(name string) (*cloudEntry, error) {
+	cloudsPaths := cloudsYAMLPaths("clouds.yaml", "OS_CLIENT_CONFIG_FILE")
+	clouds, cloudsPath, err := readCloudsYAML(cloudsPaths)
+	if err != nil {
+		return nil, err
+	}
+	if cloudsPath == "" {
+		return nil, fmt.Errorf("Unable to find a clouds.yaml file for cloud %q, searched: %v", name, cloudsPaths)
+	}
+
+	cloud, ok := clouds[name]
+	if !ok {
+		return nil, fmt.Errorf("Cloud %q was not found in %s", name, cloudsPath)
+	}
+	log.Printf("[DEBUG] Using cloud %q from %s", name, cloudsPath)
+
+	secure, securePath, err := readCloudsYAML(cloudsYAMLPaths("secure.yaml", "OS_CLIENT_SECURE_FILE"))
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := secure[name]; ok {
+		log.Printf("[DEBUG] Merging secrets for cloud %q from %s", name, securePath)
+		mergeCloudEntry(&cloud, &s)
+	}
+
+	return &cloud, nil
+}
+
+// mergeCloudEntry copies every non-empty value of src into dst.
+func mergeCloudEntry(dst, src *cloudEntry) {
+	override := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+
+	override(&dst.Auth.AuthURL, src.Auth.AuthURL)
+	override(&dst.Auth.Token, src.Auth.Token)
+	override(&dst.Auth.Username, src.Auth.Username)
+	override(&dst.Auth.UserID, src.Auth.UserID)
+	override(&dst.Auth.Password, src.Auth.Password)
+	override(&dst.Auth.ProjectName, src.Auth.ProjectName)
+	override(&dst.Auth.ProjectID, src.Auth.ProjectID)
+	override(&dst.Auth.TenantName, src.Auth.TenantName)
+	override(&dst.Auth.TenantID, src.Auth.TenantID)
+	override(&dst.Auth.DomainName, src.Auth.DomainName)
+	override(&dst.Auth.DomainID, src.Auth.DomainID)
+	override(&dst.Auth.UserDomainName, src.Auth.UserDomainName)
+	override(&dst.Auth.UserDomainID, src.Auth.UserDomainID)
+	override(&dst.Auth.ProjectDomainName, src.Auth.ProjectDomainName)
+	override(&dst.Auth.ProjectDomainID, src.Auth.ProjectDomainID)
+	override(&dst.RegionName, src.RegionName)
+	override(&dst.Interface, src.Interface)
+	override(&dst.CACertFile, src.CACertFile)
+	override(&dst.ClientCert, src.ClientCert)
+	override(&dst.ClientKey, src.ClientKey)
+
+	if src.Verify != nil {
+		dst.Verify = src.Verify
+	}
+}
+
+// loadCloud fills every unset Config field from the clouds.yaml profile
+// named by c.Cloud. Explicit provider arguments always take precedence.
+func (c *Config) loadCloud() error {
+	if c.Cloud == "" {
+		return nil
+	}
+
+	cloud, err := getCloudFromYAML(c.Cloud)
+	if err != nil {
+		return err
+	}
+
+	auth := cloud.Auth
+	fillIfEmpty := func(dst *string, values ...string) {
+		if *dst != "" {
+			return
+		}
+		for _, v := range values {
+			if v != "" {
+				*dst = v
+				return
+			}
+		}
+	}
+
+	fillIfEmpty(&c.IdentityEndpoint, auth.AuthURL)
+	fillIfEmpty(&c.Token, auth.Token)
+	fillIfEmpty(&c.Username, auth.Username)
+	fillIfEmpty(&c.UserID, auth.UserID)
+	fillIfEmpty(&c.Password, auth.Password)
+	fillIfEmpty(&c.TenantID, auth.ProjectID, auth.TenantID)
+	fillIfEmpty(&c.TenantName, auth.ProjectName, auth.TenantName)
+	fillIfEmpty(&c.DomainID, auth.UserDomainID, auth.DomainID)
+	fillIfEmpty(&c.DomainName, auth.UserDomainName, auth.DomainName)
+	fillIfEmpty(&c.Region, cloud.RegionName)
+	fillIfEmpty(&c.EndpointType, cloud.Interface)
+	fillIfEmpty(&c.CACertFile, cloud.CACertFile)
+	fillIfEmpty(&c.ClientCertFile, cloud.ClientCert)
+	fillIfEmpty(&c.ClientKeyFile, cloud.ClientKey)
+
+	// The domain arguments are the domain of the user, which the SDKs also
+	// use to look up a project by name. A project in another domain can
+	// only be scoped to by its ID.
+	if c.TenantID == "" {
+		if err := checkProjectDomain(c.DomainID, auth.ProjectDomainID, "id"); err != nil {
+			return err
+		}
+		if err := checkProjectDomain(c.DomainName, auth.ProjectDomainName, "name"); err != nil {
+			return err
+		}
+	}
+
+	if !c.Insecure && cloud.Verify != nil && !*cloud.Verify {
+		c.Insecure = true
+	}
+
+	return nil
+}
+
+// checkProjectDomain returns an error if the project domain of a clouds.yaml
+// profile differs from the domain of the user.
+func checkProjectDomain(userDomain, projectDomain, attr string) error {
+	if projectDomain == "" || projectDomain == userDomain {
+		return nil
+	}
+	if userDomain == "" {
+		return fmt.Errorf("project_domain_%s %q is set in clouds.yaml, but the user domain is not. "+
+			"Set user_domain_%s or domain_%s, or use project_id instead.", attr, projectDomain, attr, attr)
+	}
+	return fmt.Errorf("project_domain_%s %q in clouds.yaml differs from the user domain %q. "+
+		"A project in another domain can only be used by its project_id.", attr, projectDomain, userDomain)
+}
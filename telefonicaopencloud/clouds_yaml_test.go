@@ -0,0 +1,138 @@
+package telefonicaopencloud
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testCloudsYAML = `
+clouds:
+  dev:
+    region_name: eu-de
+    interface: internal
+    verify: false
+    cacert: /etc/ssl/dev-ca.pem
+    auth:
+      auth_url: https://iam.example.com/v3
+      username: dev-user
+      project_name: dev-project
+      user_domain_name: dev-domain
+`
+
+const testSecureYAML = `
+clouds:
+  dev:
+    auth:
+      password: s3cr3t
+`
+
+func testWriteCloudsYAML(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Error writing %s: %s", path, err)
+	}
+	return path
+}
+
+func TestConfigLoadCloud_merge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clouds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("OS_CLIENT_CONFIG_FILE", testWriteCloudsYAML(t, dir, "clouds.yaml", testCloudsYAML))
+	defer os.Unsetenv("OS_CLIENT_CONFIG_FILE")
+	os.Setenv("OS_CLIENT_SECURE_FILE", testWriteCloudsYAML(t, dir, "secure.yaml", testSecureYAML))
+	defer os.Unsetenv("OS_CLIENT_SECURE_FILE")
+
+	config := Config{
+		Cloud:  "dev",
+		Region: "eu-west-0",
+	}
+	if err := config.loadCloud(); err != nil {
+		t.Fatalf("Error loading cloud: %s", err)
+	}
+
+	expected := map[string]string{
+		"region":        "eu-west-0",
+		"auth_url":      "https://iam.example.com/v3",
+		"user_name":     "dev-user",
+		"password":      "s3cr3t",
+		"tenant_name":   "dev-project",
+		"domain_name":   "dev-domain",
+		"endpoint_type": "internal",
+		"cacert_file":   "/etc/ssl/dev-ca.pem",
+	}
+	actual := map[string]string{
+		"region":        config.Region,
+		"auth_url":      config.IdentityEndpoint,
+		"user_name":     config.Username,
+		"password":      config.Password,
+		"tenant_name":   config.TenantName,
+		"domain_name":   config.DomainName,
+		"endpoint_type": config.EndpointType,
+		"cacert_file":   config.CACertFile,
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected %#v, got %#v", expected, actual)
+	}
+
+	if !config.Insecure {
+		t.Fatalf("Expected verify: false to set insecure")
+	}
+}
+
+func TestConfigLoadCloud_missingProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clouds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("OS_CLIENT_CONFIG_FILE", testWriteCloudsYAML(t, dir, "clouds.yaml", testCloudsYAML))
+	defer os.Unsetenv("OS_CLIENT_CONFIG_FILE")
+
+	config := Config{Cloud: "prod"}
+	err = config.loadCloud()
+	if err == nil || !strings.Contains(err.Error(), `Cloud "prod" was not found`) {
+		t.Fatalf("Expected a missing cloud error, got: %v", err)
+	}
+}
+
+func TestConfigLoadCloud_projectDomain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clouds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("OS_CLIENT_CONFIG_FILE", testWriteCloudsYAML(t, dir, "clouds.yaml", `
+clouds:
+  dev:
+    auth:
+      username: dev-user
+      project_name: dev-project
+      user_domain_name: dev-domain
+      project_domain_name: other-domain
+`))
+	defer os.Unsetenv("OS_CLIENT_CONFIG_FILE")
+
+	config := Config{Cloud: "dev"}
+	err = config.loadCloud()
+	if err == nil || !strings.Contains(err.Error(), `differs from the user domain "dev-domain"`) {
+		t.Fatalf("Expected a project domain error, got: %v", err)
+	}
+
+	config = Config{Cloud: "dev", TenantID: "0123456789abcdef"}
+	if err := config.loadCloud(); err != nil {
+		t.Fatalf("Error loading cloud: %s", err)
+	}
+	if config.DomainName != "dev-domain" {
+		t.Fatalf("Expected the user domain dev-domain, got %q", config.DomainName)
+	}
+}
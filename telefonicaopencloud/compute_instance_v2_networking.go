@@ -13,13 +13,19 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/attachinterfaces"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/tenantnetworks"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
 )
 
 // InstanceNIC is a structured representation of a Gophercloud servers.Server
@@ -305,12 +311,84 @@ func getInstanceNetworkInfoNeutron(
 	return v, nil
 }
 
+// getInstancePorts lists the ports attached to an instance, in the order they
+// were created. The addresses returned by Nova only carry the network name,
+// which isn't unique, so the ports are the only reliable way to find out
+// which network a NIC is on when none were configured, such as when an
+// instance is imported.
+//
+// nova-network has no ports, so nothing is returned when it's in use.
+func getInstancePorts(meta interface{}, region, instanceId string) ([]ports.Port, error) {
+	if _, ok := os.LookupEnv("OS_NOVA_NETWORK"); ok {
+		return nil, nil
+	}
+
+	config := meta.(*Config)
+	networkClient, err := config.networkingV2Client(region)
+	if err != nil {
+		log.Printf("[DEBUG] Unable to obtain a network client")
+		return nil, nil
+	}
+
+	listOpts := ports.ListOpts{
+		DeviceID: instanceId,
+	}
+	allPages, err := ports.List(networkClient, listOpts).AllPages()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve ports from the Network API: %s", err)
+	}
+
+	allPorts, err := ports.ExtractPorts(allPages)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve ports from the Network API: %s", err)
+	}
+
+	// The time a port was created is only there with the standard attributes
+	// extension, which the Port struct lacks.
+	var portTimes []struct {
+		ID        string `json:"id"`
+		CreatedAt string `json:"created_at"`
+	}
+	if err := ports.ExtractPortsInto(allPages, &portTimes); err != nil {
+		return nil, fmt.Errorf("Unable to retrieve ports from the Network API: %s", err)
+	}
+	createdAt := make(map[string]string)
+	for _, port := range portTimes {
+		createdAt[port.ID] = port.CreatedAt
+	}
+	sortInstancePorts(allPorts, createdAt)
+
+	log.Printf("[DEBUG] getInstancePorts: %#v", allPorts)
+	return allPorts, nil
+}
+
+// sortInstancePorts sorts ports by the time they were created, then by ID.
+// The Network API doesn't guarantee the order it lists ports in, and clouds
+// without the standard attributes extension don't report when a port was
+// created.
+func sortInstancePorts(instancePorts []ports.Port, createdAt map[string]string) {
+	sort.Slice(instancePorts, func(i, j int) bool {
+		a, b := instancePorts[i], instancePorts[j]
+		if createdAt[a.ID] != createdAt[b.ID] {
+			return createdAt[a.ID] < createdAt[b.ID]
+		}
+		return a.ID < b.ID
+	})
+}
+
 // getInstanceAddresses parses a Gophercloud server.Server's Address field into
-// a structured InstanceAddresses struct.
+// a structured InstanceAddresses struct, sorted by network name.
 func getInstanceAddresses(addresses map[string]interface{}) []InstanceAddresses {
 	var allInstanceAddresses []InstanceAddresses
 
-	for networkName, v := range addresses {
+	networkNames := make([]string, 0, len(addresses))
+	for networkName := range addresses {
+		networkNames = append(networkNames, networkName)
+	}
+	sort.Strings(networkNames)
+
+	for _, networkName := range networkNames {
+		v := addresses[networkName]
 		instanceAddresses := InstanceAddresses{
 			NetworkName: networkName,
 		}
@@ -399,30 +477,17 @@ func flattenInstanceNetworks(
 		return nil, err
 	}
 
-	networks := []map[string]interface{}{}
-
 	// If there were no instance networks returned, this means that there
 	// was not a network specified in the Terraform configuration. When this
 	// happens, the instance will be launched on a "default" network, if one
 	// is available. If there isn't, the instance will fail to launch, so
 	// this is a safe assumption at this point.
 	if len(allInstanceNetworks) == 0 {
-		for _, instanceAddresses := range allInstanceAddresses {
-			for _, instanceNIC := range instanceAddresses.InstanceNICs {
-				v := map[string]interface{}{
-					"name":        instanceAddresses.NetworkName,
-					"fixed_ip_v4": instanceNIC.FixedIPv4,
-					"fixed_ip_v6": instanceNIC.FixedIPv6,
-					"mac":         instanceNIC.MAC,
-				}
-				networks = append(networks, v)
-			}
-		}
-
-		log.Printf("[DEBUG] flattenInstanceNetworks: %#v", networks)
-		return networks, nil
+		return flattenServerNetworks(meta, GetRegion(d, config), server)
 	}
 
+	networks := []map[string]interface{}{}
+
 	// Loop through all networks and addresses, merge relevant address details.
 	for _, instanceNetwork := range allInstanceNetworks {
 		for _, instanceAddresses := range allInstanceAddresses {
@@ -453,6 +518,58 @@ func flattenInstanceNetworks(
 	return networks, nil
 }
 
+// flattenServerNetworks flattens the networks of a server out of its
+// addresses alone. The attached ports are mapped back to their networks so
+// the network IDs are known as well. This is all there is to go on when no
+// networks were configured, such as for a server looked up by a data source.
+//
+// Nova groups the addresses by network name in a map, which has no order, so
+// the NICs are put in the order their ports were created instead. NICs
+// without a port come last, sorted by network name.
+func flattenServerNetworks(
+	meta interface{}, region string, server *servers.Server) ([]map[string]interface{}, error) {
+
+	instancePorts, err := getInstancePorts(meta, region, server.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	portNetworks := make(map[string]string)
+	portIndexes := make(map[string]int)
+	for i, port := range instancePorts {
+		portNetworks[port.MACAddress] = port.NetworkID
+		portIndexes[port.MACAddress] = i
+	}
+	portIndex := func(network map[string]interface{}) int {
+		if i, ok := portIndexes[network["mac"].(string)]; ok {
+			return i
+		}
+		return len(instancePorts)
+	}
+
+	networks := []map[string]interface{}{}
+	for _, instanceAddresses := range getInstanceAddresses(server.Addresses) {
+		for _, instanceNIC := range instanceAddresses.InstanceNICs {
+			v := map[string]interface{}{
+				"name":           instanceAddresses.NetworkName,
+				"fixed_ip_v4":    instanceNIC.FixedIPv4,
+				"fixed_ip_v6":    instanceNIC.FixedIPv6,
+				"mac":            instanceNIC.MAC,
+				"uuid":           portNetworks[instanceNIC.MAC],
+				"access_network": false,
+			}
+			networks = append(networks, v)
+		}
+	}
+
+	sort.SliceStable(networks, func(i, j int) bool {
+		return portIndex(networks[i]) < portIndex(networks[j])
+	})
+
+	log.Printf("[DEBUG] flattenServerNetworks: %#v", networks)
+	return networks, nil
+}
+
 // getInstanceAccessAddresses determines the best IP address to communicate
 // with the instance. It does this by looping through all networks and looking
 // for a valid IP address. Priority is given to a network that was flagged as
@@ -490,3 +607,275 @@ func getInstanceAccessAddresses(
 
 	return hostv4, hostv6
 }
+
+// instanceNetworkKeys are the arguments of a network block which identify
+// the NIC it configures.
+var instanceNetworkKeys = []string{"uuid", "name", "port", "fixed_ip_v4", "fixed_ip_v6"}
+
+// instanceNetworkMatches reports whether a network of an instance is the one
+// of a configured network block. Arguments left out of the block match any
+// value, since they're computed.
+func instanceNetworkMatches(current, configured map[string]interface{}) bool {
+	for _, k := range instanceNetworkKeys {
+		v, _ := configured[k].(string)
+		if v != "" && v != current[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// planInstanceNetworkChanges works out how to change the networks of an
+// instance to the configured ones live. The current networks which are
+// still configured have to be in the same order, so any other current
+// network is detached and any configured network after them is attached.
+//
+// It returns false if the changes can't be made live. That's the case when
+// nova-network is used, when the port of a network to detach can't be
+// found, and when networks are both detached and attached, because a
+// network block which changed can't be told from one which was replaced,
+// and the NIC of a network can't be changed in place.
+func planInstanceNetworkChanges(
+	current, configured []map[string]interface{}) (detach, attach []map[string]interface{}, ok bool) {
+
+	if _, ok := os.LookupEnv("OS_NOVA_NETWORK"); ok {
+		return nil, nil, false
+	}
+
+	i := 0
+	for _, network := range current {
+		if i < len(configured) && instanceNetworkMatches(network, configured[i]) {
+			i++
+			continue
+		}
+
+		if network["port"] == "" && network["mac"] == "" {
+			return nil, nil, false
+		}
+		detach = append(detach, network)
+	}
+	attach = configured[i:]
+
+	if len(detach) > 0 && len(attach) > 0 {
+		return nil, nil, false
+	}
+
+	return detach, attach, true
+}
+
+// getInstanceNetworkConfig returns the arguments set in the network blocks
+// of an instance's configuration. It returns false if there are none, in
+// which case the networks are computed.
+func getInstanceNetworkConfig(c *terraform.ResourceConfig) ([]map[string]interface{}, bool) {
+	raw, ok := c.Get("network")
+	if !ok {
+		return nil, false
+	}
+
+	var count int
+	switch v := raw.(type) {
+	case []interface{}:
+		count = len(v)
+	case []map[string]interface{}:
+		count = len(v)
+	default:
+		return nil, false
+	}
+
+	// Values which are only known at apply time are left uninterpolated, so
+	// they don't match any network and the NICs they configure are attached
+	// again.
+	var configured []map[string]interface{}
+	for i := 0; i < count; i++ {
+		network := make(map[string]interface{})
+		for _, k := range instanceNetworkKeys {
+			if v, ok := c.Get(fmt.Sprintf("network.%d.%s", i, k)); ok {
+				network[k] = fmt.Sprint(v)
+			}
+		}
+		configured = append(configured, network)
+	}
+
+	return configured, true
+}
+
+// computeInstanceNetworkDiff marks the arguments left out of the network
+// blocks as computed once the networks change. Otherwise they'd keep the
+// values of the network which was at the same index before, and the NICs
+// to attach couldn't be told from the configuration.
+func computeInstanceNetworkDiff(
+	diff *terraform.InstanceDiff, s *terraform.InstanceState, configured []map[string]interface{}) {
+
+	var changed bool
+	for k := range diff.Attributes {
+		if strings.HasPrefix(k, "network.") {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	keys := append([]string{"mac"}, instanceNetworkKeys...)
+	for i, network := range configured {
+		for _, k := range keys {
+			if _, ok := network[k]; ok {
+				continue
+			}
+
+			key := fmt.Sprintf("network.%d.%s", i, k)
+			diff.Attributes[key] = &terraform.ResourceAttrDiff{
+				Old:         s.Attributes[key],
+				NewComputed: true,
+			}
+		}
+	}
+}
+
+func expandInstanceNetworkList(v interface{}) []map[string]interface{} {
+	var networks []map[string]interface{}
+	for _, network := range v.([]interface{}) {
+		networks = append(networks, network.(map[string]interface{}))
+	}
+	return networks
+}
+
+// updateInstanceNetworks detaches the networks which were removed from an
+// instance and attaches the ones which were added to it.
+func updateInstanceNetworks(
+	computeClient *gophercloud.ServiceClient, d *schema.ResourceData, meta interface{}) error {
+
+	oldNetworks, newNetworks := d.GetChange("network")
+	detach, attach, ok := planInstanceNetworkChanges(
+		expandInstanceNetworkList(oldNetworks), expandInstanceNetworkList(newNetworks))
+	if !ok {
+		return fmt.Errorf("The networks of instance (%s) can't be changed live", d.Id())
+	}
+
+	var interfaces []attachinterfaces.Interface
+	if len(detach) > 0 {
+		allPages, err := attachinterfaces.List(computeClient, d.Id()).AllPages()
+		if err != nil {
+			return fmt.Errorf("Error listing the interfaces of TelefonicaOpenCloud server (%s): %s", d.Id(), err)
+		}
+		interfaces, err = attachinterfaces.ExtractInterfaces(allPages)
+		if err != nil {
+			return fmt.Errorf("Error listing the interfaces of TelefonicaOpenCloud server (%s): %s", d.Id(), err)
+		}
+	}
+
+	for _, network := range detach {
+		portID, _ := network["port"].(string)
+		if portID == "" {
+			for _, i := range interfaces {
+				if i.MACAddr == network["mac"] {
+					portID = i.PortID
+				}
+			}
+		}
+		if portID == "" {
+			return fmt.Errorf("Unable to find the port of network %s on instance (%s)", network["name"], d.Id())
+		}
+
+		if err := detachInstanceInterface(computeClient, d, portID); err != nil {
+			return err
+		}
+	}
+
+	for _, network := range attach {
+		var createOpts attachinterfaces.CreateOpts
+		if portID, _ := network["port"].(string); portID != "" {
+			createOpts.PortID = portID
+		} else {
+			networkID, _ := network["uuid"].(string)
+			if networkID == "" {
+				networkInfo, err := getInstanceNetworkInfo(d, meta, "name", network["name"].(string))
+				if err != nil {
+					return err
+				}
+				networkID = networkInfo["uuid"].(string)
+			}
+			createOpts.NetworkID = networkID
+
+			for _, k := range []string{"fixed_ip_v4", "fixed_ip_v6"} {
+				if address, _ := network[k].(string); address != "" {
+					createOpts.FixedIPs = append(createOpts.FixedIPs, attachinterfaces.FixedIP{IPAddress: address})
+				}
+			}
+		}
+
+		if err := attachInstanceInterface(computeClient, d, createOpts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func attachInstanceInterface(
+	computeClient *gophercloud.ServiceClient, d *schema.ResourceData, createOpts attachinterfaces.CreateOpts) error {
+
+	log.Printf("[DEBUG] Attaching interface to instance (%s): %#v", d.Id(), createOpts)
+	attachment, err := attachinterfaces.Create(computeClient, d.Id(), createOpts).Extract()
+	if err != nil {
+		return fmt.Errorf("Error attaching interface to TelefonicaOpenCloud server (%s): %s", d.Id(), err)
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"BUILD", "DOWN"},
+		Target:     []string{"ACTIVE"},
+		Refresh:    InterfaceAttachV2StateRefreshFunc(computeClient, d.Id(), attachment.PortID),
+		Timeout:    d.Timeout(schema.TimeoutUpdate),
+		Delay:      5 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, err = stateConf.WaitForState(); err != nil {
+		return fmt.Errorf("Error waiting for port (%s) to attach to instance (%s): %s", attachment.PortID, d.Id(), err)
+	}
+
+	return nil
+}
+
+func detachInstanceInterface(
+	computeClient *gophercloud.ServiceClient, d *schema.ResourceData, portID string) error {
+
+	log.Printf("[DEBUG] Detaching port (%s) from instance (%s)", portID, d.Id())
+	err := attachinterfaces.Delete(computeClient, d.Id(), portID).ExtractErr()
+	if err != nil {
+		return fmt.Errorf("Error detaching port (%s) from TelefonicaOpenCloud server (%s): %s", portID, d.Id(), err)
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"ACTIVE", "BUILD", "DOWN"},
+		Target:     []string{"DETACHED"},
+		Refresh:    InterfaceAttachV2StateRefreshFunc(computeClient, d.Id(), portID),
+		Timeout:    d.Timeout(schema.TimeoutUpdate),
+		Delay:      5 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, err = stateConf.WaitForState(); err != nil {
+		return fmt.Errorf("Error waiting for port (%s) to detach from instance (%s): %s", portID, d.Id(), err)
+	}
+
+	return nil
+}
+
+// InterfaceAttachV2StateRefreshFunc returns a resource.StateRefreshFunc that
+// is used to watch a port attached to an instance. The port is DETACHED once
+// the instance no longer has it.
+func InterfaceAttachV2StateRefreshFunc(
+	client *gophercloud.ServiceClient, instanceID, portID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		attachment, err := attachinterfaces.Get(client, instanceID, portID).Extract()
+		if err != nil {
+			if _, ok := err.(gophercloud.ErrDefault404); ok {
+				return attachment, "DETACHED", nil
+			}
+			return nil, "", err
+		}
+
+		return attachment, attachment.PortState, nil
+	}
+}
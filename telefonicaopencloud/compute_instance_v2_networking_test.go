@@ -0,0 +1,45 @@
+package telefonicaopencloud
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+)
+
+func TestSortInstancePorts(t *testing.T) {
+	createdAt := map[string]string{
+		"port-a": "2018-03-14T08:00:01Z",
+		"port-b": "2018-03-14T08:00:01Z",
+		"port-c": "2018-03-14T08:00:00Z",
+		"port-d": "2018-03-14T08:01:00Z",
+		"port-e": "2018-03-14T08:01:00Z",
+	}
+	expected := []string{"port-c", "port-a", "port-b", "port-d", "port-e"}
+
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 20; n++ {
+		var shuffled []ports.Port
+		for _, i := range r.Perm(len(expected)) {
+			shuffled = append(shuffled, ports.Port{ID: expected[i]})
+		}
+
+		sortInstancePorts(shuffled, createdAt)
+		for i, port := range shuffled {
+			if port.ID != expected[i] {
+				t.Fatalf("Expected the ports to be sorted as %v, got %v", expected, shuffled)
+			}
+		}
+	}
+}
+
+func TestSortInstancePorts_noCreatedAt(t *testing.T) {
+	instancePorts := []ports.Port{{ID: "port-b"}, {ID: "port-c"}, {ID: "port-a"}}
+
+	sortInstancePorts(instancePorts, map[string]string{})
+	for i, id := range []string{"port-a", "port-b", "port-c"} {
+		if instancePorts[i].ID != id {
+			t.Fatalf("Expected the ports to be sorted by ID, got %v", instancePorts)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// This set of code handles the power state and lock of a
+// telefonicaopencloud_compute_instance_v2 resource.
+//
+// Nova only allows an instance to be stopped, suspended or paused while it's
+// active, so moving between two of those states goes through active.
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/lockunlock"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/pauseunpause"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/startstop"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/suspendresume"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+// InstancePowerState describes how an instance enters and leaves one of
+// the power states it can be put in from active.
+type InstancePowerState struct {
+	Status string
+	Enter  func(client *gophercloud.ServiceClient, id string) error
+	Leave  func(client *gophercloud.ServiceClient, id string) error
+}
+
+var InstancePowerStates = map[string]InstancePowerState{
+	"active": {
+		Status: "ACTIVE",
+	},
+	"shutoff": {
+		Status: "SHUTOFF",
+		Enter: func(client *gophercloud.ServiceClient, id string) error {
+			return startstop.Stop(client, id).ExtractErr()
+		},
+		Leave: func(client *gophercloud.ServiceClient, id string) error {
+			return startstop.Start(client, id).ExtractErr()
+		},
+	},
+	"suspended": {
+		Status: "SUSPENDED",
+		Enter: func(client *gophercloud.ServiceClient, id string) error {
+			return suspendresume.Suspend(client, id).ExtractErr()
+		},
+		Leave: func(client *gophercloud.ServiceClient, id string) error {
+			return suspendresume.Resume(client, id).ExtractErr()
+		},
+	},
+	"paused": {
+		Status: "PAUSED",
+		Enter: func(client *gophercloud.ServiceClient, id string) error {
+			return pauseunpause.Pause(client, id).ExtractErr()
+		},
+		Leave: func(client *gophercloud.ServiceClient, id string) error {
+			return pauseunpause.Unpause(client, id).ExtractErr()
+		},
+	},
+}
+
+func resourceComputeInstanceV2ValidatePowerState(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if _, ok := InstancePowerStates[value]; !ok {
+		errors = append(errors, fmt.Errorf("%q must be one of active, shutoff, suspended or paused", k))
+	}
+	return
+}
+
+// getInstancePowerState returns the power state of an instance in the given
+// status. It returns false while the instance is in any other status, such
+// as BUILD or ERROR.
+func getInstancePowerState(status string) (string, bool) {
+	for powerState, v := range InstancePowerStates {
+		if v.Status == status {
+			return powerState, true
+		}
+	}
+	return "", false
+}
+
+// setInstancePowerState moves an instance to the power state and waits for
+// it to get there.
+func setInstancePowerState(
+	client *gophercloud.ServiceClient, id, powerState string, timeout time.Duration) error {
+
+	server, err := servers.Get(client, id).Extract()
+	if err != nil {
+		return fmt.Errorf("Error retrieving TelefonicaOpenCloud server %s: %s", id, err)
+	}
+
+	current, ok := getInstancePowerState(server.Status)
+	if !ok {
+		return fmt.Errorf("Unable to change the power state of instance (%s) in status %s", id, server.Status)
+	}
+	if current == powerState {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Changing the power state of instance (%s) from %s to %s", id, current, powerState)
+
+	if current != "active" {
+		if err := InstancePowerStates[current].Leave(client, id); err != nil {
+			return fmt.Errorf("Error making TelefonicaOpenCloud instance (%s) active: %s", id, err)
+		}
+		if err := waitForInstancePowerState(client, id, current, "active", timeout); err != nil {
+			return err
+		}
+	}
+
+	if powerState != "active" {
+		if err := InstancePowerStates[powerState].Enter(client, id); err != nil {
+			return fmt.Errorf("Error changing the power state of TelefonicaOpenCloud instance (%s) to %s: %s", id, powerState, err)
+		}
+		if err := waitForInstancePowerState(client, id, "active", powerState, timeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func waitForInstancePowerState(
+	client *gophercloud.ServiceClient, id, from, to string, timeout time.Duration) error {
+
+	log.Printf("[DEBUG] Waiting for instance (%s) to become %s", id, to)
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{InstancePowerStates[from].Status},
+		Target:     []string{InstancePowerStates[to].Status},
+		Refresh:    ServerV2StateRefreshFunc(client, id),
+		Timeout:    timeout,
+		Delay:      10 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	_, err := stateConf.WaitForState()
+	if err != nil {
+		return fmt.Errorf("Error waiting for instance (%s) to become %s: %s", id, to, err)
+	}
+
+	return nil
+}
+
+// setInstanceLocked locks or unlocks an instance. A locked instance can't be
+// changed or deleted until it's unlocked.
+func setInstanceLocked(client *gophercloud.ServiceClient, id string, locked bool) error {
+	if locked {
+		log.Printf("[DEBUG] Locking instance (%s)", id)
+		if err := lockunlock.Lock(client, id).ExtractErr(); err != nil {
+			return fmt.Errorf("Error locking TelefonicaOpenCloud instance (%s): %s", id, err)
+		}
+		return nil
+	}
+
+	log.Printf("[DEBUG] Unlocking instance (%s)", id)
+	if err := lockunlock.Unlock(client, id).ExtractErr(); err != nil {
+		return fmt.Errorf("Error unlocking TelefonicaOpenCloud instance (%s): %s", id, err)
+	}
+	return nil
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -33,15 +36,20 @@ type Config struct {
 	Cloud            string
 	DomainID         string
 	DomainName       string
+	Endpoints        map[string]string
 	EndpointType     string
 	IdentityEndpoint string
 	Insecure         bool
+	MaxRetries       int
 	Password         string
 	Region           string
+	RetryMinDelay    time.Duration
+	RetryMaxDelay    time.Duration
 	Swauth           bool
 	TenantID         string
 	TenantName       string
 	Token            string
+	TraceFile        string
 	Username         string
 	UserID           string
 	useOctavia       bool
@@ -49,9 +57,27 @@ type Config struct {
 	OsClient *gophercloud.ProviderClient
 	HwClient *golangsdk.ProviderClient
 	s3sess   *session.Session
+
+	authOnce sync.Once
+	authErr  error
+	trace    *traceFile
+
+	// akskProjects caches the project ID of each region when requests
+	// are signed with AK/SK.
+	akskProjects   map[string]string
+	akskProjectsMu sync.Mutex
+
+	// serviceClients caches the service clients of both SDKs, keyed by
+	// service, region and endpoint type.
+	serviceClients   map[string]interface{}
+	serviceClientsMu sync.Mutex
 }
 
 func (c *Config) LoadAndValidate() error {
+	if err := c.loadCloud(); err != nil {
+		return err
+	}
+
 	validEndpoint := false
 	validEndpoints := []string{
 		"internal", "internalURL",
@@ -69,45 +95,103 @@ func (c *Config) LoadAndValidate() error {
 	if !validEndpoint {
 		return fmt.Errorf("Invalid endpoint type provided")
 	}
-	err := newopenstackClient(c)
-	if err != nil {
+
+	// Everything which can be checked without calling the cloud is checked
+	// now, so that mistakes are reported before any resource is touched.
+	if err := c.validateAuthURL(); err != nil {
+		return err
+	}
+
+	if err := c.validateCredentials(); err != nil {
+		return err
+	}
+
+	if _, err := c.loadTLSConfig(); err != nil {
 		return err
 	}
 
-	return newhwClient(c)
+	if c.TraceFile != "" {
+		trace, err := openTraceFile(c.TraceFile)
+		if err != nil {
+			return err
+		}
+		c.trace = trace
+	}
 
+	// Authentication is deferred until the first service client is
+	// requested, so that a plan doesn't need credentials which are only
+	// known once other resources have been created.
+	return nil
 }
 
-func newopenstackClient(c *Config) error {
-	ao := gophercloud.AuthOptions{
-		DomainID:         c.DomainID,
-		DomainName:       c.DomainName,
-		IdentityEndpoint: c.IdentityEndpoint,
-		Password:         c.Password,
-		TenantID:         c.TenantID,
-		TenantName:       c.TenantName,
-		TokenID:          c.Token,
-		Username:         c.Username,
-		UserID:           c.UserID,
+// validateAuthURL checks the syntax of auth_url, if it's known.
+func (c *Config) validateAuthURL() error {
+	if c.IdentityEndpoint == "" {
+		return nil
 	}
 
-	client, err := openstack.NewClient(ao.IdentityEndpoint)
+	u, err := url.Parse(c.IdentityEndpoint)
 	if err != nil {
-		return err
+		return fmt.Errorf("Invalid auth_url %q: %s", c.IdentityEndpoint, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Invalid auth_url %q: it must be an http or https URL", c.IdentityEndpoint)
 	}
 
-	// Set UserAgent
-	client.UserAgent.Prepend(terraform.UserAgentString())
+	return nil
+}
+
+// validateCredentials checks that the credentials can be combined. Unless
+// AK/SK is used, this builds the Identity v3 token request the way
+// authentication does, but only once a password or token is known, since
+// credentials may be computed from other resources.
+func (c *Config) validateCredentials() error {
+	if (c.AccessKey == "") != (c.SecretKey == "") {
+		return fmt.Errorf("access_key and secret_key must be set together")
+	}
+
+	if c.usingAKSK() || c.Swauth || (c.Password == "" && c.Token == "") {
+		return nil
+	}
+	if !strings.HasSuffix(strings.TrimSuffix(c.IdentityEndpoint, "/"), "/v3") {
+		return nil
+	}
+
+	ao := gophercloud.AuthOptions{
+		DomainID:   c.DomainID,
+		DomainName: c.DomainName,
+		Password:   c.Password,
+		TenantID:   c.TenantID,
+		TenantName: c.TenantName,
+		TokenID:    c.Token,
+		Username:   c.Username,
+		UserID:     c.UserID,
+	}
+	scope, err := ao.ToTokenV3ScopeMap()
+	if err == nil {
+		_, err = ao.ToTokenV3CreateMap(scope)
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid credentials: %s", err)
+	}
 
+	return nil
+}
+
+// loadTLSConfig builds the TLS configuration of the clients from the CA,
+// client certificate and key files.
+func (c *Config) loadTLSConfig() (*tls.Config, error) {
 	config := &tls.Config{}
 	if c.CACertFile != "" {
 		caCert, _, err := pathorcontents.Read(c.CACertFile)
 		if err != nil {
-			return fmt.Errorf("Error reading CA Cert: %s", err)
+			return nil, fmt.Errorf("Error reading CA Cert: %s", err)
 		}
 
 		caCertPool := x509.NewCertPool()
-		caCertPool.AppendCertsFromPEM([]byte(caCert))
+		if !caCertPool.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, fmt.Errorf("Error reading CA Cert: no PEM encoded certificate found")
+		}
 		config.RootCAs = caCertPool
 	}
 
@@ -115,25 +199,93 @@ func newopenstackClient(c *Config) error {
 		config.InsecureSkipVerify = true
 	}
 
+	if (c.ClientCertFile == "") != (c.ClientKeyFile == "") {
+		return nil, fmt.Errorf("cert and key must be set together")
+	}
+
 	if c.ClientCertFile != "" && c.ClientKeyFile != "" {
 		clientCert, _, err := pathorcontents.Read(c.ClientCertFile)
 		if err != nil {
-			return fmt.Errorf("Error reading Client Cert: %s", err)
+			return nil, fmt.Errorf("Error reading Client Cert: %s", err)
 		}
 		clientKey, _, err := pathorcontents.Read(c.ClientKeyFile)
 		if err != nil {
-			return fmt.Errorf("Error reading Client Key: %s", err)
+			return nil, fmt.Errorf("Error reading Client Key: %s", err)
 		}
 
 		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		config.Certificates = []tls.Certificate{cert}
 		config.BuildNameToCertificate()
 	}
 
+	return config, nil
+}
+
+// traceRoundTripper wraps rt to write every request to the trace file, if
+// one is configured.
+func (c *Config) traceRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	if c.trace == nil {
+		return rt
+	}
+	return &TraceRoundTripper{Rt: rt, Trace: c.trace}
+}
+
+// cassetteRoundTripper wraps rt to record or replay requests when the
+// acceptance tests are run with cassettes.
+func cassetteRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	if cassettePlayer == nil {
+		return rt
+	}
+	return &CassetteRoundTripper{Rt: rt}
+}
+
+// authenticate creates and authenticates the ProviderClients of both SDKs
+// the first time it's called. Later calls return the same error, if any.
+func (c *Config) authenticate() error {
+	c.authOnce.Do(func() {
+		c.trace.addEndpoint(c.IdentityEndpoint, "identity")
+		if c.authErr = newopenstackClient(c); c.authErr != nil {
+			return
+		}
+		c.authErr = newhwClient(c)
+	})
+	return c.authErr
+}
+
+func newopenstackClient(c *Config) error {
+	ao := gophercloud.AuthOptions{
+		DomainID:         c.DomainID,
+		DomainName:       c.DomainName,
+		IdentityEndpoint: c.IdentityEndpoint,
+		Password:         c.Password,
+		TenantID:         c.TenantID,
+		TenantName:       c.TenantName,
+		TokenID:          c.Token,
+		Username:         c.Username,
+		UserID:           c.UserID,
+		AllowReauth:      c.canReauth(),
+	}
+
+	client, err := openstack.NewClient(ao.IdentityEndpoint)
+	if err != nil {
+		return err
+	}
+
+	// Set UserAgent
+	client.UserAgent.Prepend(terraform.UserAgentString())
+
+	// Share a single re-authentication between concurrent requests.
+	client.UseTokenLock()
+
+	config, err := c.loadTLSConfig()
+	if err != nil {
+		return err
+	}
+
 	// if OS_DEBUG is set, log the requests and responses
 	var osDebug bool
 	if os.Getenv("OS_DEBUG") != "" {
@@ -142,18 +294,43 @@ func newopenstackClient(c *Config) error {
 
 	transport := &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: config}
 	client.HTTPClient = http.Client{
-		Transport: &LogRoundTripper{
-			Rt:      transport,
-			OsDebug: osDebug,
+		Transport: &RetryRoundTripper{
+			Rt: &LogRoundTripper{
+				Rt:      c.traceRoundTripper(cassetteRoundTripper(transport)),
+				OsDebug: osDebug,
+			},
+			MaxRetries: c.MaxRetries,
+			MinDelay:   c.RetryMinDelay,
+			MaxDelay:   c.RetryMaxDelay,
 		},
 	}
 
+	if c.Token != "" {
+		client.HTTPClient.Transport = &FixedTokenRoundTripper{
+			Rt: client.HTTPClient.Transport,
+		}
+	}
+
 	// If using Swift Authentication, there's no need to validate authentication normally.
-	if !c.Swauth {
+	// With AK/SK only there are no Keystone credentials to authenticate with at all.
+	if c.usingAKSK() {
+		client.EndpointLocator = func(opts gophercloud.EndpointOpts) (string, error) {
+			return "", fmt.Errorf("The %s service requires user_name/password or token authentication, "+
+				"it can't be used with access_key/secret_key only", opts.Type)
+		}
+	} else if !c.Swauth {
 		err = openstack.Authenticate(client, ao)
 		if err != nil {
 			return err
 		}
+
+		catalog := client.EndpointLocator
+		client.EndpointLocator = func(opts gophercloud.EndpointOpts) (string, error) {
+			if endpoint, ok := c.endpointForType(opts.Type); ok {
+				return endpoint, nil
+			}
+			return catalog(opts)
+		}
 	}
 
 	c.OsClient = client
@@ -185,6 +362,10 @@ func newopenstackClient(c *Config) error {
 			HTTPClient:  cleanhttp.DefaultClient(),
 		}
 
+		if c.MaxRetries > 0 {
+			sConfig.MaxRetries = aws.Int(c.MaxRetries)
+		}
+
 		if osDebug {
 			sConfig.LogLevel = aws.LogLevel(aws.LogDebugWithHTTPBody | aws.LogDebugWithRequestRetries | aws.LogDebugWithRequestErrors)
 			sConfig.Logger = sLogger{}
@@ -218,6 +399,7 @@ func newhwClient(c *Config) error {
 		TokenID:          c.Token,
 		Username:         c.Username,
 		UserID:           c.UserID,
+		AllowReauth:      c.canReauth(),
 	}
 
 	client, err := huaweisdk.NewClient(ao.IdentityEndpoint)
@@ -228,53 +410,56 @@ func newhwClient(c *Config) error {
 	// Set UserAgent
 	client.UserAgent.Prepend(terraform.UserAgentString())
 
-	config := &tls.Config{}
-	if c.CACertFile != "" {
-		caCert, _, err := pathorcontents.Read(c.CACertFile)
-		if err != nil {
-			return fmt.Errorf("Error reading CA Cert: %s", err)
-		}
+	// Share a single re-authentication between concurrent requests.
+	client.UseTokenLock()
 
-		caCertPool := x509.NewCertPool()
-		caCertPool.AppendCertsFromPEM([]byte(caCert))
-		config.RootCAs = caCertPool
+	config, err := c.loadTLSConfig()
+	if err != nil {
+		return err
 	}
 
-	if c.Insecure {
-		config.InsecureSkipVerify = true
+	// if OS_DEBUG is set, log the requests and responses
+	var osDebug bool
+	if os.Getenv("OS_DEBUG") != "" {
+		osDebug = true
 	}
 
-	if c.ClientCertFile != "" && c.ClientKeyFile != "" {
-		clientCert, _, err := pathorcontents.Read(c.ClientCertFile)
-		if err != nil {
-			return fmt.Errorf("Error reading Client Cert: %s", err)
-		}
-		clientKey, _, err := pathorcontents.Read(c.ClientKeyFile)
-		if err != nil {
-			return fmt.Errorf("Error reading Client Key: %s", err)
-		}
+	var rt http.RoundTripper = &LogRoundTripper{
+		Rt:      c.traceRoundTripper(cassetteRoundTripper(&http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: config})),
+		OsDebug: osDebug,
+	}
 
-		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
-		if err != nil {
-			return err
+	// With AK/SK every request is signed instead of carrying a token. This
+	// happens below the retries so that each one is signed with a fresh date.
+	if c.usingAKSK() {
+		rt = &AKSKRoundTripper{
+			Rt:        rt,
+			AccessKey: c.AccessKey,
+			SecretKey: c.SecretKey,
 		}
+	}
 
-		config.Certificates = []tls.Certificate{cert}
-		config.BuildNameToCertificate()
+	client.HTTPClient = http.Client{
+		Transport: &RetryRoundTripper{
+			Rt:         rt,
+			MaxRetries: c.MaxRetries,
+			MinDelay:   c.RetryMinDelay,
+			MaxDelay:   c.RetryMaxDelay,
+		},
 	}
 
-	// if OS_DEBUG is set, log the requests and responses
-	var osDebug bool
-	if os.Getenv("OS_DEBUG") != "" {
-		osDebug = true
+	if c.Token != "" {
+		client.HTTPClient.Transport = &FixedTokenRoundTripper{
+			Rt: client.HTTPClient.Transport,
+		}
 	}
 
-	transport := &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: config}
-	client.HTTPClient = http.Client{
-		Transport: &LogRoundTripper{
-			Rt:      transport,
-			OsDebug: osDebug,
-		},
+	// AK/SK signed requests need no authentication up front.
+	if c.usingAKSK() {
+		log.Printf("[INFO] Signing TelefonicaOpenCloud requests with access_key/secret_key")
+		c.HwClient = client
+
+		return nil
 	}
 
 	// If using Swift Authentication, there's no need to validate authentication normally.
@@ -283,6 +468,18 @@ func newhwClient(c *Config) error {
 		if err != nil {
 			return err
 		}
+
+		if ao.AllowReauth {
+			client.ReauthFunc = newHwReauthFunc(client, ao)
+		}
+
+		catalog := client.EndpointLocator
+		client.EndpointLocator = func(opts golangsdk.EndpointOpts) (string, error) {
+			if endpoint, ok := c.endpointForType(opts.Type); ok {
+				return endpoint, nil
+			}
+			return catalog(opts)
+		}
 	}
 
 	c.HwClient = client
@@ -290,6 +487,209 @@ func newhwClient(c *Config) error {
 	return nil
 }
 
+// newHwReauthFunc returns a ReauthFunc which only replaces the token of
+// client. The golangsdk one re-authenticates the client in place, replacing
+// fields like ReauthFunc while concurrent requests are reading them.
+func newHwReauthFunc(client *golangsdk.ProviderClient, ao golangsdk.AuthOptions) func() error {
+	// Authenticate a throw-away copy of the client which can't re-authenticate
+	// itself, so a failure isn't retried.
+	tao := ao
+	tao.AllowReauth = false
+	tac := *client
+	tac.ReauthFunc = nil
+
+	return func() error {
+		tac.TokenID = ""
+		if err := huaweisdk.Authenticate(&tac, tao); err != nil {
+			return err
+		}
+
+		client.TokenID = tac.TokenID
+		log.Printf("[DEBUG] Re-authenticated TelefonicaOpenCloud client")
+		return nil
+	}
+}
+
+// endpointServiceTypes maps every key of the endpoints argument to the
+// catalog service type it replaces. Services which are derived from another
+// catalog entry have no type of their own and are overridden by their client
+// function instead.
+var endpointServiceTypes = map[string]string{
+	"autoscaling": "as",
+	"ces":         "ces",
+	"compute":     "compute",
+	"dns":         "dns",
+	"elb":         "",
+	"identity":    "identity",
+	"image":       "image",
+	"network":     "network",
+	"networkv1":   "",
+	"obs":         "",
+	"rds":         "database",
+	"smn":         "",
+	"volumev2":    "volumev2",
+}
+
+// endpoint returns the endpoint set for a key of the endpoints argument.
+func (c *Config) endpoint(key string) (string, bool) {
+	if v, ok := c.Endpoints[key]; ok && v != "" {
+		return gophercloud.NormalizeURL(v), true
+	}
+	return "", false
+}
+
+// endpointForType returns the endpoint set for a catalog service type.
+func (c *Config) endpointForType(serviceType string) (string, bool) {
+	for key, t := range endpointServiceTypes {
+		if t != "" && t == serviceType {
+			return c.endpoint(key)
+		}
+	}
+	return "", false
+}
+
+// canReauth reports whether the credentials can be used to acquire a new
+// token once the current one expires. A token passed in by the user can only
+// ever be exchanged for a token with the same expiry.
+func (c *Config) canReauth() bool {
+	return c.Token == "" && c.Password != ""
+}
+
+// usingAKSK reports whether requests are signed with access_key/secret_key
+// because no Keystone credentials were provided.
+func (c *Config) usingAKSK() bool {
+	return c.AccessKey != "" && c.SecretKey != "" && c.Password == "" && c.Token == ""
+}
+
+// akskEndpoints holds the URL of each service type used by AK/SK signed
+// clients, since the service catalog is only returned with a token.
+var akskEndpoints = map[string]string{
+	"compute": "https://ecs.{region}.{domain}/v2/{project_id}/",
+	"network": "https://vpc.{region}.{domain}/",
+	"as":      "https://as.{region}.{domain}/autoscaling-api/v1/",
+	"ces":     "https://ces.{region}.{domain}/V1.0/",
+	"obs":     "https://obs.{region}.{domain}/",
+}
+
+// akskEndpointURL renders the endpoint of a service type for a region.
+func (c *Config) akskEndpointURL(serviceType, region, projectID string) (string, error) {
+	tmpl, ok := akskEndpoints[serviceType]
+	if !ok {
+		return "", fmt.Errorf("The %s service is not supported with access_key/secret_key authentication", serviceType)
+	}
+
+	var domain string
+	if strings.Contains(tmpl, "{domain}") {
+		var err error
+		if domain, err = c.akskDomain(); err != nil {
+			return "", err
+		}
+	}
+
+	return strings.NewReplacer(
+		"{region}", region,
+		"{domain}", domain,
+		"{project_id}", projectID,
+	).Replace(tmpl), nil
+}
+
+// akskDomain returns the cloud domain, which is whatever follows the IAM host
+// and region in auth_url, e.g. https://iam.<region>.<domain>/v3.
+func (c *Config) akskDomain() (string, error) {
+	u, err := url.Parse(c.IdentityEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing auth_url: %s", err)
+	}
+
+	domain := strings.TrimPrefix(u.Hostname(), "iam.")
+	if c.Region != "" {
+		domain = strings.TrimPrefix(domain, c.Region+".")
+	}
+	if domain == u.Hostname() || !strings.Contains(domain, ".") {
+		return "", fmt.Errorf("Unable to derive the cloud domain from auth_url %q, which should look like "+
+			"https://iam.<region>.<domain>/v3. Set the endpoints of the services used with "+
+			"access_key/secret_key in the endpoints argument instead.", c.IdentityEndpoint)
+	}
+
+	return domain, nil
+}
+
+// akskProjectID returns the ID of the project used in a region. The project
+// named after the region is used unless tenant_id or tenant_name is set for
+// the provider-level region.
+func (c *Config) akskProjectID(region string) (string, error) {
+	if region == c.Region && c.TenantID != "" {
+		return c.TenantID, nil
+	}
+
+	c.akskProjectsMu.Lock()
+	defer c.akskProjectsMu.Unlock()
+
+	if id, ok := c.akskProjects[region]; ok {
+		return id, nil
+	}
+
+	name := region
+	if region == c.Region && c.TenantName != "" {
+		name = c.TenantName
+	}
+
+	identity, err := huaweisdk.NewIdentityV3(c.HwClient, golangsdk.EndpointOpts{})
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Projects []struct {
+			ID string `json:"id"`
+		} `json:"projects"`
+	}
+	_, err = identity.Get(identity.ServiceURL("projects")+"?name="+url.QueryEscape(name), &result, nil)
+	if err != nil {
+		return "", fmt.Errorf("Error retrieving the project of region %s: %s", region, err)
+	}
+	if len(result.Projects) != 1 {
+		return "", fmt.Errorf("Expected 1 project named %s, got %d", name, len(result.Projects))
+	}
+
+	if c.akskProjects == nil {
+		c.akskProjects = make(map[string]string)
+	}
+	c.akskProjects[region] = result.Projects[0].ID
+	log.Printf("[DEBUG] TelefonicaOpenCloud project of region %s is %s", region, result.Projects[0].ID)
+
+	return result.Projects[0].ID, nil
+}
+
+// hwClient returns the ProviderClient to build golangsdk service clients for
+// a region from. AK/SK signed clients are scoped to the region's project and
+// locate endpoints without a service catalog.
+func (c *Config) hwClient(region string) (*golangsdk.ProviderClient, error) {
+	if !c.usingAKSK() {
+		return c.HwClient, nil
+	}
+
+	projectID, err := c.akskProjectID(region)
+	if err != nil {
+		return nil, err
+	}
+
+	client := *c.HwClient
+	client.ProjectID = projectID
+	client.HTTPClient.Transport = &AKSKProjectRoundTripper{
+		Rt:        c.HwClient.HTTPClient.Transport,
+		ProjectID: projectID,
+	}
+	client.EndpointLocator = func(opts golangsdk.EndpointOpts) (string, error) {
+		if endpoint, ok := c.endpointForType(opts.Type); ok {
+			return endpoint, nil
+		}
+		return c.akskEndpointURL(opts.Type, region, projectID)
+	}
+
+	return &client, nil
+}
+
 type sLogger struct{}
 
 func (l sLogger) Log(args ...interface{}) {
@@ -309,21 +709,106 @@ func (c *Config) determineRegion(region string) string {
 		region = c.Region
 	}
 
-	log.Printf("[DEBUG] TelefonicaOpenCloud Region is: %s", region)
 	return region
 }
 
+// osServiceClient returns the cached gophercloud client of a service in a
+// region, building it with newClient if there is none yet.
+func (c *Config) osServiceClient(service, region string, newClient func(region string) (*gophercloud.ServiceClient, error)) (*gophercloud.ServiceClient, error) {
+	if err := c.authenticate(); err != nil {
+		return nil, err
+	}
+
+	region = c.determineRegion(region)
+	key := c.serviceClientKey(service, region)
+	if client, ok := c.cachedServiceClient(key); ok {
+		return client.(*gophercloud.ServiceClient), nil
+	}
+
+	log.Printf("[DEBUG] Creating TelefonicaOpenCloud %s client for region %s", service, region)
+	client, err := newClient(region)
+	if err != nil {
+		return nil, err
+	}
+	c.trace.addEndpoint(client.ResourceBaseURL(), service)
+	return c.cacheServiceClient(key, client).(*gophercloud.ServiceClient), nil
+}
+
+// hwServiceClient returns the cached golangsdk client of a service in a
+// region, building it with newClient if there is none yet.
+func (c *Config) hwServiceClient(service, region string, newClient func(region string) (*golangsdk.ServiceClient, error)) (*golangsdk.ServiceClient, error) {
+	if err := c.authenticate(); err != nil {
+		return nil, err
+	}
+
+	region = c.determineRegion(region)
+	key := c.serviceClientKey(service, region)
+	if client, ok := c.cachedServiceClient(key); ok {
+		return client.(*golangsdk.ServiceClient), nil
+	}
+
+	log.Printf("[DEBUG] Creating TelefonicaOpenCloud %s client for region %s", service, region)
+	client, err := newClient(region)
+	if err != nil {
+		return nil, err
+	}
+	c.trace.addEndpoint(client.ResourceBaseURL(), service)
+	return c.cacheServiceClient(key, client).(*golangsdk.ServiceClient), nil
+}
+
+func (c *Config) serviceClientKey(service, region string) string {
+	return strings.Join([]string{service, region, string(c.getEndpointType())}, "/")
+}
+
+func (c *Config) cachedServiceClient(key string) (interface{}, bool) {
+	c.serviceClientsMu.Lock()
+	defer c.serviceClientsMu.Unlock()
+
+	client, ok := c.serviceClients[key]
+	return client, ok
+}
+
+// cacheServiceClient stores a client unless another caller has been faster,
+// and returns the client which is cached. Errors are never cached, so a
+// failed endpoint lookup is tried again next time.
+func (c *Config) cacheServiceClient(key string, client interface{}) interface{} {
+	c.serviceClientsMu.Lock()
+	defer c.serviceClientsMu.Unlock()
+
+	if cached, ok := c.serviceClients[key]; ok {
+		return cached
+	}
+	if c.serviceClients == nil {
+		c.serviceClients = make(map[string]interface{})
+	}
+	c.serviceClients[key] = client
+	return client
+}
+
 func (c *Config) computeS3conn(region string) (*s3.S3, error) {
+	if err := c.authenticate(); err != nil {
+		return nil, err
+	}
+
 	if c.s3sess == nil {
 		return nil, fmt.Errorf("Missing credentials for Swift S3 Provider, need access_key and secret_key values for provider.")
 	}
 
-	client, err := openstack.NewNetworkV2(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
-	})
-	// Bit of a hack, seems the only way to compute this.
-	endpoint := strings.Replace(client.Endpoint, "//vpc", "//obs", 1)
+	endpoint, ok := c.endpoint("obs")
+	var err error
+	if ok {
+		log.Printf("[DEBUG] Using OBS endpoint %s", endpoint)
+	} else if c.usingAKSK() {
+		endpoint, err = c.akskEndpointURL("obs", c.determineRegion(region), "")
+	} else {
+		var client *gophercloud.ServiceClient
+		client, err = openstack.NewNetworkV2(c.OsClient, gophercloud.EndpointOpts{
+			Region:       c.determineRegion(region),
+			Availability: c.getEndpointType(),
+		})
+		// Bit of a hack, seems the only way to compute this.
+		endpoint = strings.Replace(client.Endpoint, "//vpc", "//obs", 1)
+	}
 
 	S3Sess := c.s3sess.Copy(&aws.Config{Endpoint: aws.String(endpoint)})
 	s3conn := s3.New(S3Sess)
@@ -332,108 +817,183 @@ func (c *Config) computeS3conn(region string) (*s3.S3, error) {
 }
 
 func (c *Config) blockStorageV1Client(region string) (*gophercloud.ServiceClient, error) {
-	return openstack.NewBlockStorageV1(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+	return c.osServiceClient("blockstoragev1", region, func(region string) (*gophercloud.ServiceClient, error) {
+		return openstack.NewBlockStorageV1(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) blockStorageV2Client(region string) (*gophercloud.ServiceClient, error) {
-	return openstack.NewBlockStorageV2(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+	return c.osServiceClient("blockstoragev2", region, func(region string) (*gophercloud.ServiceClient, error) {
+		return openstack.NewBlockStorageV2(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) computeV2Client(region string) (*gophercloud.ServiceClient, error) {
-	return openstack.NewComputeV2(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+	return c.osServiceClient("compute", region, func(region string) (*gophercloud.ServiceClient, error) {
+		return openstack.NewComputeV2(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) dnsV2Client(region string) (*gophercloud.ServiceClient, error) {
-	return openstack.NewDNSV2(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+	return c.osServiceClient("dns", region, func(region string) (*gophercloud.ServiceClient, error) {
+		return openstack.NewDNSV2(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) identityV3Client(region string) (*gophercloud.ServiceClient, error) {
-	return openstack.NewIdentityV3(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+	return c.osServiceClient("identity", region, func(region string) (*gophercloud.ServiceClient, error) {
+		return openstack.NewIdentityV3(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) imageV2Client(region string) (*gophercloud.ServiceClient, error) {
-	return openstack.NewImageServiceV2(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+	return c.osServiceClient("image", region, func(region string) (*gophercloud.ServiceClient, error) {
+		return openstack.NewImageServiceV2(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) networkingV1Client(region string) (*golangsdk.ServiceClient, error) {
-	return huaweisdk.NewNetworkV1(c.HwClient, golangsdk.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getHwEndpointType(),
+	return c.hwServiceClient("networkv1", region, func(region string) (*golangsdk.ServiceClient, error) {
+		client, err := c.hwClient(region)
+		if err != nil {
+			return nil, err
+		}
+
+		if endpoint, ok := c.endpoint("networkv1"); ok {
+			return &golangsdk.ServiceClient{
+				ProviderClient: client,
+				Endpoint:       endpoint,
+				ResourceBase:   endpoint + "v1/",
+				Type:           "network",
+			}, nil
+		}
+
+		return huaweisdk.NewNetworkV1(client, golangsdk.EndpointOpts{
+			Region:       region,
+			Availability: c.getHwEndpointType(),
+		})
 	})
 }
 
 func (c *Config) networkingV2Client(region string) (*gophercloud.ServiceClient, error) {
-	return openstack.NewNetworkV2(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+	return c.osServiceClient("network", region, func(region string) (*gophercloud.ServiceClient, error) {
+		return openstack.NewNetworkV2(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) objectStorageV1Client(region string) (*gophercloud.ServiceClient, error) {
-	// If Swift Authentication is being used, return a swauth client.
-	if c.Swauth {
-		return swauth.NewObjectStorageV1(c.OsClient, swauth.AuthOpts{
-			User: c.Username,
-			Key:  c.Password,
-		})
-	}
+	return c.osServiceClient("objectstorage", region, func(region string) (*gophercloud.ServiceClient, error) {
+		// If Swift Authentication is being used, return a swauth client.
+		if c.Swauth {
+			return swauth.NewObjectStorageV1(c.OsClient, swauth.AuthOpts{
+				User: c.Username,
+				Key:  c.Password,
+			})
+		}
 
-	return openstack.NewObjectStorageV1(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+		return openstack.NewObjectStorageV1(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) loadBalancerV2Client(region string) (*gophercloud.ServiceClient, error) {
-	return openstack.NewLoadBalancerV2(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+	return c.osServiceClient("loadbalancer", region, func(region string) (*gophercloud.ServiceClient, error) {
+		return openstack.NewLoadBalancerV2(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) databaseV1Client(region string) (*gophercloud.ServiceClient, error) {
-	return openstack.NewDBV1(c.OsClient, gophercloud.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getEndpointType(),
+	return c.osServiceClient("database", region, func(region string) (*gophercloud.ServiceClient, error) {
+		return openstack.NewDBV1(c.OsClient, gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: c.getEndpointType(),
+		})
 	})
 }
 
 func (c *Config) loadElasticLoadBalancerClient(region string) (*golangsdk.ServiceClient, error) {
-	return huaweisdk.NewElasticLoadBalancer(c.HwClient, golangsdk.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getHwEndpointType(),
+	return c.hwServiceClient("elb", region, func(region string) (*golangsdk.ServiceClient, error) {
+		client, err := c.hwClient(region)
+		if err != nil {
+			return nil, err
+		}
+
+		if endpoint, ok := c.endpoint("elb"); ok {
+			return &golangsdk.ServiceClient{
+				ProviderClient: client,
+				Endpoint:       endpoint,
+				ResourceBase:   endpoint,
+				Type:           "elb",
+			}, nil
+		}
+
+		return huaweisdk.NewElasticLoadBalancer(client, golangsdk.EndpointOpts{
+			Region:       region,
+			Availability: c.getHwEndpointType(),
+		})
 	})
 }
 
 func (c *Config) autoscalingV1Client(region string) (*golangsdk.ServiceClient, error) {
-	return huaweisdk.NewAutoScalingService(c.HwClient, golangsdk.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getHwEndpointType(),
+	return c.hwServiceClient("autoscaling", region, func(region string) (*golangsdk.ServiceClient, error) {
+		client, err := c.hwClient(region)
+		if err != nil {
+			return nil, err
+		}
+
+		return huaweisdk.NewAutoScalingService(client, golangsdk.EndpointOpts{
+			Region:       region,
+			Availability: c.getHwEndpointType(),
+		})
 	})
 }
 
 func (c *Config) SmnV2Client(region string) (*golangsdk.ServiceClient, error) {
-	return huaweisdk.NewSmnServiceV2(c.HwClient, golangsdk.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getHwEndpointType(),
+	return c.hwServiceClient("smn", region, func(region string) (*golangsdk.ServiceClient, error) {
+		client, err := c.hwClient(region)
+		if err != nil {
+			return nil, err
+		}
+
+		if endpoint, ok := c.endpoint("smn"); ok {
+			return &golangsdk.ServiceClient{
+				ProviderClient: client,
+				Endpoint:       endpoint,
+				ResourceBase:   endpoint + "notifications/",
+				Type:           "smn",
+			}, nil
+		}
+
+		return huaweisdk.NewSmnServiceV2(client, golangsdk.EndpointOpts{
+			Region:       region,
+			Availability: c.getHwEndpointType(),
+		})
 	})
 }
 
@@ -448,9 +1008,16 @@ func (c *Config) getEndpointType() gophercloud.Availability {
 }
 
 func (c *Config) loadCESClient(region string) (*golangsdk.ServiceClient, error) {
-	return huaweisdk.NewCESClient(c.HwClient, golangsdk.EndpointOpts{
-		Region:       c.determineRegion(region),
-		Availability: c.getHwEndpointType(),
+	return c.hwServiceClient("ces", region, func(region string) (*golangsdk.ServiceClient, error) {
+		client, err := c.hwClient(region)
+		if err != nil {
+			return nil, err
+		}
+
+		return huaweisdk.NewCESClient(client, golangsdk.EndpointOpts{
+			Region:       region,
+			Availability: c.getHwEndpointType(),
+		})
 	})
 }
 
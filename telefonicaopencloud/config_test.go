@@ -0,0 +1,383 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/huaweicloud/golangsdk/openstack/networking/v1/eips"
+)
+
+// testReauthServer is a minimal Keystone v3 and service API. Every token it
+// issues except the most recent one is treated as expired.
+type testReauthServer struct {
+	*httptest.Server
+	issued int32
+}
+
+func newTestReauthServer(t testing.TB) *testReauthServer {
+	s := &testReauthServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Openstack-Request-Id", "req-"+r.Method+r.URL.Path)
+
+		if r.Method == "POST" && r.URL.Path == "/v3/auth/tokens" {
+			n := atomic.AddInt32(&s.issued, 1)
+			w.Header().Set("X-Subject-Token", fmt.Sprintf("token-%d", n))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": {"expires_at": "2030-01-01T00:00:00Z", "project": {"id": "project-1"}, "catalog": [
+				{"type": "compute", "endpoints": [{"interface": "public", "region": "RegionOne", "url": "%[1]s/compute/"}]},
+				{"type": "network", "endpoints": [{"interface": "public", "region": "RegionOne", "url": "%[1]s/network/"}]}
+			]}}`, s.URL)
+			return
+		}
+
+		if r.Header.Get("X-Auth-Token") != fmt.Sprintf("token-%d", atomic.LoadInt32(&s.issued)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": {"code": 401, "message": "The request you have made requires authentication."}}`)
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/compute/servers/"):
+			fmt.Fprint(w, `{"server": {"id": "server-1", "status": "ACTIVE"}}`)
+		case strings.HasPrefix(r.URL.Path, "/network/v1/project-1/publicips/"):
+			fmt.Fprint(w, `{"publicip": {"id": "eip-1", "status": "ACTIVE"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return s
+}
+
+// expire makes the server reject all tokens issued so far.
+func (s *testReauthServer) expire() {
+	atomic.AddInt32(&s.issued, 1)
+}
+
+func TestConfigReauth_concurrent(t *testing.T) {
+	server := newTestReauthServer(t)
+	defer server.Close()
+
+	config := &Config{
+		IdentityEndpoint: server.URL + "/v3/",
+		Username:         "user",
+		Password:         "password",
+		TenantName:       "project",
+		DomainName:       "domain",
+		Region:           "RegionOne",
+	}
+	if err := config.authenticate(); err != nil {
+		t.Fatalf("Error authenticating: %s", err)
+	}
+
+	computeClient, err := config.computeV2Client("RegionOne")
+	if err != nil {
+		t.Fatalf("Error creating compute client: %s", err)
+	}
+	networkClient, err := config.networkingV1Client("RegionOne")
+	if err != nil {
+		t.Fatalf("Error creating networking v1 client: %s", err)
+	}
+
+	for _, client := range []string{"gophercloud", "golangsdk"} {
+		server.expire()
+		before := atomic.LoadInt32(&server.issued)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var err error
+				if client == "gophercloud" {
+					_, err = servers.Get(computeClient, "server-1").Extract()
+				} else {
+					_, err = eips.Get(networkClient, "eip-1").Extract()
+				}
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("Unexpected %s error after token expiry: %s", client, err)
+			}
+		}
+
+		if issued := atomic.LoadInt32(&server.issued) - before; issued != 1 {
+			t.Fatalf("Expected %s to re-authenticate once, got %d", client, issued)
+		}
+	}
+}
+
+func TestConfigReauth_fixedToken(t *testing.T) {
+	server := newTestReauthServer(t)
+	defer server.Close()
+
+	config := &Config{
+		IdentityEndpoint: server.URL + "/v3/",
+		Token:            "user-token",
+		Region:           "RegionOne",
+	}
+	if err := config.authenticate(); err != nil {
+		t.Fatalf("Error authenticating: %s", err)
+	}
+
+	computeClient, err := config.computeV2Client("RegionOne")
+	if err != nil {
+		t.Fatalf("Error creating compute client: %s", err)
+	}
+
+	server.expire()
+	_, err = servers.Get(computeClient, "server-1").Extract()
+	if err == nil || !strings.Contains(err.Error(), "single fixed token which can't be renewed") {
+		t.Fatalf("Expected a fixed token error, got: %v", err)
+	}
+}
+
+func TestConfigEndpoints(t *testing.T) {
+	server := newTestReauthServer(t)
+	defer server.Close()
+
+	config := &Config{
+		IdentityEndpoint: server.URL + "/v3/",
+		Username:         "user",
+		Password:         "password",
+		DomainName:       "domain",
+		Region:           "RegionOne",
+		Endpoints: map[string]string{
+			"compute": "https://compute.example.com/v2/project-1",
+			"dns":     "https://dns.example.com/",
+			"elb":     "https://elb.example.com/v1.0/",
+			"smn":     "https://smn.example.com/v2/project-1/",
+		},
+	}
+	if err := config.authenticate(); err != nil {
+		t.Fatalf("Error authenticating: %s", err)
+	}
+
+	computeClient, err := config.computeV2Client("RegionOne")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dnsClient, err := config.dnsV2Client("RegionOne")
+	if err != nil {
+		t.Fatal(err)
+	}
+	networkClient, err := config.networkingV2Client("RegionOne")
+	if err != nil {
+		t.Fatal(err)
+	}
+	elbClient, err := config.loadElasticLoadBalancerClient("RegionOne")
+	if err != nil {
+		t.Fatal(err)
+	}
+	smnClient, err := config.SmnV2Client("RegionOne")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"compute": "https://compute.example.com/v2/project-1/",
+		"dns":     "https://dns.example.com/v2/",
+		"network": server.URL + "/network/v2.0/",
+		"elb":     "https://elb.example.com/v1.0/",
+		"smn":     "https://smn.example.com/v2/project-1/notifications/",
+	}
+	actual := map[string]string{
+		"compute": computeClient.ResourceBaseURL(),
+		"dns":     dnsClient.ResourceBaseURL(),
+		"network": networkClient.ResourceBaseURL(),
+		"elb":     elbClient.ResourceBaseURL(),
+		"smn":     smnClient.ResourceBaseURL(),
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected %#v, got %#v", expected, actual)
+	}
+}
+
+func TestConfigServiceClientCache(t *testing.T) {
+	server := newTestReauthServer(t)
+	defer server.Close()
+
+	config := &Config{
+		IdentityEndpoint: server.URL + "/v3/",
+		Username:         "user",
+		Password:         "password",
+		DomainName:       "domain",
+		Region:           "RegionOne",
+	}
+	if err := config.authenticate(); err != nil {
+		t.Fatalf("Error authenticating: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	clients := make(chan interface{}, 20)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client, err := config.computeV2Client("")
+			if err != nil {
+				t.Errorf("Error creating compute client: %s", err)
+			}
+			clients <- client
+		}()
+		go func() {
+			defer wg.Done()
+			client, err := config.networkingV1Client("RegionOne")
+			if err != nil {
+				t.Errorf("Error creating networking v1 client: %s", err)
+			}
+			clients <- client
+		}()
+	}
+	wg.Wait()
+	close(clients)
+
+	unique := make(map[interface{}]bool)
+	for client := range clients {
+		unique[client] = true
+	}
+	if len(unique) != 2 {
+		t.Fatalf("Expected one compute and one networking v1 client, got %d clients", len(unique))
+	}
+
+	// Lookup failures are not cached.
+	if _, err := config.computeV2Client("RegionTwo"); err == nil {
+		t.Fatalf("Expected an error for a region without endpoints")
+	}
+	if _, ok := config.cachedServiceClient(config.serviceClientKey("compute", "RegionTwo")); ok {
+		t.Fatalf("Expected the failed lookup not to be cached")
+	}
+}
+
+// BenchmarkConfigComputeV2Client compares building a client for every
+// resource operation, as done before clients were cached, with the cache.
+func BenchmarkConfigComputeV2Client(b *testing.B) {
+	server := newTestReauthServer(nil)
+	defer server.Close()
+
+	config := &Config{
+		IdentityEndpoint: server.URL + "/v3/",
+		Username:         "user",
+		Password:         "password",
+		DomainName:       "domain",
+		Region:           "RegionOne",
+	}
+	if err := config.authenticate(); err != nil {
+		b.Fatalf("Error authenticating: %s", err)
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := openstack.NewComputeV2(config.OsClient, gophercloud.EndpointOpts{
+				Region:       config.determineRegion(""),
+				Availability: config.getEndpointType(),
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := config.computeV2Client(""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestConfigDeferredAuth(t *testing.T) {
+	server := newTestReauthServer(t)
+	server.Close()
+
+	config := &Config{
+		IdentityEndpoint: server.URL + "/v3/",
+		Username:         "user",
+		Password:         "password",
+		DomainName:       "domain",
+		Region:           "RegionOne",
+	}
+	if err := config.LoadAndValidate(); err != nil {
+		t.Fatalf("Expected no authentication before a client is requested, got: %s", err)
+	}
+
+	_, err := config.computeV2Client("RegionOne")
+	if err == nil {
+		t.Fatalf("Expected an authentication error")
+	}
+
+	// The error is reported again rather than retrying for every resource.
+	_, err2 := config.networkingV1Client("RegionOne")
+	if err2 == nil || err2.Error() != err.Error() {
+		t.Fatalf("Expected the same authentication error %q, got: %v", err, err2)
+	}
+}
+
+func TestConfigLoadAndValidate_invalid(t *testing.T) {
+	cases := map[string]struct {
+		config   *Config
+		expected string
+	}{
+		"auth_url": {
+			&Config{IdentityEndpoint: "iam.example.com/v3"},
+			"Invalid auth_url",
+		},
+		"cacert_file": {
+			&Config{IdentityEndpoint: "https://iam.example.com/v3", CACertFile: "/nonexistent/ca.pem"},
+			"Error reading CA Cert",
+		},
+		"cert without key": {
+			&Config{IdentityEndpoint: "https://iam.example.com/v3", ClientCertFile: "-----BEGIN CERTIFICATE-----"},
+			"cert and key must be set together",
+		},
+		"access_key without secret_key": {
+			&Config{IdentityEndpoint: "https://iam.example.com/v3", AccessKey: "AK"},
+			"access_key and secret_key must be set together",
+		},
+		"domain_id and domain_name": {
+			&Config{
+				IdentityEndpoint: "https://iam.example.com/v3",
+				Username:         "user",
+				Password:         "password",
+				DomainID:         "domain-id",
+				DomainName:       "domain",
+				TenantID:         "project-id",
+			},
+			"Invalid credentials",
+		},
+	}
+
+	for name, c := range cases {
+		err := c.config.LoadAndValidate()
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%s: expected an error containing %q, got: %v", name, c.expected, err)
+		}
+	}
+
+	// Credentials which are computed from other resources aren't known yet.
+	config := &Config{
+		IdentityEndpoint: "https://iam.example.com/v3",
+		Username:         "user",
+		DomainName:       "domain",
+	}
+	if err := config.LoadAndValidate(); err != nil {
+		t.Fatalf("Expected unknown credentials to be accepted, got: %s", err)
+	}
+}
@@ -0,0 +1,92 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/availabilityzones"
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceAvailabilityZones() *schema.Resource {
+	return &schema.Resource{
+		Read:   dataSourceAvailabilityZonesRead,
+		Schema: availabilityZonesSchema(),
+	}
+}
+
+// availabilityZonesSchema is shared by the compute and block storage
+// availability zone data sources.
+func availabilityZonesSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"region": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Computed: true,
+		},
+
+		"state": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "available",
+			ValidateFunc: dataSourceAvailabilityZonesValidateState,
+		},
+
+		// Computed values
+		"names": &schema.Schema{
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+	}
+}
+
+func dataSourceAvailabilityZonesRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	return availabilityZonesRead(d, computeClient, GetRegion(d, config))
+}
+
+// availabilityZonesRead lists the availability zones of a service. Nova and
+// Cinder list them the same way, so both use the compute package.
+func availabilityZonesRead(d *schema.ResourceData, client *gophercloud.ServiceClient, region string) error {
+	pages, err := availabilityzones.List(client).AllPages()
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve availability zones: %s", err)
+	}
+
+	allZones, err := availabilityzones.ExtractAvailabilityZones(pages)
+	if err != nil {
+		return fmt.Errorf("Unable to extract availability zones: %s", err)
+	}
+
+	available := d.Get("state").(string) == "available"
+	var names []string
+	for _, zone := range allZones {
+		if zone.ZoneState.Available == available {
+			names = append(names, zone.ZoneName)
+		}
+	}
+	sort.Strings(names)
+
+	log.Printf("[DEBUG] Retrieved availability zones: %v", names)
+
+	d.SetId(strconv.Itoa(hashcode.String(strings.Join(names, ","))))
+	d.Set("names", names)
+	d.Set("region", region)
+
+	return nil
+}
+
+func dataSourceAvailabilityZonesValidateState(v interface{}, k string) (ws []string, errors []error) {
+	return ValidateStringList(v, k, []string{"available", "unavailable"})
+}
@@ -0,0 +1,86 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccAvailabilityZones_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccAvailabilityZones_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckAvailabilityZonesNames(
+						"data.telefonicaopencloud_availability_zones.zones", OS_AVAILABILITY_ZONE, true),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAvailabilityZones_unavailable(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccAvailabilityZones_unavailable,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckAvailabilityZonesNames(
+						"data.telefonicaopencloud_availability_zones.zones", OS_AVAILABILITY_ZONE, false),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckAvailabilityZonesNames checks whether a zone is in the names
+// of an availability zones data source.
+func testAccCheckAvailabilityZonesNames(n, zone string, found bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Can't find availability zones data source: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("Availability zones data source ID not set")
+		}
+
+		count, err := strconv.Atoi(rs.Primary.Attributes["names.#"])
+		if err != nil {
+			return fmt.Errorf("Availability zones data source has no names: %s", err)
+		}
+
+		for i := 0; i < count; i++ {
+			if rs.Primary.Attributes[fmt.Sprintf("names.%d", i)] == zone {
+				if !found {
+					return fmt.Errorf("Availability zone %s is in %s", zone, n)
+				}
+				return nil
+			}
+		}
+
+		if found {
+			return fmt.Errorf("Availability zone %s is not in %s", zone, n)
+		}
+		return nil
+	}
+}
+
+const testAccAvailabilityZones_basic = `
+data "telefonicaopencloud_availability_zones" "zones" {}
+`
+
+const testAccAvailabilityZones_unavailable = `
+data "telefonicaopencloud_availability_zones" "zones" {
+  state = "unavailable"
+}
+`
@@ -0,0 +1,24 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceBlockStorageAvailabilityZonesV2() *schema.Resource {
+	return &schema.Resource{
+		Read:   dataSourceBlockStorageAvailabilityZonesV2Read,
+		Schema: availabilityZonesSchema(),
+	}
+}
+
+func dataSourceBlockStorageAvailabilityZonesV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	blockStorageClient, err := config.blockStorageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
+	}
+
+	return availabilityZonesRead(d, blockStorageClient, GetRegion(d, config))
+}
@@ -0,0 +1,27 @@
+package telefonicaopencloud
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccBlockStorageV2AvailabilityZones_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccBlockStorageV2AvailabilityZones_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckAvailabilityZonesNames(
+						"data.telefonicaopencloud_blockstorage_availability_zones_v2.zones", OS_AVAILABILITY_ZONE, true),
+				),
+			},
+		},
+	})
+}
+
+const testAccBlockStorageV2AvailabilityZones_basic = `
+data "telefonicaopencloud_blockstorage_availability_zones_v2" "zones" {}
+`
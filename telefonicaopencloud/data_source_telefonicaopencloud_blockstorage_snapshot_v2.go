@@ -0,0 +1,161 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/gophercloud/gophercloud/openstack/blockstorage/v2/snapshots"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceBlockStorageSnapshotV2() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceBlockStorageSnapshotV2Read,
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"volume_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"metadata": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+				Computed: true,
+			},
+
+			"most_recent": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			// Computed values
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"size": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// dataSourceBlockStorageSnapshotV2Read performs the snapshot lookup.
+func dataSourceBlockStorageSnapshotV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	blockStorageClient, err := config.blockStorageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
+	}
+
+	listOpts := snapshots.ListOpts{
+		VolumeID: d.Get("volume_id").(string),
+		Name:     d.Get("name").(string),
+		Status:   d.Get("status").(string),
+	}
+
+	log.Printf("[DEBUG] List Options: %#v", listOpts)
+
+	pages, err := snapshots.List(blockStorageClient, listOpts).AllPages()
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve snapshots: %s", err)
+	}
+
+	allSnapshots, err := snapshots.ExtractSnapshots(pages)
+	if err != nil {
+		return fmt.Errorf("Unable to extract snapshots: %s", err)
+	}
+
+	metadata := d.Get("metadata").(map[string]interface{})
+
+	var filteredSnapshots []snapshots.Snapshot
+	for _, snapshot := range allSnapshots {
+		if !snapshotHasMetadata(snapshot, metadata) {
+			continue
+		}
+		filteredSnapshots = append(filteredSnapshots, snapshot)
+	}
+
+	if len(filteredSnapshots) < 1 {
+		return fmt.Errorf("Your query returned no results. " +
+			"Please change your search criteria and try again.")
+	}
+
+	var snapshot snapshots.Snapshot
+	if len(filteredSnapshots) > 1 {
+		if !d.Get("most_recent").(bool) {
+			log.Printf("[DEBUG] Multiple results found: %#v", filteredSnapshots)
+			return fmt.Errorf("Your query returned more than one result. Please try a more " +
+				"specific search criteria, or set `most_recent` attribute to true.")
+		}
+		snapshot = mostRecentSnapshot(filteredSnapshots)
+	} else {
+		snapshot = filteredSnapshots[0]
+	}
+
+	log.Printf("[DEBUG] Retrieved snapshot %s: %+v", snapshot.ID, snapshot)
+	d.SetId(snapshot.ID)
+
+	d.Set("volume_id", snapshot.VolumeID)
+	d.Set("name", snapshot.Name)
+	d.Set("description", snapshot.Description)
+	d.Set("status", snapshot.Status)
+	d.Set("size", snapshot.Size)
+	d.Set("region", GetRegion(d, config))
+
+	if err := d.Set("metadata", snapshot.Metadata); err != nil {
+		log.Printf("[DEBUG] Unable to set metadata: %s", err)
+	}
+
+	return nil
+}
+
+// snapshotHasMetadata returns whether a snapshot has all of the metadata
+// with the same values.
+func snapshotHasMetadata(snapshot snapshots.Snapshot, metadata map[string]interface{}) bool {
+	for k, v := range metadata {
+		if value, ok := snapshot.Metadata[k]; !ok || value != v.(string) {
+			return false
+		}
+	}
+	return true
+}
+
+type snapshotSort []snapshots.Snapshot
+
+func (a snapshotSort) Len() int      { return len(a) }
+func (a snapshotSort) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a snapshotSort) Less(i, j int) bool {
+	return a[i].CreatedAt.Before(a[j].CreatedAt)
+}
+
+// mostRecentSnapshot returns the most recently created snapshot.
+func mostRecentSnapshot(allSnapshots []snapshots.Snapshot) snapshots.Snapshot {
+	sortedSnapshots := allSnapshots
+	sort.Sort(snapshotSort(sortedSnapshots))
+	return sortedSnapshots[len(sortedSnapshots)-1]
+}
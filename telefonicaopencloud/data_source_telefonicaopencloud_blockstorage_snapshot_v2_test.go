@@ -0,0 +1,86 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccBlockStorageV2SnapshotDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccBlockStorageV2SnapshotDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2SnapshotDataSourceID("data.telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1"),
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "id",
+						"telefonicaopencloud_blockstorage_snapshot_v2.snapshot_2", "id"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "name", "snapshot_2"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "size", "1"),
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_blockstorage_snapshot_v2.snapshot_2", "id",
+						"telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "id"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_blockstorage_snapshot_v2.snapshot_2", "metadata.stage", "first"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckBlockStorageV2SnapshotDataSourceID(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Can't find snapshot data source: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("Snapshot data source ID not set")
+		}
+
+		return nil
+	}
+}
+
+const testAccBlockStorageV2SnapshotDataSource_basic = `
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1"
+  size = 1
+}
+
+resource "telefonicaopencloud_blockstorage_snapshot_v2" "snapshot_1" {
+  name = "snapshot_1"
+  volume_id = "${telefonicaopencloud_blockstorage_volume_v2.volume_1.id}"
+  metadata {
+    stage = "first"
+  }
+}
+
+resource "telefonicaopencloud_blockstorage_snapshot_v2" "snapshot_2" {
+  name = "snapshot_2"
+  volume_id = "${telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1.volume_id}"
+  metadata {
+    stage = "second"
+  }
+}
+
+data "telefonicaopencloud_blockstorage_snapshot_v2" "snapshot_1" {
+  volume_id = "${telefonicaopencloud_blockstorage_snapshot_v2.snapshot_2.volume_id}"
+  most_recent = true
+}
+
+data "telefonicaopencloud_blockstorage_snapshot_v2" "snapshot_2" {
+  volume_id = "${telefonicaopencloud_blockstorage_snapshot_v2.snapshot_2.volume_id}"
+  metadata {
+    stage = "${telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1.metadata.stage}"
+  }
+}
+`
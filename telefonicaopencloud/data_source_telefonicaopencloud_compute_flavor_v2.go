@@ -0,0 +1,338 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceComputeFlavorV2() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceComputeFlavorV2Read,
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"name_prefix", "name_regex"},
+			},
+
+			"name_prefix": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"name", "name_regex"},
+			},
+
+			"name_regex": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"name", "name_prefix"},
+				ValidateFunc:  validateFlavorNameRegex,
+			},
+
+			"vcpus": &schema.Schema{
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"min_vcpus"},
+			},
+
+			"min_vcpus": &schema.Schema{
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ConflictsWith: []string{"vcpus"},
+			},
+
+			"ram": &schema.Schema{
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"min_ram"},
+			},
+
+			"min_ram": &schema.Schema{
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ConflictsWith: []string{"ram"},
+			},
+
+			"disk": &schema.Schema{
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"min_disk"},
+			},
+
+			"min_disk": &schema.Schema{
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ConflictsWith: []string{"disk"},
+			},
+
+			"performance_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"availability_zone": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"smallest": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			// Computed values
+			"swap": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"rx_tx_factor": &schema.Schema{
+				Type:     schema.TypeFloat,
+				Computed: true,
+			},
+
+			"is_public": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"availability_zones": &schema.Schema{
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
+			"extra_specs": &schema.Schema{
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceComputeFlavorV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	listOpts := flavors.ListOpts{}
+
+	if v, ok := d.GetOk("min_ram"); ok {
+		listOpts.MinRAM = v.(int)
+	}
+
+	if v, ok := d.GetOk("min_disk"); ok {
+		listOpts.MinDisk = v.(int)
+	}
+
+	pages, err := flavors.ListDetail(computeClient, listOpts).AllPages()
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve flavors: %s", err)
+	}
+
+	allFlavors, err := flavors.ExtractFlavors(pages)
+	if err != nil {
+		return fmt.Errorf("Unable to extract flavors: %s", err)
+	}
+
+	var nameRegex *regexp.Regexp
+	if v, ok := d.GetOk("name_regex"); ok {
+		nameRegex = regexp.MustCompile(v.(string))
+	}
+
+	var filteredFlavors []flavors.Flavor
+	for _, flavor := range allFlavors {
+		if v, ok := d.GetOk("name"); ok && flavor.Name != v.(string) {
+			continue
+		}
+		if v, ok := d.GetOk("name_prefix"); ok && !strings.HasPrefix(flavor.Name, v.(string)) {
+			continue
+		}
+		if nameRegex != nil && !nameRegex.MatchString(flavor.Name) {
+			continue
+		}
+		if v, ok := d.GetOkExists("vcpus"); ok && flavor.VCPUs != v.(int) {
+			continue
+		}
+		if v, ok := d.GetOk("min_vcpus"); ok && flavor.VCPUs < v.(int) {
+			continue
+		}
+		if v, ok := d.GetOkExists("ram"); ok && flavor.RAM != v.(int) {
+			continue
+		}
+		if v, ok := d.GetOk("min_ram"); ok && flavor.RAM < v.(int) {
+			continue
+		}
+		if v, ok := d.GetOkExists("disk"); ok && flavor.Disk != v.(int) {
+			continue
+		}
+		if v, ok := d.GetOk("min_disk"); ok && flavor.Disk < v.(int) {
+			continue
+		}
+		filteredFlavors = append(filteredFlavors, flavor)
+	}
+
+	// Filtering on extra specs needs a request per flavor, so it's only
+	// done when asked for and after the cheaper filters above.
+	extraSpecs := make(map[string]map[string]string)
+	performanceType, filterPerformanceType := d.GetOk("performance_type")
+	availabilityZone, filterAvailabilityZone := d.GetOk("availability_zone")
+	if filterPerformanceType || filterAvailabilityZone {
+		var matchingFlavors []flavors.Flavor
+		for _, flavor := range filteredFlavors {
+			specs, err := getFlavorExtraSpecs(computeClient, flavor.ID)
+			if err != nil {
+				return err
+			}
+			extraSpecs[flavor.ID] = specs
+
+			if filterPerformanceType && specs["ecs:performancetype"] != performanceType.(string) {
+				continue
+			}
+			if filterAvailabilityZone && !flavorAvailableInZone(specs, availabilityZone.(string)) {
+				continue
+			}
+			matchingFlavors = append(matchingFlavors, flavor)
+		}
+		filteredFlavors = matchingFlavors
+	}
+
+	if len(filteredFlavors) < 1 {
+		return fmt.Errorf("Your query returned no results. " +
+			"Please change your search criteria and try again.")
+	}
+
+	if len(filteredFlavors) > 1 {
+		if !d.Get("smallest").(bool) {
+			log.Printf("[DEBUG] Multiple results found: %#v", filteredFlavors)
+			return fmt.Errorf("Your query returned more than one result. Please try a more " +
+				"specific search criteria, or set `smallest` attribute to true.")
+		}
+		sort.Sort(flavorSort(filteredFlavors))
+	}
+
+	flavor := filteredFlavors[0]
+
+	specs, ok := extraSpecs[flavor.ID]
+	if !ok {
+		specs, err = getFlavorExtraSpecs(computeClient, flavor.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[DEBUG] Retrieved Flavor %s: %+v", flavor.ID, flavor)
+	d.SetId(flavor.ID)
+
+	d.Set("name", flavor.Name)
+	d.Set("vcpus", flavor.VCPUs)
+	d.Set("ram", flavor.RAM)
+	d.Set("disk", flavor.Disk)
+	d.Set("swap", flavor.Swap)
+	d.Set("rx_tx_factor", flavor.RxTxFactor)
+	d.Set("is_public", flavor.IsPublic)
+	d.Set("performance_type", specs["ecs:performancetype"])
+	d.Set("status", flavorStatus(specs))
+	d.Set("region", GetRegion(d, config))
+
+	if err := d.Set("availability_zones", flavorZoneStatus(specs)); err != nil {
+		log.Printf("[DEBUG] Unable to set availability_zones: %s", err)
+	}
+
+	if err := d.Set("extra_specs", specs); err != nil {
+		log.Printf("[DEBUG] Unable to set extra_specs: %s", err)
+	}
+
+	return nil
+}
+
+func getFlavorExtraSpecs(computeClient *gophercloud.ServiceClient, flavorID string) (map[string]string, error) {
+	specs, err := flavors.ListExtraSpecs(computeClient, flavorID).Extract()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve extra specs of flavor %s: %s", flavorID, err)
+	}
+	return specs, nil
+}
+
+// flavorStatus returns the status a flavor is sold with, which is
+// "normal" unless the cloud says otherwise.
+func flavorStatus(specs map[string]string) string {
+	if v := specs["cond:operation:status"]; v != "" {
+		return v
+	}
+	return "normal"
+}
+
+// flavorZoneStatus returns the status of a flavor in the availability zones
+// where it differs from its overall status. These are listed in the
+// cond:operation:az extra spec as "zone(status)" separated by commas.
+func flavorZoneStatus(specs map[string]string) map[string]string {
+	zones := make(map[string]string)
+	for _, v := range strings.Split(specs["cond:operation:az"], ",") {
+		v = strings.TrimSpace(v)
+		if i := strings.Index(v, "("); i > 0 && strings.HasSuffix(v, ")") {
+			zones[v[:i]] = v[i+1 : len(v)-1]
+		}
+	}
+	return zones
+}
+
+// flavorAvailableInZone returns whether instances of a flavor can be created
+// in an availability zone.
+func flavorAvailableInZone(specs map[string]string, zone string) bool {
+	status, ok := flavorZoneStatus(specs)[zone]
+	if !ok {
+		status = flavorStatus(specs)
+	}
+	return status == "normal" || status == "promotion"
+}
+
+func validateFlavorNameRegex(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := regexp.Compile(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not a valid regular expression: %s", k, err))
+	}
+	return
+}
+
+// flavorSort orders flavors from the smallest to the largest.
+type flavorSort []flavors.Flavor
+
+func (a flavorSort) Len() int      { return len(a) }
+func (a flavorSort) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a flavorSort) Less(i, j int) bool {
+	if a[i].VCPUs != a[j].VCPUs {
+		return a[i].VCPUs < a[j].VCPUs
+	}
+	if a[i].RAM != a[j].RAM {
+		return a[i].RAM < a[j].RAM
+	}
+	if a[i].Disk != a[j].Disk {
+		return a[i].Disk < a[j].Disk
+	}
+	return a[i].Name < a[j].Name
+}
@@ -0,0 +1,156 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccComputeV2FlavorDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2FlavorDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2FlavorDataSourceID("data.telefonicaopencloud_compute_flavor_v2.flavor_1"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_1", "name", OS_FLAVOR_NAME),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_1", "vcpus"),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_1", "ram"),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_1", "status"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2FlavorDataSource_testQueries(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2FlavorDataSource_size,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_2", "id",
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_1", "id"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2FlavorDataSource_minSize,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_2", "id",
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_1", "id"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2FlavorDataSource_extraSpecs,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_2", "id",
+						"data.telefonicaopencloud_compute_flavor_v2.flavor_1", "id"),
+				),
+			},
+		},
+	})
+}
+
+func TestComputeV2FlavorAvailableInZone(t *testing.T) {
+	specs := map[string]string{
+		"cond:operation:status": "normal",
+		"cond:operation:az":     "eu-de-01(normal), eu-de-02(sellout)",
+	}
+
+	zones := flavorZoneStatus(specs)
+	if len(zones) != 2 || zones["eu-de-01"] != "normal" || zones["eu-de-02"] != "sellout" {
+		t.Fatalf("Unexpected zone status: %#v", zones)
+	}
+
+	for zone, expected := range map[string]bool{
+		"eu-de-01": true,
+		"eu-de-02": false,
+		"eu-de-03": true,
+	} {
+		if available := flavorAvailableInZone(specs, zone); available != expected {
+			t.Errorf("Expected availability in %s to be %t, got %t", zone, expected, available)
+		}
+	}
+
+	specs["cond:operation:status"] = "abandon"
+	if flavorAvailableInZone(specs, "eu-de-03") {
+		t.Errorf("Expected an abandoned flavor to be unavailable")
+	}
+
+	if status := flavorStatus(map[string]string{}); status != "normal" {
+		t.Errorf("Expected flavors without a status to be normal, got %s", status)
+	}
+}
+
+func testAccCheckComputeV2FlavorDataSourceID(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Can't find flavor data source: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("Flavor data source ID not set")
+		}
+
+		return nil
+	}
+}
+
+var testAccComputeV2FlavorDataSource_basic = fmt.Sprintf(`
+data "telefonicaopencloud_compute_flavor_v2" "flavor_1" {
+  name = "%s"
+}
+`, OS_FLAVOR_NAME)
+
+var testAccComputeV2FlavorDataSource_size = fmt.Sprintf(`
+data "telefonicaopencloud_compute_flavor_v2" "flavor_1" {
+  name = "%s"
+}
+
+data "telefonicaopencloud_compute_flavor_v2" "flavor_2" {
+  name_prefix = "${data.telefonicaopencloud_compute_flavor_v2.flavor_1.name}"
+  vcpus = "${data.telefonicaopencloud_compute_flavor_v2.flavor_1.vcpus}"
+  ram = "${data.telefonicaopencloud_compute_flavor_v2.flavor_1.ram}"
+  disk = "${data.telefonicaopencloud_compute_flavor_v2.flavor_1.disk}"
+  smallest = true
+}
+`, OS_FLAVOR_NAME)
+
+var testAccComputeV2FlavorDataSource_minSize = fmt.Sprintf(`
+data "telefonicaopencloud_compute_flavor_v2" "flavor_1" {
+  name = "%s"
+}
+
+data "telefonicaopencloud_compute_flavor_v2" "flavor_2" {
+  name_regex = "^${replace(data.telefonicaopencloud_compute_flavor_v2.flavor_1.name, ".", "\\.")}$"
+  min_vcpus = "${data.telefonicaopencloud_compute_flavor_v2.flavor_1.vcpus}"
+  min_ram = "${data.telefonicaopencloud_compute_flavor_v2.flavor_1.ram}"
+  min_disk = "${data.telefonicaopencloud_compute_flavor_v2.flavor_1.disk}"
+}
+`, OS_FLAVOR_NAME)
+
+var testAccComputeV2FlavorDataSource_extraSpecs = fmt.Sprintf(`
+data "telefonicaopencloud_compute_flavor_v2" "flavor_1" {
+  name = "%s"
+}
+
+data "telefonicaopencloud_compute_flavor_v2" "flavor_2" {
+  name = "${data.telefonicaopencloud_compute_flavor_v2.flavor_1.name}"
+  performance_type = "${data.telefonicaopencloud_compute_flavor_v2.flavor_1.performance_type}"
+  availability_zone = "%s"
+}
+`, OS_FLAVOR_NAME, OS_AVAILABILITY_ZONE)
@@ -0,0 +1,408 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/availabilityzones"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/images"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// computeInstanceV2 is a server along with the availability zone it's in.
+type computeInstanceV2 struct {
+	servers.Server
+	availabilityzones.ServerAvailabilityZoneExt
+}
+
+func dataSourceComputeInstanceV2() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceComputeInstanceV2Read,
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"instance_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRegexp,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"metadata": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+				Computed: true,
+			},
+
+			"flavor_id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"flavor_name"},
+			},
+
+			"flavor_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"flavor_id"},
+			},
+
+			"image_id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"image_name"},
+			},
+
+			"image_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"image_id"},
+			},
+
+			// Computed values
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"availability_zone": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"key_pair": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"security_groups": &schema.Schema{
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+
+			"network": computeInstanceV2NetworkSchema(),
+
+			"access_ip_v4": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"access_ip_v6": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// computeInstanceV2NetworkSchema is the network block of a data source
+// instance, as the instance resource computes it.
+func computeInstanceV2NetworkSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"uuid": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"name": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"port": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"fixed_ip_v4": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"fixed_ip_v6": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"mac": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"access_network": &schema.Schema{
+					Type:     schema.TypeBool,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceComputeInstanceV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	region := GetRegion(d, config)
+	computeClient, err := config.computeV2Client(region)
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	var allInstances []computeInstanceV2
+	if v, ok := d.GetOk("instance_id"); ok {
+		var instance computeInstanceV2
+		if err := servers.Get(computeClient, v.(string)).ExtractInto(&instance); err != nil {
+			return fmt.Errorf("Unable to retrieve server %s: %s", v.(string), err)
+		}
+		allInstances = append(allInstances, instance)
+	} else {
+		allInstances, err = listComputeInstancesV2(d, computeClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	filteredInstances, err := filterComputeInstancesV2(d, computeClient, allInstances)
+	if err != nil {
+		return err
+	}
+
+	if len(filteredInstances) < 1 {
+		return fmt.Errorf("Your query returned no results. " +
+			"Please change your search criteria and try again.")
+	}
+
+	if len(filteredInstances) > 1 {
+		log.Printf("[DEBUG] Multiple results found: %#v", filteredInstances)
+		return fmt.Errorf("Your query returned more than one result. " +
+			"Please try a more specific search criteria.")
+	}
+
+	instance, err := flattenComputeInstanceV2(computeClient, meta, region, filteredInstances[0], nil)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Retrieved Server %s: %+v", instance["id"], instance)
+	d.SetId(instance["id"].(string))
+
+	d.Set("instance_id", instance["id"])
+	d.Set("name", instance["name"])
+	d.Set("status", instance["status"])
+	d.Set("flavor_id", instance["flavor_id"])
+	d.Set("flavor_name", instance["flavor_name"])
+	d.Set("image_id", instance["image_id"])
+	d.Set("image_name", instance["image_name"])
+	d.Set("availability_zone", instance["availability_zone"])
+	d.Set("key_pair", instance["key_pair"])
+	d.Set("access_ip_v4", instance["access_ip_v4"])
+	d.Set("access_ip_v6", instance["access_ip_v6"])
+	d.Set("region", region)
+
+	if err := d.Set("metadata", instance["metadata"]); err != nil {
+		log.Printf("[DEBUG] Unable to set metadata: %s", err)
+	}
+	if err := d.Set("security_groups", instance["security_groups"]); err != nil {
+		log.Printf("[DEBUG] Unable to set security_groups: %s", err)
+	}
+	if err := d.Set("network", instance["network"]); err != nil {
+		log.Printf("[DEBUG] Unable to set network: %s", err)
+	}
+
+	return nil
+}
+
+// listComputeInstancesV2 lists the servers, leaving the filtering Nova can
+// do to Nova.
+func listComputeInstancesV2(d *schema.ResourceData, computeClient *gophercloud.ServiceClient) ([]computeInstanceV2, error) {
+	listOpts := servers.ListOpts{
+		Status: strings.ToUpper(d.Get("status").(string)),
+		Flavor: d.Get("flavor_id").(string),
+		Image:  d.Get("image_id").(string),
+	}
+
+	log.Printf("[DEBUG] List Options: %#v", listOpts)
+
+	pages, err := servers.List(computeClient, listOpts).AllPages()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve servers: %s", err)
+	}
+
+	var allInstances []computeInstanceV2
+	if err := servers.ExtractServersInto(pages, &allInstances); err != nil {
+		return nil, fmt.Errorf("Unable to extract servers: %s", err)
+	}
+
+	return allInstances, nil
+}
+
+// filterComputeInstancesV2 returns the servers matching all of the filters.
+// Nova matches names as database regular expressions and ignores filters
+// it doesn't know, so every filter is checked here as well.
+func filterComputeInstancesV2(
+	d *schema.ResourceData, computeClient *gophercloud.ServiceClient, allInstances []computeInstanceV2) ([]computeInstanceV2, error) {
+
+	var nameRegex *regexp.Regexp
+	if v, ok := d.GetOk("name_regex"); ok {
+		nameRegex = regexp.MustCompile(v.(string))
+	}
+
+	flavorId := d.Get("flavor_id").(string)
+	if v, ok := d.GetOk("flavor_name"); ok {
+		id, err := flavors.IDFromName(computeClient, v.(string))
+		if err != nil {
+			return nil, err
+		}
+		flavorId = id
+	}
+
+	imageId := d.Get("image_id").(string)
+	if v, ok := d.GetOk("image_name"); ok {
+		id, err := images.IDFromName(computeClient, v.(string))
+		if err != nil {
+			return nil, err
+		}
+		imageId = id
+	}
+
+	status := d.Get("status").(string)
+	metadata := d.Get("metadata").(map[string]interface{})
+
+	var filteredInstances []computeInstanceV2
+	for _, instance := range allInstances {
+		if nameRegex != nil && !nameRegex.MatchString(instance.Name) {
+			continue
+		}
+		if status != "" && !strings.EqualFold(instance.Status, status) {
+			continue
+		}
+		if flavorId != "" && instance.Flavor["id"] != flavorId {
+			continue
+		}
+		if imageId != "" && instance.Image["id"] != imageId {
+			continue
+		}
+		if !instanceHasMetadata(instance.Server, metadata) {
+			continue
+		}
+		filteredInstances = append(filteredInstances, instance)
+	}
+
+	return filteredInstances, nil
+}
+
+// instanceHasMetadata returns whether a server has all of the metadata with
+// the same values.
+func instanceHasMetadata(server servers.Server, metadata map[string]interface{}) bool {
+	for k, v := range metadata {
+		if value, ok := server.Metadata[k]; !ok || value != v.(string) {
+			return false
+		}
+	}
+	return true
+}
+
+// flattenComputeInstanceV2 returns the attributes of a server the way the
+// instance resource computes them. The names of the flavors and images are
+// kept in names, if given, so they're retrieved once for many servers.
+func flattenComputeInstanceV2(
+	computeClient *gophercloud.ServiceClient, meta interface{}, region string,
+	instance computeInstanceV2, names map[string]string) (map[string]interface{}, error) {
+
+	if names == nil {
+		names = make(map[string]string)
+	}
+
+	networks, err := flattenServerNetworks(meta, region, &instance.Server)
+	if err != nil {
+		return nil, err
+	}
+
+	// There are no configured networks to flag as the access network, so
+	// the addresses of the first network are used.
+	hostv4, hostv6 := getInstanceAccessAddresses(nil, networks)
+	if instance.AccessIPv4 != "" && hostv4 == "" {
+		hostv4 = instance.AccessIPv4
+	}
+	if instance.AccessIPv6 != "" && hostv6 == "" {
+		hostv6 = instance.AccessIPv6
+	}
+
+	// A set nested in a list can't be set from a slice, so it's built here.
+	secGroups := schema.NewSet(schema.HashString, nil)
+	for _, sg := range instance.SecurityGroups {
+		if name, ok := sg["name"].(string); ok {
+			secGroups.Add(name)
+		}
+	}
+
+	flavorId, ok := instance.Flavor["id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Error setting TelefonicaOpenCloud server's flavor: %v", instance.Flavor)
+	}
+	flavorName, ok := names["flavor/"+flavorId]
+	if !ok {
+		flavor, err := flavors.Get(computeClient, flavorId).Extract()
+		if err != nil {
+			return nil, err
+		}
+		flavorName = flavor.Name
+		names["flavor/"+flavorId] = flavorName
+	}
+
+	// A server booted from a volume has no image.
+	imageId, _ := instance.Image["id"].(string)
+	imageName, ok := names["image/"+imageId]
+	if imageId != "" && !ok {
+		image, err := images.Get(computeClient, imageId).Extract()
+		if err != nil {
+			if _, ok := err.(gophercloud.ErrDefault404); !ok {
+				return nil, err
+			}
+			// The image may have been deleted since the server was created.
+			imageName = "Image not found"
+		} else {
+			imageName = image.Name
+		}
+		names["image/"+imageId] = imageName
+	}
+
+	return map[string]interface{}{
+		"id":                instance.ID,
+		"name":              instance.Name,
+		"status":            instance.Status,
+		"flavor_id":         flavorId,
+		"flavor_name":       flavorName,
+		"image_id":          imageId,
+		"image_name":        imageName,
+		"availability_zone": instance.AvailabilityZone,
+		"key_pair":          instance.KeyName,
+		"metadata":          instance.Metadata,
+		"security_groups":   secGroups,
+		"network":           networks,
+		"access_ip_v4":      hostv4,
+		"access_ip_v6":      hostv6,
+	}, nil
+}
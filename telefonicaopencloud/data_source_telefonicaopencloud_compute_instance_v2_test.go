@@ -0,0 +1,166 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccComputeV2InstanceDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2InstanceDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceDataSourceID("data.telefonicaopencloud_compute_instance_v2.instance_1"),
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "name",
+						"telefonicaopencloud_compute_instance_v2.instance_1", "name"),
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "access_ip_v4",
+						"telefonicaopencloud_compute_instance_v2.instance_1", "access_ip_v4"),
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "network.0.fixed_ip_v4",
+						"telefonicaopencloud_compute_instance_v2.instance_1", "network.0.fixed_ip_v4"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "network.0.uuid", OS_NETWORK_ID),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "metadata.foo", "bar"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "security_groups.#", "1"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "availability_zone", OS_AVAILABILITY_ZONE),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "flavor_name"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2InstanceDataSource_multipleNetworks(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2InstanceDataSource_multipleNetworks,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "network.#", "2"),
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "network.0.uuid",
+						"telefonicaopencloud_networking_network_v2.network_1", "id"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "network.1.uuid", OS_NETWORK_ID),
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "access_ip_v4",
+						"telefonicaopencloud_compute_instance_v2.instance_1", "network.0.fixed_ip_v4"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2InstanceDataSource_filters(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2InstanceDataSource_filters,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "id",
+						"telefonicaopencloud_compute_instance_v2.instance_2", "id"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "name", "instance_data_2"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "status", "ACTIVE"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instance_v2.instance_1", "metadata.role", "bastion"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckComputeV2InstanceDataSourceID(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Can't find compute instance data source: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("Compute instance data source ID not set")
+		}
+
+		return nil
+	}
+}
+
+var testAccComputeV2InstanceDataSource_basic = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_data_1"
+  security_groups = ["default"]
+  availability_zone = "%s"
+  metadata {
+    foo = "bar"
+  }
+  network {
+    uuid = "%s"
+  }
+}
+
+data "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+}
+`, OS_AVAILABILITY_ZONE, OS_NETWORK_ID)
+
+var testAccComputeV2InstanceDataSource_filters = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_data_1"
+  security_groups = ["default"]
+  availability_zone = "%s"
+  metadata {
+    role = "web"
+  }
+  network {
+    uuid = "%s"
+  }
+}
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_2" {
+  name = "instance_data_2"
+  security_groups = ["default"]
+  availability_zone = "%s"
+  metadata {
+    role = "bastion"
+  }
+  network {
+    uuid = "%s"
+  }
+}
+
+data "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name_regex = "^instance_data_"
+  status = "active"
+  flavor_id = "${telefonicaopencloud_compute_instance_v2.instance_1.flavor_id}"
+  metadata {
+    role = "${telefonicaopencloud_compute_instance_v2.instance_2.metadata.role}"
+  }
+}
+`, OS_AVAILABILITY_ZONE, OS_NETWORK_ID, OS_AVAILABILITY_ZONE, OS_NETWORK_ID)
+
+var testAccComputeV2InstanceDataSource_multipleNetworks = fmt.Sprintf(`
+%s
+
+data "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+}
+`, testAccComputeV2Instance_multipleNetworks)
@@ -0,0 +1,179 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceComputeInstancesV2() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceComputeInstancesV2Read,
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRegexp,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"metadata": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"flavor_id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"flavor_name"},
+			},
+
+			"flavor_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"flavor_id"},
+			},
+
+			"image_id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"image_name"},
+			},
+
+			"image_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"image_id"},
+			},
+
+			// Computed values
+			"ids": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"instances": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"flavor_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"flavor_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"image_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"image_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"availability_zone": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"key_pair": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"metadata": &schema.Schema{
+							Type:     schema.TypeMap,
+							Computed: true,
+						},
+						"security_groups": &schema.Schema{
+							Type:     schema.TypeSet,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+							Set:      schema.HashString,
+						},
+						"network": computeInstanceV2NetworkSchema(),
+						"access_ip_v4": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"access_ip_v6": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceComputeInstancesV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	region := GetRegion(d, config)
+	computeClient, err := config.computeV2Client(region)
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	allInstances, err := listComputeInstancesV2(d, computeClient)
+	if err != nil {
+		return err
+	}
+
+	filteredInstances, err := filterComputeInstancesV2(d, computeClient, allInstances)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]string)
+	ids := []string{}
+	instances := []map[string]interface{}{}
+	for _, v := range filteredInstances {
+		instance, err := flattenComputeInstanceV2(computeClient, meta, region, v, names)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, v.ID)
+		instances = append(instances, instance)
+	}
+
+	log.Printf("[DEBUG] Retrieved Servers: %v", ids)
+
+	d.SetId(strconv.Itoa(hashcode.String(strings.Join(ids, ","))))
+	d.Set("ids", ids)
+	d.Set("region", region)
+
+	if err := d.Set("instances", instances); err != nil {
+		return fmt.Errorf("Unable to set instances: %s", err)
+	}
+
+	return nil
+}
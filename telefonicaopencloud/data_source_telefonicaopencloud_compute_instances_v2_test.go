@@ -0,0 +1,77 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccComputeV2InstancesDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2InstancesDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceDataSourceID("data.telefonicaopencloud_compute_instances_v2.instances_1"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instances_v2.instances_1", "ids.#", "2"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instances_v2.instances_1", "instances.#", "2"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instances_v2.instances_1", "instances.0.network.0.uuid", OS_NETWORK_ID),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_instances_v2.instances_1", "instances.0.access_ip_v4"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instances_v2.instances_2", "ids.#", "1"),
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_compute_instances_v2.instances_2", "ids.0",
+						"telefonicaopencloud_compute_instance_v2.instance_1", "id"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_compute_instances_v2.instances_2", "instances.0.metadata.role", "web"),
+				),
+			},
+		},
+	})
+}
+
+var testAccComputeV2InstancesDataSource_basic = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_data_1"
+  security_groups = ["default"]
+  availability_zone = "%s"
+  metadata {
+    role = "web"
+  }
+  network {
+    uuid = "%s"
+  }
+}
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_2" {
+  name = "instance_data_2"
+  security_groups = ["default"]
+  availability_zone = "%s"
+  metadata {
+    role = "bastion"
+  }
+  network {
+    uuid = "%s"
+  }
+}
+
+data "telefonicaopencloud_compute_instances_v2" "instances_1" {
+  name_regex = "^instance_data_"
+  region = "${telefonicaopencloud_compute_instance_v2.instance_2.region}"
+  flavor_id = "${telefonicaopencloud_compute_instance_v2.instance_1.flavor_id}"
+}
+
+data "telefonicaopencloud_compute_instances_v2" "instances_2" {
+  image_id = "${telefonicaopencloud_compute_instance_v2.instance_1.image_id}"
+  metadata {
+    role = "${telefonicaopencloud_compute_instance_v2.instance_1.metadata.role}"
+  }
+}
+`, OS_AVAILABILITY_ZONE, OS_NETWORK_ID, OS_AVAILABILITY_ZONE, OS_NETWORK_ID)
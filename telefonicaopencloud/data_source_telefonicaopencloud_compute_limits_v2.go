@@ -0,0 +1,211 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/keypairs"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/limits"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceComputeLimitsV2() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceComputeLimitsV2Read,
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"project_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			// Computed values
+			"max_instances": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"used_instances": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_cores": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"used_cores": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_ram": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"used_ram": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_keypairs": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"used_keypairs": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_server_groups": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"used_server_groups": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_server_group_members": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_floating_ips": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"used_floating_ips": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_security_groups": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"used_security_groups": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_security_group_rules": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceComputeLimitsV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	region := GetRegion(d, config)
+	computeClient, err := config.computeV2Client(region)
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	projectId := d.Get("project_id").(string)
+	getOpts := limits.GetOpts{
+		TenantID: projectId,
+	}
+
+	l, err := limits.Get(computeClient, getOpts).Extract()
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve compute limits: %s", err)
+	}
+
+	log.Printf("[DEBUG] Retrieved compute limits: %#v", l)
+
+	// The limits don't include how many key pairs are used. Key pair
+	// quotas apply per user, so these are the key pairs of the user, which
+	// only count against the project the provider is scoped to.
+	usedKeyPairs := -1
+	ownProject, err := computeLimitsOwnProject(config, region, projectId)
+	if err != nil {
+		return err
+	}
+	if ownProject {
+		pages, err := keypairs.List(computeClient).AllPages()
+		if err != nil {
+			return fmt.Errorf("Unable to retrieve keypairs: %s", err)
+		}
+
+		allKeyPairs, err := keypairs.ExtractKeyPairs(pages)
+		if err != nil {
+			return fmt.Errorf("Unable to extract keypairs: %s", err)
+		}
+		usedKeyPairs = len(allKeyPairs)
+	}
+
+	id := region
+	if projectId != "" {
+		id = fmt.Sprintf("%s/%s", region, projectId)
+	}
+	d.SetId(id)
+
+	d.Set("max_instances", l.Absolute.MaxTotalInstances)
+	d.Set("used_instances", l.Absolute.TotalInstancesUsed)
+	d.Set("max_cores", l.Absolute.MaxTotalCores)
+	d.Set("used_cores", l.Absolute.TotalCoresUsed)
+	d.Set("max_ram", l.Absolute.MaxTotalRAMSize)
+	d.Set("used_ram", l.Absolute.TotalRAMUsed)
+	d.Set("max_keypairs", l.Absolute.MaxTotalKeypairs)
+	if usedKeyPairs >= 0 {
+		d.Set("used_keypairs", usedKeyPairs)
+	}
+	d.Set("max_server_groups", l.Absolute.MaxServerGroups)
+	d.Set("used_server_groups", l.Absolute.TotalServerGroupsUsed)
+	d.Set("max_server_group_members", l.Absolute.MaxServerGroupMembers)
+	d.Set("max_floating_ips", l.Absolute.MaxTotalFloatingIps)
+	d.Set("used_floating_ips", l.Absolute.TotalFloatingIpsUsed)
+	d.Set("max_security_groups", l.Absolute.MaxSecurityGroups)
+	d.Set("used_security_groups", l.Absolute.TotalSecurityGroupsUsed)
+	d.Set("max_security_group_rules", l.Absolute.MaxSecurityGroupRules)
+	d.Set("region", region)
+
+	return nil
+}
+
+// computeLimitsOwnProject reports whether the limits of a project are the
+// limits of the project the provider is scoped to. That's the case when no
+// project was given. Without a token, such as with access_key/secret_key,
+// the project can't be found out, so it's taken as another project.
+func computeLimitsOwnProject(config *Config, region, projectId string) (bool, error) {
+	if projectId == "" || projectId == config.TenantID {
+		return true, nil
+	}
+	if config.TenantID != "" || config.usingAKSK() {
+		return false, nil
+	}
+
+	identityClient, err := config.identityV3Client(region)
+	if err != nil {
+		return false, fmt.Errorf("Error creating TelefonicaOpenCloud identity client: %s", err)
+	}
+	if identityClient.TokenID == "" {
+		return false, nil
+	}
+
+	project, err := tokens.Get(identityClient, identityClient.TokenID).ExtractProject()
+	if err != nil {
+		return false, fmt.Errorf("Unable to retrieve the project of the token: %s", err)
+	}
+	if project == nil {
+		return false, nil
+	}
+	return project.ID == projectId, nil
+}
@@ -0,0 +1,113 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccComputeV2LimitsDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2LimitsDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2LimitsDataSourceID("data.telefonicaopencloud_compute_limits_v2.limits_1"),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_limits_v2.limits_1", "max_instances"),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_limits_v2.limits_1", "used_instances"),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_limits_v2.limits_1", "max_cores"),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_limits_v2.limits_1", "max_ram"),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_limits_v2.limits_1", "max_keypairs"),
+					testAccCheckComputeV2LimitsDataSourceAtLeast(
+						"data.telefonicaopencloud_compute_limits_v2.limits_1", "used_keypairs", 1),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2LimitsDataSource_otherProject(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2LimitsDataSource_otherProject,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2LimitsDataSourceID("data.telefonicaopencloud_compute_limits_v2.limits_1"),
+					resource.TestCheckResourceAttrSet(
+						"data.telefonicaopencloud_compute_limits_v2.limits_1", "max_keypairs"),
+					resource.TestCheckNoResourceAttr(
+						"data.telefonicaopencloud_compute_limits_v2.limits_1", "used_keypairs"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckComputeV2LimitsDataSourceID(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Can't find compute limits data source: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("Compute limits data source ID not set")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckComputeV2LimitsDataSourceAtLeast(n, key string, min int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Can't find compute limits data source: %s", n)
+		}
+
+		v, err := strconv.Atoi(rs.Primary.Attributes[key])
+		if err != nil {
+			return fmt.Errorf("%s of %s is not a number: %s", key, n, err)
+		}
+		if v < min {
+			return fmt.Errorf("%s of %s is %d, expected at least %d", key, n, v, min)
+		}
+
+		return nil
+	}
+}
+
+const testAccComputeV2LimitsDataSource_basic = `
+resource "telefonicaopencloud_compute_keypair_v2" "kp_1" {
+  name = "kp_1"
+  public_key = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQDAjpC1hwiOCCmKEWxJ4qzTTsJbKzndLo1BCz5PcwtUnflmU+gHJtWMZKpuEGVi29h0A/+ydKek1O18k10Ff+4tyFjiHDQAT9+OfgWf7+b1yK+qDip3X1C0UPMbwHlTfSGWLGZquwhvEFx9k3h/M+VtMvwR1lJ9LUyTAImnNjWG7TAIPmui30HvM2UiFEmqkr4ijq45MyX2+fLIePLRIFuu1p4whjHAQYufqyno3BS48icQb4p6iVEZPo4AE2o9oIyQvj2mx4dk5Y8CgSETOZTYDOR3rU2fZTRDRgPJDH9FWvQjF5tA0p3d9CoWWd2s6GKKbfoUIi8R/Db1BSPJwkqB jrp-hp-pc"
+}
+
+data "telefonicaopencloud_compute_limits_v2" "limits_1" {
+  region = "${telefonicaopencloud_compute_keypair_v2.kp_1.region}"
+}
+`
+
+const testAccComputeV2LimitsDataSource_otherProject = `
+resource "telefonicaopencloud_compute_keypair_v2" "kp_1" {
+  name = "kp_1"
+  public_key = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQDAjpC1hwiOCCmKEWxJ4qzTTsJbKzndLo1BCz5PcwtUnflmU+gHJtWMZKpuEGVi29h0A/+ydKek1O18k10Ff+4tyFjiHDQAT9+OfgWf7+b1yK+qDip3X1C0UPMbwHlTfSGWLGZquwhvEFx9k3h/M+VtMvwR1lJ9LUyTAImnNjWG7TAIPmui30HvM2UiFEmqkr4ijq45MyX2+fLIePLRIFuu1p4whjHAQYufqyno3BS48icQb4p6iVEZPo4AE2o9oIyQvj2mx4dk5Y8CgSETOZTYDOR3rU2fZTRDRgPJDH9FWvQjF5tA0p3d9CoWWd2s6GKKbfoUIi8R/Db1BSPJwkqB jrp-hp-pc"
+}
+
+data "telefonicaopencloud_compute_limits_v2" "limits_1" {
+  region = "${telefonicaopencloud_compute_keypair_v2.kp_1.region}"
+  project_id = "00000000000000000000000000000000"
+}
+`
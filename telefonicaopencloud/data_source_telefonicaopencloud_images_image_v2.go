@@ -0,0 +1,281 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceImagesImageV2() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceImagesImageV2Read,
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"name_regex"},
+			},
+
+			"name_regex": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"name"},
+				ValidateFunc:  validateRegexp,
+			},
+
+			"visibility": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: resourceImagesImageV2ValidateVisibility,
+			},
+
+			"owner": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"size_min": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+
+			"size_max": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+
+			"sort_key": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "name",
+			},
+
+			"sort_direction": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "asc",
+				ValidateFunc: dataSourceImagesImageV2SortDirection,
+			},
+
+			"tags": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+
+			"properties": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+				Computed: true,
+			},
+
+			"most_recent": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			// Computed values
+			"container_format": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"disk_format": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"min_disk_gb": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"min_ram_mb": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"protected": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"checksum": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"size_bytes": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"file": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"schema": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"updated_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// dataSourceImagesImageV2Read performs the image lookup.
+func dataSourceImagesImageV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	listOpts := images.ListOpts{
+		Name:       d.Get("name").(string),
+		Visibility: images.ImageVisibility(d.Get("visibility").(string)),
+		Owner:      d.Get("owner").(string),
+		Status:     images.ImageStatusActive,
+		SizeMin:    int64(d.Get("size_min").(int)),
+		SizeMax:    int64(d.Get("size_max").(int)),
+		SortKey:    d.Get("sort_key").(string),
+		SortDir:    d.Get("sort_direction").(string),
+		Tags:       resourceImagesImageV2Tags(d),
+	}
+
+	log.Printf("[DEBUG] List Options: %#v", listOpts)
+
+	pages, err := images.List(imageClient, listOpts).AllPages()
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve images: %s", err)
+	}
+
+	allImages, err := images.ExtractImages(pages)
+	if err != nil {
+		return fmt.Errorf("Unable to extract images: %s", err)
+	}
+
+	var nameRegex *regexp.Regexp
+	if v, ok := d.GetOk("name_regex"); ok {
+		nameRegex = regexp.MustCompile(v.(string))
+	}
+
+	properties := d.Get("properties").(map[string]interface{})
+
+	var filteredImages []images.Image
+	for _, image := range allImages {
+		if nameRegex != nil && !nameRegex.MatchString(image.Name) {
+			continue
+		}
+		if !imageHasProperties(image, properties) {
+			continue
+		}
+		filteredImages = append(filteredImages, image)
+	}
+
+	if len(filteredImages) < 1 {
+		return fmt.Errorf("Your query returned no results. " +
+			"Please change your search criteria and try again.")
+	}
+
+	var image images.Image
+	if len(filteredImages) > 1 {
+		if !d.Get("most_recent").(bool) {
+			log.Printf("[DEBUG] Multiple results found: %#v", filteredImages)
+			return fmt.Errorf("Your query returned more than one result. Please try a more " +
+				"specific search criteria, or set `most_recent` attribute to true.")
+		}
+		image = mostRecentImage(filteredImages)
+	} else {
+		image = filteredImages[0]
+	}
+
+	log.Printf("[DEBUG] Retrieved Image %s: %+v", image.ID, image)
+	d.SetId(image.ID)
+
+	d.Set("name", image.Name)
+	d.Set("tags", image.Tags)
+	d.Set("container_format", image.ContainerFormat)
+	d.Set("disk_format", image.DiskFormat)
+	d.Set("min_disk_gb", image.MinDiskGigabytes)
+	d.Set("min_ram_mb", image.MinRAMMegabytes)
+	d.Set("owner", image.Owner)
+	d.Set("protected", image.Protected)
+	d.Set("visibility", string(image.Visibility))
+	d.Set("checksum", image.Checksum)
+	d.Set("size_bytes", image.SizeBytes)
+	d.Set("file", image.File)
+	d.Set("schema", image.Schema)
+	d.Set("created_at", image.CreatedAt.Format(time.RFC3339))
+	d.Set("updated_at", image.UpdatedAt.Format(time.RFC3339))
+	d.Set("region", GetRegion(d, config))
+
+	imageProperties := make(map[string]string)
+	for k, v := range image.Properties {
+		imageProperties[k] = fmt.Sprint(v)
+	}
+	if err := d.Set("properties", imageProperties); err != nil {
+		log.Printf("[DEBUG] Unable to set properties: %s", err)
+	}
+
+	return nil
+}
+
+// imageHasProperties returns whether an image has all of the properties
+// with the same values.
+func imageHasProperties(image images.Image, properties map[string]interface{}) bool {
+	for k, v := range properties {
+		value, ok := image.Properties[k]
+		if !ok || fmt.Sprint(value) != v.(string) {
+			return false
+		}
+	}
+	return true
+}
+
+type imageSort []images.Image
+
+func (a imageSort) Len() int      { return len(a) }
+func (a imageSort) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a imageSort) Less(i, j int) bool {
+	return a[i].CreatedAt.Before(a[j].CreatedAt)
+}
+
+// mostRecentImage returns the most recently created image.
+func mostRecentImage(allImages []images.Image) images.Image {
+	sortedImages := allImages
+	sort.Sort(imageSort(sortedImages))
+	return sortedImages[len(sortedImages)-1]
+}
+
+func dataSourceImagesImageV2SortDirection(v interface{}, k string) (ws []string, errors []error) {
+	return ValidateStringList(v, k, []string{"asc", "desc"})
+}
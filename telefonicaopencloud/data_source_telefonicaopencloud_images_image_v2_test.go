@@ -0,0 +1,182 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccImagesImageV2DataSource_basic(t *testing.T) {
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageV2DataSource_images(imageFile),
+			},
+			resource.TestStep{
+				Config: testAccImagesImageV2DataSource_basic(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2DataSourceID("data.telefonicaopencloud_images_image_v2.image_1"),
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_images_image_v2.image_1", "id",
+						"telefonicaopencloud_images_image_v2.image_1", "id"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_images_image_v2.image_1", "container_format", "bare"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_images_image_v2.image_1", "disk_format", "raw"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_images_image_v2.image_1", "min_disk_gb", "10"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_images_image_v2.image_1", "protected", "false"),
+					resource.TestCheckResourceAttr(
+						"data.telefonicaopencloud_images_image_v2.image_1", "properties.os_type", "Linux"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImagesImageV2DataSource_testQueries(t *testing.T) {
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageV2DataSource_images(imageFile),
+			},
+			resource.TestStep{
+				Config: testAccImagesImageV2DataSource_queryRegex(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_images_image_v2.image_1", "id",
+						"telefonicaopencloud_images_image_v2.image_2", "id"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccImagesImageV2DataSource_queryTags(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_images_image_v2.image_1", "id",
+						"telefonicaopencloud_images_image_v2.image_1", "id"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccImagesImageV2DataSource_queryProperties(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.telefonicaopencloud_images_image_v2.image_1", "id",
+						"telefonicaopencloud_images_image_v2.image_2", "id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckImagesImageV2DataSourceID(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Can't find image data source: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("Image data source ID not set")
+		}
+
+		return nil
+	}
+}
+
+func testAccImagesImageV2DataSource_images(imageFile string) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_images_image_v2" "image_1" {
+  name = "tf_acc_image_1"
+  local_file_path = "%s"
+  container_format = "bare"
+  disk_format = "raw"
+  min_disk_gb = 10
+  tags = ["tf_acc", "tf_acc_1"]
+
+  properties {
+    os_type = "Linux"
+    tf_acc_index = "1"
+  }
+}
+
+resource "telefonicaopencloud_images_image_v2" "image_2" {
+  name = "tf_acc_image_2"
+  local_file_path = "%s"
+  container_format = "bare"
+  disk_format = "raw"
+  tags = ["tf_acc", "tf_acc_2"]
+
+  properties {
+    os_type = "Linux"
+    tf_acc_index = "2"
+  }
+
+  depends_on = ["telefonicaopencloud_images_image_v2.image_1"]
+}
+`, imageFile, imageFile)
+}
+
+func testAccImagesImageV2DataSource_basic(imageFile string) string {
+	return fmt.Sprintf(`
+%s
+
+data "telefonicaopencloud_images_image_v2" "image_1" {
+  name = "${telefonicaopencloud_images_image_v2.image_1.name}"
+}
+`, testAccImagesImageV2DataSource_images(imageFile))
+}
+
+func testAccImagesImageV2DataSource_queryRegex(imageFile string) string {
+	return fmt.Sprintf(`
+%s
+
+data "telefonicaopencloud_images_image_v2" "image_1" {
+  name_regex = "^tf_acc_image_[0-9]$"
+  visibility = "private"
+  owner = "${telefonicaopencloud_images_image_v2.image_2.owner}"
+  most_recent = true
+}
+`, testAccImagesImageV2DataSource_images(imageFile))
+}
+
+func testAccImagesImageV2DataSource_queryTags(imageFile string) string {
+	return fmt.Sprintf(`
+%s
+
+data "telefonicaopencloud_images_image_v2" "image_1" {
+  visibility = "private"
+  owner = "${telefonicaopencloud_images_image_v2.image_2.owner}"
+  tags = ["tf_acc", "tf_acc_1"]
+}
+`, testAccImagesImageV2DataSource_images(imageFile))
+}
+
+func testAccImagesImageV2DataSource_queryProperties(imageFile string) string {
+	return fmt.Sprintf(`
+%s
+
+data "telefonicaopencloud_images_image_v2" "image_1" {
+  visibility = "private"
+  owner = "${telefonicaopencloud_images_image_v2.image_2.owner}"
+  tags = ["tf_acc"]
+
+  properties {
+    tf_acc_index = "2"
+  }
+}
+`, testAccImagesImageV2DataSource_images(imageFile))
+}
@@ -0,0 +1,36 @@
+package telefonicaopencloud
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/quotasets"
+)
+
+func TestAccComputeV2Quotaset_importBasic(t *testing.T) {
+	var quotaset quotasets.QuotaSet
+	resourceName := "telefonicaopencloud_compute_quotaset_v2.quotaset_1"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testAccPreCheckAdminOnly(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2QuotasetDestroy(&quotaset),
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2Quotaset_basic,
+				Check: testAccCheckComputeV2QuotasetExists(
+					"telefonicaopencloud_compute_quotaset_v2.quotaset_1", &quotaset),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package telefonicaopencloud
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccImagesImageAccessAcceptV2_importBasic(t *testing.T) {
+	resourceName := "telefonicaopencloud_images_image_access_accept_v2.accept_1"
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageAccessV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageAccessAcceptV2_basic(imageFile, "accepted"),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
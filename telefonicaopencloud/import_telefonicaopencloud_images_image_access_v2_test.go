@@ -0,0 +1,31 @@
+package telefonicaopencloud
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccImagesImageAccessV2_importBasic(t *testing.T) {
+	resourceName := "telefonicaopencloud_images_image_access_v2.access_1"
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageAccessV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageAccessV2_basic(imageFile),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
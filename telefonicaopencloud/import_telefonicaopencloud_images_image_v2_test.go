@@ -0,0 +1,36 @@
+package telefonicaopencloud
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccImagesImageV2_importBasic(t *testing.T) {
+	resourceName := "telefonicaopencloud_images_image_v2.image_1"
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageV2_basic(imageFile),
+			},
+
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"local_file_path",
+					"image_cache_path",
+					"verify_checksum",
+				},
+			},
+		},
+	})
+}
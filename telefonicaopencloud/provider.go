@@ -1,6 +1,8 @@
 package telefonicaopencloud
 
 import (
+	"time"
+
 	"github.com/hashicorp/terraform/helper/mutexkv"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
@@ -11,7 +13,7 @@ var osMutexKV = mutexkv.NewMutexKV()
 
 // Provider returns a schema.Provider for TelefonicaOpenCloud.
 func Provider() terraform.ResourceProvider {
-	return &schema.Provider{
+	return &customDiffProvider{Provider: &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"access_key": {
 				Type:        schema.TypeString,
@@ -167,24 +169,74 @@ func Provider() terraform.ResourceProvider {
 				DefaultFunc: schema.EnvDefaultFunc("OS_CLOUD", ""),
 				Description: descriptions["cloud"],
 			},
+
+			"endpoints": &schema.Schema{
+				Type:         schema.TypeMap,
+				Optional:     true,
+				ValidateFunc: validateEndpoints,
+				Description:  descriptions["endpoints"],
+			},
+
+			"max_retries": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("OS_MAX_RETRIES", 0),
+				ValidateFunc: validateNonNegativeInt,
+				Description:  descriptions["max_retries"],
+			},
+
+			"retry_min_delay": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("OS_RETRY_MIN_DELAY", "1s"),
+				ValidateFunc: validateDuration,
+				Description:  descriptions["retry_min_delay"],
+			},
+
+			"retry_max_delay": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("OS_RETRY_MAX_DELAY", "30s"),
+				ValidateFunc: validateDuration,
+				Description:  descriptions["retry_max_delay"],
+			},
+
+			"trace_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OS_TRACE_FILE", ""),
+				Description: descriptions["trace_file"],
+			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"telefonicaopencloud_dns_zone_v2":            dataSourceDNSZoneV2(),
-			"telefonicaopencloud_networking_network_v2":  dataSourceNetworkingNetworkV2(),
-			"telefonicaopencloud_networking_subnet_v2":   dataSourceNetworkingSubnetV2(),
-			"telefonicaopencloud_networking_secgroup_v2": dataSourceNetworkingSecGroupV2(),
-			"telefonicaopencloud_s3_bucket_object":       dataSourceS3BucketObject(),
+			"telefonicaopencloud_availability_zones":                 dataSourceAvailabilityZones(),
+			"telefonicaopencloud_blockstorage_availability_zones_v2": dataSourceBlockStorageAvailabilityZonesV2(),
+			"telefonicaopencloud_blockstorage_snapshot_v2":           dataSourceBlockStorageSnapshotV2(),
+			"telefonicaopencloud_compute_flavor_v2":                  dataSourceComputeFlavorV2(),
+			"telefonicaopencloud_compute_instance_v2":                dataSourceComputeInstanceV2(),
+			"telefonicaopencloud_compute_instances_v2":               dataSourceComputeInstancesV2(),
+			"telefonicaopencloud_compute_limits_v2":                  dataSourceComputeLimitsV2(),
+			"telefonicaopencloud_dns_zone_v2":                        dataSourceDNSZoneV2(),
+			"telefonicaopencloud_images_image_v2":                    dataSourceImagesImageV2(),
+			"telefonicaopencloud_networking_network_v2":              dataSourceNetworkingNetworkV2(),
+			"telefonicaopencloud_networking_subnet_v2":               dataSourceNetworkingSubnetV2(),
+			"telefonicaopencloud_networking_secgroup_v2":             dataSourceNetworkingSecGroupV2(),
+			"telefonicaopencloud_s3_bucket_object":                   dataSourceS3BucketObject(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
+			"telefonicaopencloud_blockstorage_snapshot_v2":        resourceBlockStorageSnapshotV2(),
 			"telefonicaopencloud_blockstorage_volume_v2":          resourceBlockStorageVolumeV2(),
 			"telefonicaopencloud_compute_instance_v2":             resourceComputeInstanceV2(),
 			"telefonicaopencloud_compute_keypair_v2":              resourceComputeKeypairV2(),
+			"telefonicaopencloud_compute_quotaset_v2":             resourceComputeQuotasetV2(),
 			"telefonicaopencloud_compute_secgroup_v2":             resourceComputeSecGroupV2(),
 			"telefonicaopencloud_compute_servergroup_v2":          resourceComputeServerGroupV2(),
 			"telefonicaopencloud_compute_floatingip_v2":           resourceComputeFloatingIPV2(),
 			"telefonicaopencloud_compute_floatingip_associate_v2": resourceComputeFloatingIPAssociateV2(),
+			"telefonicaopencloud_compute_instance_image_v2":       resourceComputeInstanceImageV2(),
+			"telefonicaopencloud_compute_interface_attach_v2":     resourceComputeInterfaceAttachV2(),
 			"telefonicaopencloud_compute_volume_attach_v2":        resourceComputeVolumeAttachV2(),
 			"telefonicaopencloud_dns_recordset_v2":                resourceDNSRecordSetV2(),
 			"telefonicaopencloud_dns_zone_v2":                     resourceDNSZoneV2(),
@@ -192,6 +244,9 @@ func Provider() terraform.ResourceProvider {
 			"telefonicaopencloud_elb_listener":                    resourceELBListener(),
 			"telefonicaopencloud_elb_healthcheck":                 resourceELBHealthCheck(),
 			"telefonicaopencloud_elb_backendecs":                  resourceELBBackendECS(),
+			"telefonicaopencloud_images_image_v2":                 resourceImagesImageV2(),
+			"telefonicaopencloud_images_image_access_v2":          resourceImagesImageAccessV2(),
+			"telefonicaopencloud_images_image_access_accept_v2":   resourceImagesImageAccessAcceptV2(),
 			"telefonicaopencloud_networking_network_v2":           resourceNetworkingNetworkV2(),
 			"telefonicaopencloud_networking_subnet_v2":            resourceNetworkingSubnetV2(),
 			"telefonicaopencloud_networking_floatingip_v2":        resourceNetworkingFloatingIPV2(),
@@ -214,7 +269,32 @@ func Provider() terraform.ResourceProvider {
 		},
 
 		ConfigureFunc: configureProvider,
+	}}
+}
+
+// resourceCustomDiffs are the diff functions of the resources whose diff
+// depends on their configuration, such as which changes force a new
+// resource. The vendored helper/schema has no CustomizeDiff for this yet.
+var resourceCustomDiffs = map[string]func(*terraform.InstanceState, *terraform.ResourceConfig) (*terraform.InstanceDiff, error){
+	"telefonicaopencloud_blockstorage_volume_v2": resourceBlockStorageVolumeV2Diff,
+	"telefonicaopencloud_compute_instance_v2":    resourceComputeInstanceV2Diff,
+}
+
+// customDiffProvider is a schema.Provider which diffs the resources in
+// resourceCustomDiffs with their own diff function.
+type customDiffProvider struct {
+	*schema.Provider
+}
+
+func (p *customDiffProvider) Diff(
+	info *terraform.InstanceInfo,
+	s *terraform.InstanceState,
+	c *terraform.ResourceConfig) (*terraform.InstanceDiff, error) {
+	if diff, ok := resourceCustomDiffs[info.Type]; ok {
+		return diff(s, c)
 	}
+
+	return p.Provider.Diff(info, s, c)
 }
 
 var descriptions map[string]string
@@ -260,6 +340,17 @@ func init() {
 			"service (Octavia) instead of the Networking service (Neutron).",
 
 		"cloud": "An entry in a `clouds.yaml` file to use.",
+
+		"endpoints": "The custom endpoints used to override the service catalog, keyed by service.",
+
+		"max_retries": "How many times to retry throttled requests, gateway errors and\n" +
+			"connection resets. 0 disables retries.",
+
+		"retry_min_delay": "The delay before the first retry, doubled on every further retry.",
+
+		"retry_max_delay": "The maximum delay between retries.",
+
+		"trace_file": "A file to append a JSON trace of every API request to.",
 	}
 }
 
@@ -276,10 +367,12 @@ func configureProvider(d *schema.ResourceData) (interface{}, error) {
 		EndpointType:     d.Get("endpoint_type").(string),
 		IdentityEndpoint: d.Get("auth_url").(string),
 		Insecure:         d.Get("insecure").(bool),
+		MaxRetries:       d.Get("max_retries").(int),
 		Password:         d.Get("password").(string),
 		Region:           d.Get("region").(string),
 		Swauth:           d.Get("swauth").(bool),
 		Token:            d.Get("token").(string),
+		TraceFile:        d.Get("trace_file").(string),
 		TenantID:         d.Get("tenant_id").(string),
 		TenantName:       d.Get("tenant_name").(string),
 		Username:         d.Get("user_name").(string),
@@ -287,6 +380,16 @@ func configureProvider(d *schema.ResourceData) (interface{}, error) {
 		useOctavia:       d.Get("use_octavia").(bool),
 	}
 
+	endpoints := make(map[string]string)
+	for k, v := range d.Get("endpoints").(map[string]interface{}) {
+		endpoints[k] = v.(string)
+	}
+	config.Endpoints = endpoints
+
+	// Both durations were checked by validateDuration already.
+	config.RetryMinDelay, _ = time.ParseDuration(d.Get("retry_min_delay").(string))
+	config.RetryMaxDelay, _ = time.ParseDuration(d.Get("retry_max_delay").(string))
+
 	if err := config.LoadAndValidate(); err != nil {
 		return nil, err
 	}
@@ -1,50 +1,292 @@
 package telefonicaopencloud
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/helper/pathorcontents"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/terraform-providers/terraform-provider-telefonicaopencloud/fakecloud"
 )
 
 var (
-	OS_DB_ENVIRONMENT         = os.Getenv("OS_DB_ENVIRONMENT")
-	OS_DB_DATASTORE_VERSION   = os.Getenv("OS_DB_DATASTORE_VERSION")
-	OS_DB_DATASTORE_TYPE      = os.Getenv("OS_DB_DATASTORE_TYPE")
-	OS_DEPRECATED_ENVIRONMENT = os.Getenv("OS_DEPRECATED_ENVIRONMENT")
-	OS_DNS_ENVIRONMENT        = os.Getenv("OS_DNS_ENVIRONMENT")
-	OS_EXTGW_ID               = os.Getenv("OS_EXTGW_ID")
-	OS_FLAVOR_ID              = os.Getenv("OS_FLAVOR_ID")
-	OS_FLAVOR_NAME            = os.Getenv("OS_FLAVOR_NAME")
-	OS_IMAGE_ID               = os.Getenv("OS_IMAGE_ID")
-	OS_IMAGE_NAME             = os.Getenv("OS_IMAGE_NAME")
-	OS_NETWORK_ID             = os.Getenv("OS_NETWORK_ID")
-	OS_VPC_ID                 = os.Getenv("OS_VPC_ID")
-	OS_POOL_NAME              = os.Getenv("OS_POOL_NAME")
-	OS_REGION_NAME            = os.Getenv("OS_REGION_NAME")
-	OS_SWIFT_ENVIRONMENT      = os.Getenv("OS_SWIFT_ENVIRONMENT")
-	OS_AVAILABILITY_ZONE      = os.Getenv("OS_AVAILABILITY_ZONE")
-	OS_ACCESS_KEY             = os.Getenv("OS_ACCESS_KEY")
-	OS_SECRET_KEY             = os.Getenv("OS_SECRET_KEY")
-	OS_TENANT_ID              = os.Getenv("OS_TENANT_ID")
+	OS_DB_ENVIRONMENT         = testAccGetenv("OS_DB_ENVIRONMENT")
+	OS_DB_DATASTORE_VERSION   = testAccGetenv("OS_DB_DATASTORE_VERSION")
+	OS_DB_DATASTORE_TYPE      = testAccGetenv("OS_DB_DATASTORE_TYPE")
+	OS_DEPRECATED_ENVIRONMENT = testAccGetenv("OS_DEPRECATED_ENVIRONMENT")
+	OS_DNS_ENVIRONMENT        = testAccGetenv("OS_DNS_ENVIRONMENT")
+	OS_EXTGW_ID               = testAccGetenv("OS_EXTGW_ID")
+	OS_FLAVOR_ID              = testAccGetenv("OS_FLAVOR_ID")
+	OS_FLAVOR_NAME            = testAccGetenv("OS_FLAVOR_NAME")
+	OS_IMAGE_ID               = testAccGetenv("OS_IMAGE_ID")
+	OS_IMAGE_NAME             = testAccGetenv("OS_IMAGE_NAME")
+	OS_NETWORK_ID             = testAccGetenv("OS_NETWORK_ID")
+	OS_VPC_ID                 = testAccGetenv("OS_VPC_ID")
+	OS_POOL_NAME              = testAccGetenv("OS_POOL_NAME")
+	OS_REGION_NAME            = testAccGetenv("OS_REGION_NAME")
+	OS_SWIFT_ENVIRONMENT      = testAccGetenv("OS_SWIFT_ENVIRONMENT")
+	OS_AVAILABILITY_ZONE      = testAccGetenv("OS_AVAILABILITY_ZONE")
+	OS_ACCESS_KEY             = testAccGetenv("OS_ACCESS_KEY")
+	OS_SECRET_KEY             = testAccGetenv("OS_SECRET_KEY")
+	OS_TENANT_ID              = testAccGetenv("OS_TENANT_ID")
 )
 
 var testAccProviders map[string]terraform.ResourceProvider
 var testAccProvider *schema.Provider
 
 func init() {
-	testAccProvider = Provider().(*schema.Provider)
+	provider := Provider().(*customDiffProvider)
+	testAccProvider = provider.Provider
 	testAccProviders = map[string]terraform.ResourceProvider{
-		"telefonicaopencloud": testAccProvider,
+		"telefonicaopencloud": provider,
+	}
+}
+
+// testAccFakeCloud is the in-memory fake cloud the acceptance tests run
+// against when OS_FAKE_CLOUD is set, so that they need neither credentials
+// nor network.
+var testAccFakeCloud = testAccStartFakeCloud()
+
+func testAccStartFakeCloud() *fakecloud.Server {
+	if os.Getenv("OS_FAKE_CLOUD") == "" {
+		return nil
+	}
+
+	cloud := fakecloud.New()
+	for k, v := range cloud.Env() {
+		os.Setenv(k, v)
+	}
+	return cloud
+}
+
+// testAccGetenv reads an environment variable of the acceptance tests. Test
+// configurations are built when the package is initialized, so this makes
+// sure the fake cloud, if any, has been started first.
+func testAccGetenv(key string) string {
+	if testAccFakeCloud != nil {
+		if v, ok := testAccFakeCloud.Env()[key]; ok {
+			return v
+		}
+	}
+	if v, ok := testAccCassetteEnv[key]; ok {
+		return v
+	}
+	return os.Getenv(key)
+}
+
+// testAccCassetteMode is set with OS_CASSETTE. With "record", the requests of
+// every acceptance test are recorded to a cassette in testAccCassetteDir.
+// With "replay", they are replayed from it, without a cloud.
+var testAccCassetteMode = os.Getenv("OS_CASSETTE")
+
+const testAccCassetteDir = "testdata/cassettes"
+
+// testAccCassetteEnvVars are the environment variables which are recorded
+// along with the cassettes and set again when they're replayed.
+var testAccCassetteEnvVars = []string{
+	"OS_AUTH_URL", "OS_REGION_NAME", "OS_ENDPOINT_TYPE",
+	"OS_USERNAME", "OS_USER_ID", "OS_PASSWORD", "OS_AUTH_TOKEN",
+	"OS_ACCESS_KEY", "OS_SECRET_KEY",
+	"OS_TENANT_ID", "OS_PROJECT_ID", "OS_TENANT_NAME", "OS_PROJECT_NAME",
+	"OS_DOMAIN_ID", "OS_USER_DOMAIN_ID", "OS_PROJECT_DOMAIN_ID",
+	"OS_DOMAIN_NAME", "OS_USER_DOMAIN_NAME", "OS_PROJECT_DOMAIN_NAME", "OS_DEFAULT_DOMAIN",
+	"OS_DB_ENVIRONMENT", "OS_DB_DATASTORE_VERSION", "OS_DB_DATASTORE_TYPE",
+	"OS_DEPRECATED_ENVIRONMENT", "OS_DNS_ENVIRONMENT", "OS_SWIFT_ENVIRONMENT",
+	"OS_EXTGW_ID", "OS_FLAVOR_ID", "OS_FLAVOR_NAME", "OS_IMAGE_ID", "OS_IMAGE_NAME",
+	"OS_NETWORK_ID", "OS_VPC_ID", "OS_POOL_NAME", "OS_AVAILABILITY_ZONE",
+}
+
+// testAccCassetteScrubbed are the environment variables whose values are
+// replaced in the cassettes, and the placeholders which replace them.
+var testAccCassetteScrubbed = map[string]string{
+	"OS_PASSWORD":            cassetteScrubbed,
+	"OS_AUTH_TOKEN":          cassetteScrubbed,
+	"OS_ACCESS_KEY":          cassetteScrubbed,
+	"OS_SECRET_KEY":          cassetteScrubbed,
+	"OS_USERNAME":            "scrubbed-user-name",
+	"OS_USER_ID":             "scrubbed-user-id",
+	"OS_TENANT_ID":           "scrubbed-project-id",
+	"OS_PROJECT_ID":          "scrubbed-project-id",
+	"OS_DOMAIN_ID":           "scrubbed-domain-id",
+	"OS_USER_DOMAIN_ID":      "scrubbed-domain-id",
+	"OS_PROJECT_DOMAIN_ID":   "scrubbed-domain-id",
+	"OS_DOMAIN_NAME":         "scrubbed-domain-name",
+	"OS_USER_DOMAIN_NAME":    "scrubbed-domain-name",
+	"OS_PROJECT_DOMAIN_NAME": "scrubbed-domain-name",
+	"OS_DEFAULT_DOMAIN":      "scrubbed-domain-name",
+}
+
+// testAccCassetteEnv holds the recorded environment variables when the
+// cassettes are replayed.
+var testAccCassetteEnv = testAccStartCassettes()
+
+var testAccCassetteEnvOnce sync.Once
+
+func testAccStartCassettes() map[string]string {
+	switch testAccCassetteMode {
+	case "":
+		return nil
+	case "record":
+		cassettePlayer = &cassetteDeck{}
+		return nil
+	case "replay":
+		cassettePlayer = &cassetteDeck{}
+	default:
+		log.Fatalf("OS_CASSETTE must be record or replay, got %q", testAccCassetteMode)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(testAccCassetteDir, "env.json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		log.Fatalf("Error reading the environment of the cassettes: %s", err)
+	}
+
+	var env map[string]string
+	if err := json.Unmarshal(data, &env); err != nil {
+		log.Fatalf("Error parsing the environment of the cassettes: %s", err)
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	return env
+}
+
+// testAccCassetteScrub returns the sensitive values of the environment and
+// the placeholders which replace them. Values which are too short to be
+// told apart from the rest of a request are left out.
+func testAccCassetteScrub() map[string]string {
+	scrub := make(map[string]string)
+	for k, placeholder := range testAccCassetteScrubbed {
+		if v := os.Getenv(k); len(v) >= 4 {
+			scrub[v] = placeholder
+		}
+	}
+	return scrub
+}
+
+// testAccSaveCassetteEnv records the environment of the acceptance tests,
+// scrubbed, next to the cassettes.
+func testAccSaveCassetteEnv(scrub map[string]string) error {
+	env := make(map[string]string)
+	for _, k := range testAccCassetteEnvVars {
+		if v := os.Getenv(k); v != "" {
+			if placeholder, ok := testAccCassetteScrubbed[k]; ok && len(v) < 4 {
+				v = placeholder
+			}
+			env[k] = scrubCassetteText(v, scrub)
+		}
+	}
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(testAccCassetteDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(testAccCassetteDir, "env.json"), append(data, '\n'), 0644)
+}
+
+// testAccInsertCassette records the requests of the test to its cassette, or
+// replays them from it, when the acceptance tests are run with cassettes.
+// Tests which have no cassette are skipped when replaying. The cassette is
+// ejected when the next test inserts its own, or once every test has run.
+func testAccInsertCassette(t *testing.T) {
+	if cassettePlayer == nil {
+		return
+	}
+
+	path := filepath.Join(testAccCassetteDir, strings.Replace(t.Name(), "/", "_", -1)+".json")
+	if c := cassettePlayer.current(); c != nil && c.path == path {
+		return
+	}
+
+	if err := testAccEjectCassette(); err != nil {
+		log.Printf("[ERROR] Error saving cassette: %s", err)
+	}
+
+	var c *cassette
+	if testAccCassetteMode == "record" {
+		scrub := testAccCassetteScrub()
+		var err error
+		testAccCassetteEnvOnce.Do(func() { err = testAccSaveCassetteEnv(scrub) })
+		if err != nil {
+			t.Fatalf("Error recording the environment of the cassettes: %s", err)
+		}
+		c = newCassette(path, scrub)
+		c.keep = func() bool { return !t.Failed() && !t.Skipped() }
+	} else {
+		var err error
+		c, err = loadCassette(path)
+		if os.IsNotExist(err) {
+			t.Skipf("No cassette has been recorded for %s", t.Name())
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cassettePlayer.insert(c)
+}
+
+// testAccEjectCassette ejects the inserted cassette, and saves it if it was
+// recorded and its test passed.
+func testAccEjectCassette() error {
+	c := cassettePlayer.eject()
+	if c != nil && c.recording && c.keep() {
+		return c.save()
+	}
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if err := testAccEjectCassette(); err != nil {
+		log.Printf("[ERROR] Error saving cassette: %s", err)
+		code = 1
+	}
+	if testAccFakeCloud != nil {
+		testAccFakeCloud.Close()
+	}
+	os.Exit(code)
+}
+
+// testAccOBSTests are the prefixes of the names of the acceptance tests which
+// use OBS. The fake cloud doesn't serve OBS, and OBS requests don't go
+// through the cassettes.
+var testAccOBSTests = []string{"TestAccS3", "TestAccDataSourceS3", "TestAccDataSourceAWSS3"}
+
+// testAccPreCheckFakeCloud skips the acceptance tests which can't be run
+// against the fake cloud or with cassettes.
+func testAccPreCheckFakeCloud(t *testing.T) {
+	for _, prefix := range testAccOBSTests {
+		if !strings.HasPrefix(t.Name(), prefix) {
+			continue
+		}
+		if os.Getenv("OS_FAKE_CLOUD") != "" {
+			t.Skip("The fake cloud does not support OBS")
+		}
+		if testAccCassetteMode != "" {
+			t.Skip("OBS requests are not recorded to cassettes")
+		}
 	}
 }
 
 func testAccPreCheckRequiredEnvVars(t *testing.T) {
+	testAccPreCheckFakeCloud(t)
+	testAccInsertCassette(t)
+
 	v := os.Getenv("OS_AUTH_URL")
 	if v == "" {
 		t.Fatal("OS_AUTH_URL must be set for acceptance tests")
@@ -149,7 +391,7 @@ func testAccPreCheckELB(t *testing.T) {
 }
 
 func TestProvider(t *testing.T) {
-	if err := Provider().(*schema.Provider).InternalValidate(); err != nil {
+	if err := Provider().(*customDiffProvider).InternalValidate(); err != nil {
 		t.Fatalf("err: %s", err)
 	}
 }
@@ -188,6 +430,9 @@ func TestAccProvider_caCertFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected err when specifying TelefonicaOpenCloud CA by file: %s", err)
 	}
+	if err := testAccProviderAuthenticate(p); err != nil {
+		t.Fatalf("Error authenticating with TelefonicaOpenCloud CA by file: %s", err)
+	}
 }
 
 func TestAccProvider_caCertString(t *testing.T) {
@@ -216,6 +461,9 @@ func TestAccProvider_caCertString(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected err when specifying TelefonicaOpenCloud CA by string: %s", err)
 	}
+	if err := testAccProviderAuthenticate(p); err != nil {
+		t.Fatalf("Error authenticating with TelefonicaOpenCloud CA by string: %s", err)
+	}
 }
 
 func TestAccProvider_clientCertFile(t *testing.T) {
@@ -252,6 +500,9 @@ func TestAccProvider_clientCertFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected err when specifying TelefonicaOpenCloud Client keypair by file: %s", err)
 	}
+	if err := testAccProviderAuthenticate(p); err != nil {
+		t.Fatalf("Error authenticating with TelefonicaOpenCloud Client keypair by file: %s", err)
+	}
 }
 
 func TestAccProvider_clientCertString(t *testing.T) {
@@ -286,6 +537,15 @@ func TestAccProvider_clientCertString(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected err when specifying TelefonicaOpenCloud Client keypair by contents: %s", err)
 	}
+	if err := testAccProviderAuthenticate(p); err != nil {
+		t.Fatalf("Error authenticating with TelefonicaOpenCloud Client keypair by contents: %s", err)
+	}
+}
+
+// testAccProviderAuthenticate authenticates a configured provider, which
+// otherwise only happens once the first resource needs a client.
+func testAccProviderAuthenticate(p terraform.ResourceProvider) error {
+	return p.(*customDiffProvider).Provider.Meta().(*Config).authenticate()
 }
 
 func envVarContents(varName string) (string, error) {
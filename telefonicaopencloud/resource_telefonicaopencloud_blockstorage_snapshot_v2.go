@@ -0,0 +1,238 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/blockstorage/v2/snapshots"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceBlockStorageSnapshotV2() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceBlockStorageSnapshotV2Create,
+		Read:   resourceBlockStorageSnapshotV2Read,
+		Update: resourceBlockStorageSnapshotV2Update,
+		Delete: resourceBlockStorageSnapshotV2Delete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"volume_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"force": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+
+			"metadata": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			// Computed values
+			"size": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceBlockStorageSnapshotV2Create(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	blockStorageClient, err := config.blockStorageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
+	}
+
+	createOpts := &snapshots.CreateOpts{
+		VolumeID:    d.Get("volume_id").(string),
+		Force:       d.Get("force").(bool),
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Metadata:    resourceSnapshotMetadataV2(d),
+	}
+
+	log.Printf("[DEBUG] Create Options: %#v", createOpts)
+	s, err := snapshots.Create(blockStorageClient, createOpts).Extract()
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud snapshot: %s", err)
+	}
+	log.Printf("[INFO] Snapshot ID: %s", s.ID)
+
+	// Store the ID now
+	d.SetId(s.ID)
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"creating"},
+		Target:     []string{"available"},
+		Refresh:    SnapshotV2StateRefreshFunc(blockStorageClient, s.ID),
+		Timeout:    d.Timeout(schema.TimeoutCreate),
+		Delay:      10 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	_, err = stateConf.WaitForState()
+	if err != nil {
+		return fmt.Errorf(
+			"Error waiting for snapshot (%s) to become ready: %s",
+			s.ID, err)
+	}
+
+	return resourceBlockStorageSnapshotV2Read(d, meta)
+}
+
+func resourceBlockStorageSnapshotV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	blockStorageClient, err := config.blockStorageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
+	}
+
+	s, err := snapshots.Get(blockStorageClient, d.Id()).Extract()
+	if err != nil {
+		return CheckDeleted(d, err, "snapshot")
+	}
+
+	log.Printf("[DEBUG] Retrieved snapshot %s: %+v", d.Id(), s)
+
+	d.Set("volume_id", s.VolumeID)
+	d.Set("name", s.Name)
+	d.Set("description", s.Description)
+	d.Set("size", s.Size)
+	d.Set("status", s.Status)
+	d.Set("region", GetRegion(d, config))
+
+	if err := d.Set("metadata", s.Metadata); err != nil {
+		log.Printf("[DEBUG] Unable to set metadata: %s", err)
+	}
+
+	return nil
+}
+
+func resourceBlockStorageSnapshotV2Update(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	blockStorageClient, err := config.blockStorageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
+	}
+
+	if d.HasChange("metadata") {
+		// The metadata are replaced as a whole, so removed keys go too.
+		updateOpts := snapshots.UpdateMetadataOpts{
+			Metadata: d.Get("metadata").(map[string]interface{}),
+		}
+
+		log.Printf("[DEBUG] Updating metadata of snapshot %s: %#v", d.Id(), updateOpts)
+		if _, err := snapshots.UpdateMetadata(blockStorageClient, d.Id(), updateOpts).ExtractMetadata(); err != nil {
+			return fmt.Errorf("Error updating metadata of TelefonicaOpenCloud snapshot %s: %s", d.Id(), err)
+		}
+	}
+
+	return resourceBlockStorageSnapshotV2Read(d, meta)
+}
+
+func resourceBlockStorageSnapshotV2Delete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	blockStorageClient, err := config.blockStorageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
+	}
+
+	if err := snapshots.Delete(blockStorageClient, d.Id()).ExtractErr(); err != nil {
+		return CheckDeleted(d, err, "snapshot")
+	}
+
+	// Wait for the snapshot to delete before moving on, since its volume
+	// can't be deleted until then.
+	log.Printf("[DEBUG] Waiting for snapshot (%s) to delete", d.Id())
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"deleting", "available"},
+		Target:     []string{"deleted"},
+		Refresh:    SnapshotV2StateRefreshFunc(blockStorageClient, d.Id()),
+		Timeout:    d.Timeout(schema.TimeoutDelete),
+		Delay:      10 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	_, err = stateConf.WaitForState()
+	if err != nil {
+		return fmt.Errorf(
+			"Error waiting for snapshot (%s) to delete: %s",
+			d.Id(), err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func resourceSnapshotMetadataV2(d *schema.ResourceData) map[string]string {
+	m := make(map[string]string)
+	for key, val := range d.Get("metadata").(map[string]interface{}) {
+		m[key] = val.(string)
+	}
+	return m
+}
+
+// SnapshotV2StateRefreshFunc returns a resource.StateRefreshFunc that is used to watch
+// an TelefonicaOpenCloud snapshot.
+func SnapshotV2StateRefreshFunc(client *gophercloud.ServiceClient, snapshotID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		s, err := snapshots.Get(client, snapshotID).Extract()
+		if err != nil {
+			if _, ok := err.(gophercloud.ErrDefault404); ok {
+				return s, "deleted", nil
+			}
+			return nil, "", err
+		}
+
+		if s.Status == "error" || s.Status == "error_deleting" {
+			return s, s.Status, fmt.Errorf("There was an error with the snapshot. " +
+				"Please check with your cloud admin or check the Block Storage " +
+				"API logs to see why this error occurred.")
+		}
+
+		return s, s.Status, nil
+	}
+}
@@ -0,0 +1,214 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/gophercloud/gophercloud/openstack/blockstorage/v2/snapshots"
+)
+
+func TestAccBlockStorageV2Snapshot_basic(t *testing.T) {
+	var snapshot snapshots.Snapshot
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckBlockStorageV2SnapshotDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccBlockStorageV2Snapshot_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2SnapshotExists("telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", &snapshot),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "name", "snapshot_1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "size", "1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "status", "available"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "metadata.foo", "bar"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccBlockStorageV2Snapshot_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2SnapshotExists("telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", &snapshot),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "metadata.%", "1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "metadata.stage", "before-upgrade"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccBlockStorageV2Snapshot_force(t *testing.T) {
+	var snapshot snapshots.Snapshot
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckBlockStorageV2SnapshotDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccBlockStorageV2Snapshot_force,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2SnapshotExists("telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", &snapshot),
+					resource.TestCheckResourceAttrPair(
+						"telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", "volume_id",
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "id"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccBlockStorageV2Snapshot_timeout(t *testing.T) {
+	var snapshot snapshots.Snapshot
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckBlockStorageV2SnapshotDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccBlockStorageV2Snapshot_timeout,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2SnapshotExists("telefonicaopencloud_blockstorage_snapshot_v2.snapshot_1", &snapshot),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckBlockStorageV2SnapshotDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Config)
+	blockStorageClient, err := config.blockStorageV2Client(OS_REGION_NAME)
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
+	}
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "telefonicaopencloud_blockstorage_snapshot_v2" {
+			continue
+		}
+
+		_, err := snapshots.Get(blockStorageClient, rs.Primary.ID).Extract()
+		if err == nil {
+			return fmt.Errorf("Snapshot still exists")
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckBlockStorageV2SnapshotExists(n string, snapshot *snapshots.Snapshot) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		config := testAccProvider.Meta().(*Config)
+		blockStorageClient, err := config.blockStorageV2Client(OS_REGION_NAME)
+		if err != nil {
+			return fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
+		}
+
+		found, err := snapshots.Get(blockStorageClient, rs.Primary.ID).Extract()
+		if err != nil {
+			return err
+		}
+
+		if found.ID != rs.Primary.ID {
+			return fmt.Errorf("Snapshot not found")
+		}
+
+		*snapshot = *found
+
+		return nil
+	}
+}
+
+const testAccBlockStorageV2Snapshot_basic = `
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1"
+  size = 1
+}
+
+resource "telefonicaopencloud_blockstorage_snapshot_v2" "snapshot_1" {
+  name = "snapshot_1"
+  description = "first test snapshot"
+  volume_id = "${telefonicaopencloud_blockstorage_volume_v2.volume_1.id}"
+  metadata {
+    foo = "bar"
+  }
+}
+`
+
+const testAccBlockStorageV2Snapshot_update = `
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1"
+  size = 1
+}
+
+resource "telefonicaopencloud_blockstorage_snapshot_v2" "snapshot_1" {
+  name = "snapshot_1"
+  description = "first test snapshot"
+  volume_id = "${telefonicaopencloud_blockstorage_volume_v2.volume_1.id}"
+  metadata {
+    stage = "before-upgrade"
+  }
+}
+`
+
+var testAccBlockStorageV2Snapshot_force = fmt.Sprintf(`
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1"
+  size = 1
+}
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "%s"
+  }
+}
+
+resource "telefonicaopencloud_compute_volume_attach_v2" "va_1" {
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+  volume_id = "${telefonicaopencloud_blockstorage_volume_v2.volume_1.id}"
+}
+
+resource "telefonicaopencloud_blockstorage_snapshot_v2" "snapshot_1" {
+  name = "snapshot_1"
+  volume_id = "${telefonicaopencloud_compute_volume_attach_v2.va_1.volume_id}"
+  force = true
+}
+`, OS_NETWORK_ID)
+
+const testAccBlockStorageV2Snapshot_timeout = `
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1"
+  size = 1
+}
+
+resource "telefonicaopencloud_blockstorage_snapshot_v2" "snapshot_1" {
+  name = "snapshot_1"
+  volume_id = "${telefonicaopencloud_blockstorage_volume_v2.volume_1.id}"
+
+  timeouts {
+    create = "5m"
+    delete = "5m"
+  }
+}
+`
@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/blockstorage/extensions/volumeactions"
 	"github.com/gophercloud/gophercloud/openstack/blockstorage/v2/volumes"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/volumeattach"
 	"github.com/hashicorp/terraform/helper/hashcode"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
 )
 
 func resourceBlockStorageVolumeV2() *schema.Resource {
@@ -26,6 +29,7 @@ func resourceBlockStorageVolumeV2() *schema.Resource {
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 
@@ -40,7 +44,6 @@ func resourceBlockStorageVolumeV2() *schema.Resource {
 			"size": &schema.Schema{
 				Type:     schema.TypeInt,
 				Required: true,
-				ForceNew: true,
 			},
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
@@ -82,9 +85,14 @@ func resourceBlockStorageVolumeV2() *schema.Resource {
 			"volume_type": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
 				Computed: true,
 			},
+			"migration_policy": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "never",
+				ValidateFunc: resourceBlockStorageVolumeV2ValidateMigrationPolicy,
+			},
 			"consistency_group_id": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
@@ -233,23 +241,134 @@ func resourceBlockStorageVolumeV2Update(d *schema.ResourceData, meta interface{}
 		return fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
 	}
 
-	updateOpts := volumes.UpdateOpts{
-		Name:        d.Get("name").(string),
-		Description: d.Get("description").(string),
+	if d.HasChange("size") {
+		if err := resourceBlockStorageVolumeV2Extend(d, blockStorageClient); err != nil {
+			return err
+		}
 	}
 
-	if d.HasChange("metadata") {
-		updateOpts.Metadata = resourceVolumeMetadataV2(d)
+	if d.HasChange("volume_type") {
+		if err := resourceBlockStorageVolumeV2Retype(d, blockStorageClient); err != nil {
+			return err
+		}
 	}
 
-	_, err = volumes.Update(blockStorageClient, d.Id(), updateOpts).Extract()
-	if err != nil {
-		return fmt.Errorf("Error updating TelefonicaOpenCloud volume: %s", err)
+	if d.HasChange("name") || d.HasChange("description") || d.HasChange("metadata") {
+		name := d.Get("name").(string)
+		description := d.Get("description").(string)
+		updateOpts := VolumeUpdateOpts{
+			Name:        &name,
+			Description: &description,
+		}
+
+		if d.HasChange("metadata") {
+			updateOpts.Metadata = resourceVolumeMetadataV2(d)
+		}
+
+		log.Printf("[DEBUG] Updating volume %s: %#v", d.Id(), updateOpts)
+		_, err = volumes.Update(blockStorageClient, d.Id(), updateOpts).Extract()
+		if err != nil {
+			return fmt.Errorf("Error updating TelefonicaOpenCloud volume: %s", err)
+		}
 	}
 
 	return resourceBlockStorageVolumeV2Read(d, meta)
 }
 
+// resourceBlockStorageVolumeV2Extend grows a volume in place. Attached
+// volumes can only be extended if the cloud supports extending them online,
+// otherwise Cinder rejects the request.
+func resourceBlockStorageVolumeV2Extend(d *schema.ResourceData, blockStorageClient *gophercloud.ServiceClient) error {
+	v, err := volumes.Get(blockStorageClient, d.Id()).Extract()
+	if err != nil {
+		return CheckDeleted(d, err, "volume")
+	}
+
+	extendOpts := volumeactions.ExtendSizeOpts{
+		NewSize: d.Get("size").(int),
+	}
+
+	log.Printf("[DEBUG] Extending volume %s: %#v", d.Id(), extendOpts)
+	if err := volumeactions.ExtendSize(blockStorageClient, d.Id(), extendOpts).ExtractErr(); err != nil {
+		if v.Status == "in-use" {
+			return fmt.Errorf("Error extending TelefonicaOpenCloud volume %s while it's attached, "+
+				"which the cloud may not support: %s", d.Id(), err)
+		}
+		return fmt.Errorf("Error extending TelefonicaOpenCloud volume %s: %s", d.Id(), err)
+	}
+
+	// The volume returns to the status it was in once it has been extended.
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"extending"},
+		Target:     []string{v.Status},
+		Refresh:    VolumeV2SizeRefreshFunc(blockStorageClient, d.Id(), extendOpts.NewSize),
+		Timeout:    d.Timeout(schema.TimeoutUpdate),
+		Delay:      10 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, err := stateConf.WaitForState(); err != nil {
+		return fmt.Errorf(
+			"Error waiting for volume (%s) to be extended: %s",
+			d.Id(), err)
+	}
+
+	return nil
+}
+
+// resourceBlockStorageVolumeV2Retype changes the type of a volume in place.
+// Attached volumes can be retyped as well, but moving a volume to another
+// backend requires the migration policy to be on-demand.
+func resourceBlockStorageVolumeV2Retype(d *schema.ResourceData, blockStorageClient *gophercloud.ServiceClient) error {
+	v, err := volumes.Get(blockStorageClient, d.Id()).Extract()
+	if err != nil {
+		return CheckDeleted(d, err, "volume")
+	}
+
+	retypeOpts := VolumeRetypeOpts{
+		NewType:         d.Get("volume_type").(string),
+		MigrationPolicy: d.Get("migration_policy").(string),
+	}
+
+	log.Printf("[DEBUG] Retyping volume %s: %#v", d.Id(), retypeOpts)
+	b, err := retypeOpts.ToVolumeRetypeMap()
+	if err != nil {
+		return err
+	}
+
+	_, err = blockStorageClient.Post(blockStorageClient.ServiceURL("volumes", d.Id(), "action"), b, nil, &gophercloud.RequestOpts{
+		OkCodes: []int{202},
+	})
+	if err != nil {
+		// The cloud explains why it refuses a retype, e.g. because the
+		// volume would have to be migrated, in the body of the response.
+		if errCode, ok := err.(gophercloud.ErrDefault400); ok {
+			return fmt.Errorf("Error changing the type of TelefonicaOpenCloud volume %s from %s to %s: %s",
+				d.Id(), v.VolumeType, retypeOpts.NewType, errCode.Body)
+		}
+		return fmt.Errorf("Error changing the type of TelefonicaOpenCloud volume %s from %s to %s: %s",
+			d.Id(), v.VolumeType, retypeOpts.NewType, err)
+	}
+
+	// Like extending, retyping returns the volume to the status it was in.
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"retyping"},
+		Target:     []string{v.Status},
+		Refresh:    VolumeV2TypeRefreshFunc(blockStorageClient, d.Id(), retypeOpts.NewType),
+		Timeout:    d.Timeout(schema.TimeoutUpdate),
+		Delay:      10 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, err := stateConf.WaitForState(); err != nil {
+		return fmt.Errorf(
+			"Error waiting for volume (%s) to be retyped: %s",
+			d.Id(), err)
+	}
+
+	return nil
+}
+
 func resourceBlockStorageVolumeV2Delete(d *schema.ResourceData, meta interface{}) error {
 	config := meta.(*Config)
 	blockStorageClient, err := config.blockStorageV2Client(GetRegion(d, config))
@@ -355,6 +474,90 @@ func VolumeV2StateRefreshFunc(client *gophercloud.ServiceClient, volumeID string
 	}
 }
 
+// VolumeV2SizeRefreshFunc returns a resource.StateRefreshFunc that is used to
+// watch an TelefonicaOpenCloud volume being extended. The volume is reported
+// as extending until it has the new size, since Cinder may not have started
+// extending it yet when the request returns. A volume in error couldn't be
+// extended.
+func VolumeV2SizeRefreshFunc(client *gophercloud.ServiceClient, volumeID string, size int) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		v, err := volumes.Get(client, volumeID).Extract()
+		if err != nil {
+			return nil, "", err
+		}
+
+		if v.Status == "error" || v.Status == "error_extending" {
+			return v, v.Status, fmt.Errorf("There was an error extending the volume. " +
+				"Please check with your cloud admin or check the Block Storage " +
+				"API logs to see why this error occurred.")
+		}
+
+		if v.Size != size {
+			return v, "extending", nil
+		}
+
+		return v, v.Status, nil
+	}
+}
+
+// VolumeV2TypeRefreshFunc returns a resource.StateRefreshFunc that is used to
+// watch an TelefonicaOpenCloud volume being retyped. The volume is retyping
+// until it has the new type, which may take a while if it has to be migrated
+// to another backend. A volume which is done retyping but still has its old
+// type couldn't be retyped.
+func VolumeV2TypeRefreshFunc(client *gophercloud.ServiceClient, volumeID string, volumeType string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		v, err := volumes.Get(client, volumeID).Extract()
+		if err != nil {
+			return nil, "", err
+		}
+
+		if v.Status == "error" {
+			return v, v.Status, fmt.Errorf("There was an error retyping the volume. " +
+				"Please check with your cloud admin or check the Block Storage " +
+				"API logs to see why this error occurred.")
+		}
+
+		// Cinder puts the volume in the retyping status before it accepts
+		// the retype, so a volume which is out of it with its old type was
+		// left as it was.
+		if v.VolumeType != volumeType && v.Status != "retyping" {
+			return v, v.Status, fmt.Errorf("The volume is %s again, but its type is still %s instead of %s. "+
+				"Please check with your cloud admin or check the Block Storage "+
+				"API logs to see why the volume couldn't be retyped.", v.Status, v.VolumeType, volumeType)
+		}
+
+		return v, v.Status, nil
+	}
+}
+
+// resourceBlockStorageVolumeV2Diff diffs a volume. A volume can be extended
+// in place, but it can't be shrunk, so a smaller size is rejected unless the
+// volume is replaced anyway.
+func resourceBlockStorageVolumeV2Diff(
+	s *terraform.InstanceState, c *terraform.ResourceConfig) (*terraform.InstanceDiff, error) {
+	diff, err := resourceBlockStorageVolumeV2().Diff(s, c)
+	if err != nil || diff == nil || s == nil || s.ID == "" || diff.RequiresNew() {
+		return diff, err
+	}
+
+	if attr, ok := diff.Attributes["size"]; ok && !attr.NewComputed {
+		oldSize, _ := strconv.Atoi(attr.Old)
+		newSize, err := strconv.Atoi(attr.New)
+		if err == nil && newSize < oldSize {
+			return nil, fmt.Errorf(
+				"The size of volume %s can't be decreased from %d to %d GB. "+
+					"Volumes can only be extended.", s.ID, oldSize, newSize)
+		}
+	}
+
+	return diff, nil
+}
+
+func resourceBlockStorageVolumeV2ValidateMigrationPolicy(v interface{}, k string) (ws []string, errors []error) {
+	return ValidateStringList(v, k, []string{"never", "on-demand"})
+}
+
 func resourceVolumeV2AttachmentHash(v interface{}) int {
 	var buf bytes.Buffer
 	m := v.(map[string]interface{})
@@ -2,6 +2,9 @@ package telefonicaopencloud
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -37,6 +40,15 @@ func TestAccBlockStorageV2Volume_basic(t *testing.T) {
 						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "name", "volume_1-updated"),
 				),
 			},
+			resource.TestStep{
+				Config: testAccBlockStorageV2Volume_clearDescription,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "name", "volume_1-updated"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "description", ""),
+				),
+			},
 		},
 	})
 }
@@ -79,6 +91,207 @@ func TestAccBlockStorageV2Volume_timeout(t *testing.T) {
 	})
 }
 
+func TestAccBlockStorageV2Volume_extend(t *testing.T) {
+	var volume, extended volumes.Volume
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckBlockStorageV2VolumeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccBlockStorageV2Volume_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2VolumeExists("telefonicaopencloud_blockstorage_volume_v2.volume_1", &volume),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "size", "1"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccBlockStorageV2Volume_extend,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2VolumeExists("telefonicaopencloud_blockstorage_volume_v2.volume_1", &extended),
+					testAccCheckBlockStorageV2VolumeSame(&volume, &extended),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "size", "2"),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccBlockStorageV2Volume_basic,
+				ExpectError: regexp.MustCompile(`can't be decreased from 2 to 1 GB`),
+			},
+		},
+	})
+}
+
+func TestAccBlockStorageV2Volume_extendAttached(t *testing.T) {
+	var volume, extended volumes.Volume
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckBlockStorageV2VolumeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccBlockStorageV2Volume_attached(1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2VolumeExists("telefonicaopencloud_blockstorage_volume_v2.volume_1", &volume),
+				),
+			},
+			resource.TestStep{
+				Config: testAccBlockStorageV2Volume_attached(2),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2VolumeExists("telefonicaopencloud_blockstorage_volume_v2.volume_1", &extended),
+					testAccCheckBlockStorageV2VolumeSame(&volume, &extended),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "size", "2"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "attachment.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccBlockStorageV2Volume_retype(t *testing.T) {
+	var volume, retyped volumes.Volume
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckBlockStorageV2VolumeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccBlockStorageV2Volume_retype("SATA", "never"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2VolumeExists("telefonicaopencloud_blockstorage_volume_v2.volume_1", &volume),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "volume_type", "SATA"),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccBlockStorageV2Volume_retype("SSD", "never"),
+				ExpectError: regexp.MustCompile(`requires migration`),
+			},
+			resource.TestStep{
+				Config: testAccBlockStorageV2Volume_retype("SSD", "on-demand"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2VolumeExists("telefonicaopencloud_blockstorage_volume_v2.volume_1", &retyped),
+					testAccCheckBlockStorageV2VolumeSame(&volume, &retyped),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "volume_type", "SSD"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccBlockStorageV2Volume_retypeAttached(t *testing.T) {
+	var volume, retyped volumes.Volume
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckBlockStorageV2VolumeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccBlockStorageV2Volume_retypeAttached("SATA"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2VolumeExists("telefonicaopencloud_blockstorage_volume_v2.volume_1", &volume),
+				),
+			},
+			resource.TestStep{
+				Config: testAccBlockStorageV2Volume_retypeAttached("SSD"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckBlockStorageV2VolumeExists("telefonicaopencloud_blockstorage_volume_v2.volume_1", &retyped),
+					testAccCheckBlockStorageV2VolumeSame(&volume, &retyped),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "volume_type", "SSD"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_blockstorage_volume_v2.volume_1", "attachment.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestVolumeV2TypeRefreshFunc(t *testing.T) {
+	cases := []struct {
+		status     string
+		volumeType string
+		state      string
+		err        bool
+	}{
+		{"retyping", "SATA", "retyping", false},
+		{"retyping", "SSD", "retyping", false},
+		{"available", "SSD", "available", false},
+		{"in-use", "SSD", "in-use", false},
+		{"available", "SATA", "available", true},
+		{"in-use", "SATA", "in-use", true},
+		{"error", "SATA", "error", true},
+	}
+
+	for _, tc := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"volume": {"id": "volume_1", "status": %q, "volume_type": %q}}`, tc.status, tc.volumeType)
+		}))
+
+		client := &gophercloud.ServiceClient{
+			ProviderClient: &gophercloud.ProviderClient{},
+			Endpoint:       server.URL + "/",
+		}
+		_, state, err := VolumeV2TypeRefreshFunc(client, "volume_1", "SSD")()
+		server.Close()
+
+		if state != tc.state {
+			t.Errorf("%s volume of type %s: expected state %s, got %s", tc.status, tc.volumeType, tc.state, state)
+		}
+		if tc.err != (err != nil) {
+			t.Errorf("%s volume of type %s: unexpected error: %v", tc.status, tc.volumeType, err)
+		}
+	}
+}
+
+func TestVolumeV2SizeRefreshFunc(t *testing.T) {
+	cases := []struct {
+		status string
+		size   int
+		state  string
+		err    bool
+	}{
+		{"available", 1, "extending", false},
+		{"extending", 1, "extending", false},
+		{"extending", 2, "extending", false},
+		{"available", 2, "available", false},
+		{"in-use", 2, "in-use", false},
+		{"error_extending", 1, "error_extending", true},
+		{"error", 1, "error", true},
+		{"error", 2, "error", true},
+	}
+
+	for _, tc := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"volume": {"id": "volume_1", "status": %q, "size": %d}}`, tc.status, tc.size)
+		}))
+
+		client := &gophercloud.ServiceClient{
+			ProviderClient: &gophercloud.ProviderClient{},
+			Endpoint:       server.URL + "/",
+		}
+		_, state, err := VolumeV2SizeRefreshFunc(client, "volume_1", 2)()
+		server.Close()
+
+		if state != tc.state {
+			t.Errorf("%s volume of size %d: expected state %s, got %s", tc.status, tc.size, tc.state, state)
+		}
+		if tc.err != (err != nil) {
+			t.Errorf("%s volume of size %d: unexpected error: %v", tc.status, tc.size, err)
+		}
+	}
+}
+
 func testAccCheckBlockStorageV2VolumeDestroy(s *terraform.State) error {
 	config := testAccProvider.Meta().(*Config)
 	blockStorageClient, err := config.blockStorageV2Client(OS_REGION_NAME)
@@ -152,6 +365,16 @@ func testAccCheckBlockStorageV2VolumeDoesNotExist(t *testing.T, n string, volume
 	}
 }
 
+func testAccCheckBlockStorageV2VolumeSame(before, after *volumes.Volume) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if before.ID != after.ID {
+			return fmt.Errorf("Volume %s was replaced by %s", before.ID, after.ID)
+		}
+
+		return nil
+	}
+}
+
 func testAccCheckBlockStorageV2VolumeMetadata(
 	volume *volumes.Volume, k string, v string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -197,6 +420,84 @@ resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
 }
 `
 
+const testAccBlockStorageV2Volume_clearDescription = `
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1-updated"
+  metadata {
+    foo = "bar"
+  }
+  size = 1
+}
+`
+
+const testAccBlockStorageV2Volume_extend = `
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1"
+  description = "first test volume"
+  metadata {
+    foo = "bar"
+  }
+  size = 2
+}
+`
+
+func testAccBlockStorageV2Volume_attached(size int) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1"
+  size = %d
+}
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "%s"
+  }
+}
+
+resource "telefonicaopencloud_compute_volume_attach_v2" "va_1" {
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+  volume_id = "${telefonicaopencloud_blockstorage_volume_v2.volume_1.id}"
+}
+`, size, OS_NETWORK_ID)
+}
+
+func testAccBlockStorageV2Volume_retype(volumeType, migrationPolicy string) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1"
+  size = 1
+  volume_type = "%s"
+  migration_policy = "%s"
+}
+`, volumeType, migrationPolicy)
+}
+
+func testAccBlockStorageV2Volume_retypeAttached(volumeType string) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
+  name = "volume_1"
+  size = 1
+  volume_type = "%s"
+  migration_policy = "on-demand"
+}
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "%s"
+  }
+}
+
+resource "telefonicaopencloud_compute_volume_attach_v2" "va_1" {
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+  volume_id = "${telefonicaopencloud_blockstorage_volume_v2.volume_1.id}"
+}
+`, volumeType, OS_NETWORK_ID)
+}
+
 var testAccBlockStorageV2Volume_image = fmt.Sprintf(`
 resource "telefonicaopencloud_blockstorage_volume_v2" "volume_1" {
   name = "volume_1"
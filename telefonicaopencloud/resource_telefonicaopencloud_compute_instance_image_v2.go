@@ -0,0 +1,248 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceComputeInstanceImageV2() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceComputeInstanceImageV2Create,
+		Read:   resourceComputeInstanceImageV2Read,
+		Update: resourceComputeInstanceImageV2Update,
+		Delete: resourceImagesImageV2Delete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"instance_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"metadata": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			// Computed values
+			"container_format": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"disk_format": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"min_disk_gb": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"min_ram_mb": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"visibility": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"checksum": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"size_bytes": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"owner": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"updated_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceComputeInstanceImageV2Create(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	instanceId := d.Get("instance_id").(string)
+	createOpts := &servers.CreateImageOpts{
+		Name:     d.Get("name").(string),
+		Metadata: resourceComputeInstanceImageV2Metadata(d),
+	}
+
+	log.Printf("[DEBUG] Creating image of instance %s: %#v", instanceId, createOpts)
+	imageId, err := servers.CreateImage(computeClient, instanceId, createOpts).ExtractImageID()
+	if err != nil {
+		return fmt.Errorf("Error creating image of TelefonicaOpenCloud server (%s): %s", instanceId, err)
+	}
+
+	d.SetId(imageId)
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{string(images.ImageStatusQueued), string(images.ImageStatusSaving)},
+		Target:     []string{string(images.ImageStatusActive)},
+		Refresh:    ImagesImageV2StateRefreshFunc(imageClient, d.Id()),
+		Timeout:    d.Timeout(schema.TimeoutCreate),
+		Delay:      5 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, err = stateConf.WaitForState(); err != nil {
+		return fmt.Errorf("Error waiting for image %s of instance %s to become active: %s", d.Id(), instanceId, err)
+	}
+
+	return resourceComputeInstanceImageV2Read(d, meta)
+}
+
+func resourceComputeInstanceImageV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	img, err := images.Get(imageClient, d.Id()).Extract()
+	if err != nil {
+		return CheckDeleted(d, err, "image")
+	}
+
+	log.Printf("[DEBUG] Retrieved Image %s: %#v", d.Id(), img)
+
+	// Nova records the instance an image was created from as a property.
+	if v, ok := img.Properties["instance_uuid"]; ok {
+		d.Set("instance_id", fmt.Sprint(v))
+	}
+
+	d.Set("name", img.Name)
+	d.Set("container_format", img.ContainerFormat)
+	d.Set("disk_format", img.DiskFormat)
+	d.Set("min_disk_gb", img.MinDiskGigabytes)
+	d.Set("min_ram_mb", img.MinRAMMegabytes)
+	d.Set("visibility", string(img.Visibility))
+	d.Set("checksum", img.Checksum)
+	d.Set("size_bytes", img.SizeBytes)
+	d.Set("status", string(img.Status))
+	d.Set("owner", img.Owner)
+	d.Set("created_at", img.CreatedAt.Format(time.RFC3339))
+	d.Set("updated_at", img.UpdatedAt.Format(time.RFC3339))
+	d.Set("region", GetRegion(d, config))
+
+	// The metadata become properties of the image, next to the ones Nova
+	// copies from the instance, so only the configured keys are tracked.
+	metadata := make(map[string]string)
+	for k := range d.Get("metadata").(map[string]interface{}) {
+		if v, ok := img.Properties[k]; ok {
+			metadata[k] = fmt.Sprint(v)
+		}
+	}
+	if err := d.Set("metadata", metadata); err != nil {
+		log.Printf("[DEBUG] Unable to set metadata: %s", err)
+	}
+
+	return nil
+}
+
+func resourceComputeInstanceImageV2Update(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	var updateOpts images.UpdateOpts
+
+	if d.HasChange("name") {
+		updateOpts = append(updateOpts, images.ReplaceImageName{NewName: d.Get("name").(string)})
+	}
+
+	if d.HasChange("metadata") {
+		o, n := d.GetChange("metadata")
+		oldMetadata := o.(map[string]interface{})
+		newMetadata := n.(map[string]interface{})
+
+		for k := range oldMetadata {
+			if _, ok := newMetadata[k]; !ok {
+				updateOpts = append(updateOpts, imagePatch{Op: "remove", Path: imagePropertyPath(k)})
+			}
+		}
+		for k, v := range newMetadata {
+			if oldMetadata[k] != v {
+				updateOpts = append(updateOpts, imagePatch{Op: "add", Path: imagePropertyPath(k), Value: v})
+			}
+		}
+	}
+
+	if len(updateOpts) > 0 {
+		log.Printf("[DEBUG] Update Options: %#v", updateOpts)
+		_, err = images.Update(imageClient, d.Id(), updateOpts).Extract()
+		if err != nil {
+			return fmt.Errorf("Error updating image %s: %s", d.Id(), err)
+		}
+	}
+
+	return resourceComputeInstanceImageV2Read(d, meta)
+}
+
+func resourceComputeInstanceImageV2Metadata(d *schema.ResourceData) map[string]string {
+	metadata := make(map[string]string)
+	for k, v := range d.Get("metadata").(map[string]interface{}) {
+		metadata[k] = v.(string)
+	}
+	return metadata
+}
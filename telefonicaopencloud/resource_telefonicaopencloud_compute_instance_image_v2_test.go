@@ -0,0 +1,126 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+)
+
+func TestAccComputeV2InstanceImage_basic(t *testing.T) {
+	var instance servers.Server
+	var image images.Image
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InstanceImageDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2InstanceImage_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance),
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_compute_instance_image_v2.image_1", &image),
+					resource.TestCheckResourceAttrPair(
+						"telefonicaopencloud_compute_instance_image_v2.image_1", "instance_id",
+						"telefonicaopencloud_compute_instance_v2.instance_1", "id"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_image_v2.image_1", "name", "image_1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_image_v2.image_1", "status", "active"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_image_v2.image_1", "visibility", "private"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_image_v2.image_1", "metadata.foo", "bar"),
+					testAccCheckImagesImageV2Property(&image, "foo", "bar"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2InstanceImage_update(t *testing.T) {
+	var image images.Image
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InstanceImageDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2InstanceImage_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_compute_instance_image_v2.image_1", &image),
+					testAccCheckImagesImageV2Property(&image, "foo", "bar"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2InstanceImage_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_compute_instance_image_v2.image_1", &image),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_image_v2.image_1", "name", "image_2"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_image_v2.image_1", "metadata.%", "2"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_image_v2.image_1", "metadata.os_type", "Linux"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_image_v2.image_1", "metadata.os/distro", "cirros"),
+					testAccCheckImagesImageV2Property(&image, "foo", ""),
+					testAccCheckImagesImageV2Property(&image, "os_type", "Linux"),
+					testAccCheckImagesImageV2Property(&image, "os/distro", "cirros"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckComputeV2InstanceImageDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Config)
+	imageClient, err := config.imageV2Client(OS_REGION_NAME)
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "telefonicaopencloud_compute_instance_image_v2" {
+			continue
+		}
+
+		_, err := images.Get(imageClient, rs.Primary.ID).Extract()
+		if err == nil {
+			return fmt.Errorf("Image still exists")
+		}
+	}
+
+	return nil
+}
+
+var testAccComputeV2InstanceImage_basic = fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_compute_instance_image_v2" "image_1" {
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+  name = "image_1"
+  metadata {
+    foo = "bar"
+  }
+}
+`, testAccComputeV2Instance_basic)
+
+var testAccComputeV2InstanceImage_update = fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_compute_instance_image_v2" "image_1" {
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+  name = "image_2"
+  metadata {
+    os_type = "Linux"
+    "os/distro" = "cirros"
+  }
+}
+`, testAccComputeV2Instance_basic)
@@ -6,10 +6,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/blockstorage/v2/volumes"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/availabilityzones"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/bootfromvolume"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/keypairs"
@@ -22,6 +25,7 @@ import (
 	"github.com/hashicorp/terraform/helper/hashcode"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
 )
 
 func resourceComputeInstanceV2() *schema.Resource {
@@ -31,6 +35,10 @@ func resourceComputeInstanceV2() *schema.Resource {
 		Update: resourceComputeInstanceV2Update,
 		Delete: resourceComputeInstanceV2Delete,
 
+		Importer: &schema.ResourceImporter{
+			State: resourceComputeInstanceV2ImportState,
+		},
+
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(30 * time.Minute),
 			Update: schema.DefaultTimeout(30 * time.Minute),
@@ -330,6 +338,22 @@ func resourceComputeInstanceV2() *schema.Resource {
 				Optional: true,
 				Default:  false,
 			},
+			"rebuild_on_image_change": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"power_state": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "active",
+				ValidateFunc: resourceComputeInstanceV2ValidatePowerState,
+			},
+			"locked": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 			"all_metadata": &schema.Schema{
 				Type:     schema.TypeMap,
 				Computed: true,
@@ -463,6 +487,19 @@ func resourceComputeInstanceV2Create(d *schema.ResourceData, meta interface{}) e
 			server.ID, err)
 	}
 
+	if powerState := d.Get("power_state").(string); powerState != "active" {
+		err := setInstancePowerState(computeClient, server.ID, powerState, d.Timeout(schema.TimeoutCreate))
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.Get("locked").(bool) {
+		if err := setInstanceLocked(computeClient, server.ID, true); err != nil {
+			return err
+		}
+	}
+
 	return resourceComputeInstanceV2Read(d, meta)
 }
 
@@ -473,7 +510,15 @@ func resourceComputeInstanceV2Read(d *schema.ResourceData, meta interface{}) err
 		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
 	}
 
-	server, err := servers.Get(computeClient, d.Id()).Extract()
+	// Nova reports whether an instance is locked from microversion 2.9 on.
+	// Clouds which don't support it are asked without a microversion, and
+	// locked is left as it is.
+	raw := servers.Get(computeV2Microversion(computeClient, "2.9"), d.Id())
+	if computeV2MicroversionUnsupported(raw.Err) {
+		log.Printf("[DEBUG] Compute microversion 2.9 is unsupported, retrieving Server %s without it", d.Id())
+		raw = servers.Get(computeClient, d.Id())
+	}
+	server, err := raw.Extract()
 	if err != nil {
 		return CheckDeleted(d, err, "server")
 	}
@@ -482,6 +527,22 @@ func resourceComputeInstanceV2Read(d *schema.ResourceData, meta interface{}) err
 
 	d.Set("name", server.Name)
 
+	// The power state is left alone while the instance is busy, for
+	// example while it's being resized.
+	if powerState, ok := getInstancePowerState(server.Status); ok {
+		d.Set("power_state", powerState)
+	}
+
+	var serverLock struct {
+		Locked *bool `json:"locked"`
+	}
+	if err := raw.ExtractIntoStructPtr(&serverLock, "server"); err != nil {
+		return fmt.Errorf("Error retrieving the lock of TelefonicaOpenCloud server %s: %s", d.Id(), err)
+	}
+	if serverLock.Locked != nil {
+		d.Set("locked", *serverLock.Locked)
+	}
+
 	// Get the instance network and address information
 	networks, err := flattenInstanceNetworks(d, meta)
 	if err != nil {
@@ -562,6 +623,138 @@ func resourceComputeInstanceV2Read(d *schema.ResourceData, meta interface{}) err
 	return nil
 }
 
+// resourceComputeInstanceV2ImportState sets the arguments which Read leaves
+// alone because they can't be told apart from the configuration, such as
+// the key pair, security groups, metadata and boot volume. The rest is
+// filled in by the Read which follows the import.
+func resourceComputeInstanceV2ImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	// Nova reports whether the attached volumes are deleted along with the
+	// server from microversion 2.3 on.
+	raw := servers.Get(computeV2Microversion(computeClient, "2.3"), d.Id())
+	server, err := raw.Extract()
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving TelefonicaOpenCloud server %s: %s", d.Id(), err)
+	}
+
+	// servers.Server unmarshals itself, so the volumes extension can't be
+	// embedded alongside it.
+	var serverAttachments struct {
+		VolumesAttached []map[string]interface{} `json:"os-extended-volumes:volumes_attached"`
+	}
+	if err := raw.ExtractIntoStructPtr(&serverAttachments, "server"); err != nil {
+		return nil, fmt.Errorf("Error retrieving the volumes of TelefonicaOpenCloud server %s: %s", d.Id(), err)
+	}
+
+	var secGroups []string
+	for _, sg := range server.SecurityGroups {
+		if name, ok := sg["name"].(string); ok {
+			secGroups = append(secGroups, name)
+		}
+	}
+	d.Set("security_groups", secGroups)
+	if server.KeyName != "" {
+		d.Set("key_pair", server.KeyName)
+	}
+	if len(server.Metadata) > 0 {
+		d.Set("metadata", server.Metadata)
+	}
+	d.Set("stop_before_destroy", false)
+	d.Set("rebuild_on_image_change", false)
+	d.Set("locked", false)
+
+	// A server booted from a volume has no image. Its boot volume is
+	// the first bootable volume attached to it.
+	if server.Image == nil {
+		blockDevices, err := getInstanceBootBlockDevices(d, meta, serverAttachments.VolumesAttached)
+		if err != nil {
+			return nil, err
+		}
+		d.Set("block_device", blockDevices)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// getInstanceBootBlockDevices rebuilds the block_device of the boot volume
+// out of the volumes attached to a server. Volumes created from an image
+// are reported with the image as their source.
+func getInstanceBootBlockDevices(
+	d *schema.ResourceData, meta interface{}, volumesAttached []map[string]interface{}) ([]map[string]interface{}, error) {
+
+	config := meta.(*Config)
+	blockStorageClient, err := config.blockStorageV2Client(GetRegion(d, config))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating TelefonicaOpenCloud block storage client: %s", err)
+	}
+
+	for _, attached := range volumesAttached {
+		volumeID, _ := attached["id"].(string)
+
+		raw := volumes.Get(blockStorageClient, volumeID)
+		volume, err := raw.Extract()
+		if err != nil {
+			return nil, fmt.Errorf("Error retrieving TelefonicaOpenCloud volume %s: %s", volumeID, err)
+		}
+
+		var volumeImage struct {
+			VolumeImageMetadata map[string]interface{} `json:"volume_image_metadata"`
+		}
+		if err := raw.ExtractIntoStructPtr(&volumeImage, "volume"); err != nil {
+			return nil, fmt.Errorf("Error retrieving the image of TelefonicaOpenCloud volume %s: %s", volumeID, err)
+		}
+
+		if volume.Bootable != "true" {
+			continue
+		}
+
+		deleteOnTermination, _ := attached["delete_on_termination"].(bool)
+		blockDevice := map[string]interface{}{
+			"source_type":           "volume",
+			"uuid":                  volume.ID,
+			"destination_type":      "volume",
+			"boot_index":            0,
+			"delete_on_termination": deleteOnTermination,
+		}
+		if imageID, ok := volumeImage.VolumeImageMetadata["image_id"].(string); ok && imageID != "" {
+			blockDevice["source_type"] = "image"
+			blockDevice["uuid"] = imageID
+			blockDevice["volume_size"] = volume.Size
+		}
+
+		log.Printf("[DEBUG] getInstanceBootBlockDevices: %#v", blockDevice)
+		return []map[string]interface{}{blockDevice}, nil
+	}
+
+	return nil, nil
+}
+
+// computeV2Microversion returns a copy of a compute client which asks for a
+// microversion of the API. The clients are shared, so they're left alone.
+func computeV2Microversion(client *gophercloud.ServiceClient, microversion string) *gophercloud.ServiceClient {
+	c := *client
+	c.Microversion = microversion
+	return &c
+}
+
+// computeV2MicroversionUnsupported reports whether a request failed because
+// the cloud doesn't support the microversion it asked for. Nova answers with
+// a 406, older clouds which don't know microversions at all with a 400.
+func computeV2MicroversionUnsupported(err error) bool {
+	switch err := err.(type) {
+	case gophercloud.ErrDefault400:
+		return true
+	case gophercloud.ErrUnexpectedResponseCode:
+		return err.Actual == http.StatusNotAcceptable
+	}
+	return false
+}
+
 func resourceComputeInstanceV2Update(d *schema.ResourceData, meta interface{}) error {
 	config := meta.(*Config)
 	computeClient, err := config.computeV2Client(GetRegion(d, config))
@@ -569,6 +762,31 @@ func resourceComputeInstanceV2Update(d *schema.ResourceData, meta interface{}) e
 		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
 	}
 
+	// A locked instance can't be changed, so it's unlocked for the update
+	// and locked again afterwards.
+	if oldLocked, _ := d.GetChange("locked"); oldLocked.(bool) {
+		if err := setInstanceLocked(computeClient, d.Id(), false); err != nil {
+			return err
+		}
+	}
+
+	// With rebuild_on_image_change a new image or personality doesn't force
+	// a new instance, so the instance is rebuilt in place instead.
+	var rebuilt bool
+	if d.Get("rebuild_on_image_change").(bool) &&
+		(d.HasChange("image_id") || d.HasChange("image_name") || d.HasChange("personality")) {
+		if err := rebuildInstance(computeClient, d); err != nil {
+			return err
+		}
+		rebuilt = true
+	}
+
+	if d.HasChange("network") {
+		if err := updateInstanceNetworks(computeClient, d, meta); err != nil {
+			return err
+		}
+	}
+
 	var updateOpts servers.UpdateOpts
 	if d.HasChange("name") {
 		updateOpts.Name = d.Get("name").(string)
@@ -581,7 +799,8 @@ func resourceComputeInstanceV2Update(d *schema.ResourceData, meta interface{}) e
 		}
 	}
 
-	if d.HasChange("metadata") {
+	// A rebuild replaces all of the metadata unless there's none.
+	if d.HasChange("metadata") && !(rebuilt && len(resourceInstanceMetadataV2(d)) > 0) {
 		oldMetadata, newMetadata := d.GetChange("metadata")
 		var metadataToDelete []string
 
@@ -652,7 +871,7 @@ func resourceComputeInstanceV2Update(d *schema.ResourceData, meta interface{}) e
 		}
 	}
 
-	if d.HasChange("admin_pass") {
+	if d.HasChange("admin_pass") && !rebuilt {
 		if newPwd, ok := d.Get("admin_pass").(string); ok {
 			err := servers.ChangeAdminPassword(computeClient, d.Id(), newPwd).ExtractErr()
 			if err != nil {
@@ -661,6 +880,7 @@ func resourceComputeInstanceV2Update(d *schema.ResourceData, meta interface{}) e
 		}
 	}
 
+	var resized bool
 	if d.HasChange("flavor_id") || d.HasChange("flavor_name") {
 		var newFlavorId string
 		var err error
@@ -720,6 +940,22 @@ func resourceComputeInstanceV2Update(d *schema.ResourceData, meta interface{}) e
 		if err != nil {
 			return fmt.Errorf("Error waiting for instance (%s) to confirm resize: %s", d.Id(), err)
 		}
+		resized = true
+	}
+
+	// Resizing can change the power state as well, and rebuilding makes a
+	// suspended or paused instance active, so it's set again.
+	if d.HasChange("power_state") || resized || rebuilt {
+		err = setInstancePowerState(computeClient, d.Id(), d.Get("power_state").(string), d.Timeout(schema.TimeoutUpdate))
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.Get("locked").(bool) {
+		if err := setInstanceLocked(computeClient, d.Id(), true); err != nil {
+			return err
+		}
 	}
 
 	return resourceComputeInstanceV2Read(d, meta)
@@ -732,7 +968,13 @@ func resourceComputeInstanceV2Delete(d *schema.ResourceData, meta interface{}) e
 		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
 	}
 
-	if d.Get("stop_before_destroy").(bool) {
+	if d.Get("locked").(bool) {
+		if err := setInstanceLocked(computeClient, d.Id(), false); err != nil {
+			return err
+		}
+	}
+
+	if d.Get("stop_before_destroy").(bool) && d.Get("power_state").(string) == "active" {
 		err = startstop.Stop(computeClient, d.Id()).ExtractErr()
 		if err != nil {
 			log.Printf("[WARN] Error stopping TelefonicaOpenCloud instance: %s", err)
@@ -763,7 +1005,7 @@ func resourceComputeInstanceV2Delete(d *schema.ResourceData, meta interface{}) e
 	log.Printf("[DEBUG] Waiting for instance (%s) to delete", d.Id())
 
 	stateConf := &resource.StateChangeConf{
-		Pending:    []string{"ACTIVE", "SHUTOFF"},
+		Pending:    []string{"ACTIVE", "SHUTOFF", "SUSPENDED", "PAUSED"},
 		Target:     []string{"DELETED", "SOFT_DELETED"},
 		Refresh:    ServerV2StateRefreshFunc(computeClient, d.Id()),
 		Timeout:    d.Timeout(schema.TimeoutDelete),
@@ -782,6 +1024,104 @@ func resourceComputeInstanceV2Delete(d *schema.ResourceData, meta interface{}) e
 	return nil
 }
 
+// resourceComputeInstanceV2Diff diffs an instance. Network changes which
+// can be made live with attachinterfaces don't force a new instance. With
+// rebuild_on_image_change set, neither does a new image or personality,
+// which is applied by rebuilding the instance.
+func resourceComputeInstanceV2Diff(
+	s *terraform.InstanceState, c *terraform.ResourceConfig) (*terraform.InstanceDiff, error) {
+	r := resourceComputeInstanceV2()
+	if s == nil || s.ID == "" {
+		return r.Diff(s, c)
+	}
+
+	if v, ok := c.Get("rebuild_on_image_change"); ok {
+		if rebuild, _ := strconv.ParseBool(fmt.Sprint(v)); rebuild {
+			for _, k := range []string{"image_id", "image_name", "personality"} {
+				r.Schema[k].ForceNew = false
+			}
+		}
+	}
+
+	configured, hotPlug := getInstanceNetworkConfig(c)
+	if hotPlug {
+		current := expandInstanceNetworkList(r.Data(s).Get("network"))
+		_, _, hotPlug = planInstanceNetworkChanges(current, configured)
+	}
+	if hotPlug {
+		network := r.Schema["network"]
+		network.ForceNew = false
+		for _, k := range instanceNetworkKeys {
+			network.Elem.(*schema.Resource).Schema[k].ForceNew = false
+		}
+	}
+
+	diff, err := r.Diff(s, c)
+	if err != nil || diff == nil {
+		return diff, err
+	}
+
+	if hotPlug {
+		computeInstanceNetworkDiff(diff, s, configured)
+	}
+
+	return diff, nil
+}
+
+// rebuildInstance rebuilds an instance with its new image, personality,
+// metadata and admin password. Its ports and volumes are kept.
+func rebuildInstance(computeClient *gophercloud.ServiceClient, d *schema.ResourceData) error {
+	var imageId string
+	var err error
+	if d.HasChange("image_name") && !d.HasChange("image_id") {
+		// The image ID in the state is still the one of the old image.
+		imageId, err = images.IDFromName(computeClient, d.Get("image_name").(string))
+	} else {
+		imageId, err = getImageIDFromConfig(computeClient, d)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Nova only rebuilds active or stopped instances.
+	if powerState, _ := d.GetChange("power_state"); powerState == "suspended" || powerState == "paused" {
+		if err := setInstancePowerState(computeClient, d.Id(), "active", d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+	}
+
+	rebuildOpts := &servers.RebuildOpts{
+		ImageID:     imageId,
+		AdminPass:   d.Get("admin_pass").(string),
+		Metadata:    resourceInstanceMetadataV2(d),
+		Personality: resourceInstancePersonalityV2(d),
+	}
+	log.Printf("[DEBUG] Rebuild configuration: %#v", rebuildOpts)
+	_, err = servers.Rebuild(computeClient, d.Id(), rebuildOpts).Extract()
+	if err != nil {
+		return fmt.Errorf("Error rebuilding TelefonicaOpenCloud server: %s", err)
+	}
+
+	// Wait for the instance to finish rebuilding.
+	log.Printf("[DEBUG] Waiting for instance (%s) to finish rebuilding", d.Id())
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"REBUILD"},
+		Target:     []string{"ACTIVE", "SHUTOFF"},
+		Refresh:    ServerV2StateRefreshFunc(computeClient, d.Id()),
+		Timeout:    d.Timeout(schema.TimeoutUpdate),
+		Delay:      10 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	_, err = stateConf.WaitForState()
+	if err != nil {
+		return fmt.Errorf("Error waiting for instance (%s) to rebuild: %s", d.Id(), err)
+	}
+
+	return nil
+}
+
 // ServerV2StateRefreshFunc returns a resource.StateRefreshFunc that is used to watch
 // an TelefonicaOpenCloud instance.
 func ServerV2StateRefreshFunc(client *gophercloud.ServiceClient, instanceID string) resource.StateRefreshFunc {
@@ -951,7 +1291,7 @@ func setImageInformation(computeClient *gophercloud.ServiceClient, server *serve
 		}
 	}
 
-	imageId := server.Image["id"].(string)
+	imageId, _ := server.Image["id"].(string)
 	if imageId != "" {
 		d.Set("image_id", imageId)
 		if image, err := images.Get(computeClient, imageId).Extract(); err != nil {
@@ -3,6 +3,7 @@ package telefonicaopencloud
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
@@ -224,6 +225,202 @@ func TestAccComputeV2Instance_stopBeforeDestroy(t *testing.T) {
 	})
 }
 
+func TestAccComputeV2Instance_powerState(t *testing.T) {
+	var instance servers.Server
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InstanceDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2Instance_powerState_1,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance),
+					testAccCheckComputeV2InstanceStatus(&instance, "SHUTOFF"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "power_state", "shutoff"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "locked", "true"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_powerState_2,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance),
+					testAccCheckComputeV2InstanceStatus(&instance, "PAUSED"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "power_state", "paused"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_powerState_3,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance),
+					testAccCheckComputeV2InstanceStatus(&instance, "SUSPENDED"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "locked", "false"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_powerState_4,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance),
+					testAccCheckComputeV2InstanceStatus(&instance, "ACTIVE"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2Instance_powerStateDrift(t *testing.T) {
+	var instance servers.Server
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InstanceDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2Instance_powerState_4,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance),
+					testAccCheckComputeV2InstanceStop(&instance),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_powerState_4,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance),
+					testAccCheckComputeV2InstanceStatus(&instance, "ACTIVE"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2Instance_lockDrift(t *testing.T) {
+	var instance servers.Server
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InstanceDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2Instance_powerState_4,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance),
+					testAccCheckComputeV2InstanceLock(&instance),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_powerState_4,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "locked", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2Instance_rebuild(t *testing.T) {
+	var instance1, instance2, instance3 servers.Server
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InstanceDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2Instance_rebuild_1,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance1),
+					testAccCheckComputeV2InstanceMetadata(&instance1, "foo", "bar"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_rebuild_2,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance2),
+					testAccCheckComputeV2InstanceInstanceIDsMatch(&instance1, &instance2),
+					testAccCheckComputeV2InstanceMetadata(&instance2, "foo", "baz"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "network.0.fixed_ip_v4", "192.168.0.30"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "personality.#", "1"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_rebuild_3,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance2),
+					testAccCheckComputeV2InstanceStatus(&instance2, "SUSPENDED"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_rebuild_4,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance3),
+					testAccCheckComputeV2InstanceInstanceIDsMatch(&instance1, &instance3),
+					testAccCheckComputeV2InstanceStatus(&instance3, "SUSPENDED"),
+					testAccCheckComputeV2InstanceMetadata(&instance3, "foo", "qux"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "power_state", "suspended"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2Instance_networkHotPlug(t *testing.T) {
+	var instance1, instance2, instance3 servers.Server
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InstanceDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2Instance_networkHotPlug_1,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance1),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "network.#", "1"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_networkHotPlug_2,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance2),
+					testAccCheckComputeV2InstanceInstanceIDsMatch(&instance1, &instance2),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "network.#", "2"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "network.1.fixed_ip_v4", "192.168.199.23"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "access_ip_v4", "192.168.199.23"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Instance_networkHotPlug_3,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InstanceExists("telefonicaopencloud_compute_instance_v2.instance_1", &instance3),
+					testAccCheckComputeV2InstanceInstanceIDsMatch(&instance1, &instance3),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "network.#", "1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_instance_v2.instance_1", "network.0.fixed_ip_v4", "192.168.199.23"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccComputeV2Instance_metadataRemove(t *testing.T) {
 	var instance servers.Server
 
@@ -393,6 +590,41 @@ func testAccCheckComputeV2InstanceNoMetadataKey(
 	}
 }
 
+func testAccCheckComputeV2InstanceStatus(
+	instance *servers.Server, status string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if instance.Status != status {
+			return fmt.Errorf("Expected instance status %s, got %s", status, instance.Status)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckComputeV2InstanceStop(instance *servers.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config := testAccProvider.Meta().(*Config)
+		computeClient, err := config.computeV2Client(OS_REGION_NAME)
+		if err != nil {
+			return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+		}
+
+		return setInstancePowerState(computeClient, instance.ID, "shutoff", 3*time.Minute)
+	}
+}
+
+func testAccCheckComputeV2InstanceLock(instance *servers.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config := testAccProvider.Meta().(*Config)
+		computeClient, err := config.computeV2Client(OS_REGION_NAME)
+		if err != nil {
+			return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+		}
+
+		return setInstanceLocked(computeClient, instance.ID, true)
+	}
+}
+
 func testAccCheckComputeV2InstanceBootVolumeAttachment(
 	instance *servers.Server) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -435,6 +667,17 @@ func testAccCheckComputeV2InstanceInstanceIDsDoNotMatch(
 	}
 }
 
+func testAccCheckComputeV2InstanceInstanceIDsMatch(
+	instance1, instance2 *servers.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if instance1.ID != instance2.ID {
+			return fmt.Errorf("Instance was recreated.")
+		}
+
+		return nil
+	}
+}
+
 var testAccComputeV2Instance_basic = fmt.Sprintf(`
 resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
   name = "instance_1"
@@ -629,6 +872,163 @@ resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
 }
 `, OS_NETWORK_ID)
 
+var testAccComputeV2Instance_rebuild_1 = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  image_id = "%s"
+  rebuild_on_image_change = true
+  metadata {
+    foo = "bar"
+  }
+  personality {
+    file = "/tmp/foobar.txt"
+    content = "happy"
+  }
+  network {
+    uuid = "%s"
+    fixed_ip_v4 = "192.168.0.30"
+  }
+}
+`, OS_IMAGE_ID, OS_NETWORK_ID)
+
+var testAccComputeV2Instance_rebuild_2 = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  image_id = "%s"
+  rebuild_on_image_change = true
+  metadata {
+    foo = "baz"
+  }
+  personality {
+    file = "/tmp/foobar.txt"
+    content = "angry"
+  }
+  network {
+    uuid = "%s"
+    fixed_ip_v4 = "192.168.0.30"
+  }
+}
+`, OS_IMAGE_ID, OS_NETWORK_ID)
+
+var testAccComputeV2Instance_rebuild_3 = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  image_id = "%s"
+  rebuild_on_image_change = true
+  power_state = "suspended"
+  metadata {
+    foo = "baz"
+  }
+  personality {
+    file = "/tmp/foobar.txt"
+    content = "angry"
+  }
+  network {
+    uuid = "%s"
+    fixed_ip_v4 = "192.168.0.30"
+  }
+}
+`, OS_IMAGE_ID, OS_NETWORK_ID)
+
+var testAccComputeV2Instance_rebuild_4 = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  image_id = "%s"
+  rebuild_on_image_change = true
+  power_state = "suspended"
+  metadata {
+    foo = "qux"
+  }
+  personality {
+    file = "/tmp/foobaz.txt"
+    content = "calm"
+  }
+  network {
+    uuid = "%s"
+    fixed_ip_v4 = "192.168.0.30"
+  }
+}
+`, OS_IMAGE_ID, OS_NETWORK_ID)
+
+const testAccComputeV2Instance_networkHotPlugNetwork = `
+resource "telefonicaopencloud_networking_network_v2" "network_1" {
+  name = "network_1"
+}
+
+resource "telefonicaopencloud_networking_subnet_v2" "subnet_1" {
+  name = "subnet_1"
+  cidr = "192.168.199.0/24"
+  ip_version = 4
+  network_id = "${telefonicaopencloud_networking_network_v2.network_1.id}"
+}
+`
+
+var testAccComputeV2Instance_networkHotPlug_1 = fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  depends_on = ["telefonicaopencloud_networking_subnet_v2.subnet_1"]
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "%s"
+  }
+}
+`, testAccComputeV2Instance_networkHotPlugNetwork, OS_NETWORK_ID)
+
+var testAccComputeV2Instance_networkHotPlug_2 = fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  depends_on = ["telefonicaopencloud_networking_subnet_v2.subnet_1"]
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "%s"
+  }
+  network {
+    uuid = "${telefonicaopencloud_networking_network_v2.network_1.id}"
+    fixed_ip_v4 = "192.168.199.23"
+    access_network = true
+  }
+}
+`, testAccComputeV2Instance_networkHotPlugNetwork, OS_NETWORK_ID)
+
+var testAccComputeV2Instance_networkHotPlug_3 = fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  depends_on = ["telefonicaopencloud_networking_subnet_v2.subnet_1"]
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "${telefonicaopencloud_networking_network_v2.network_1.id}"
+    fixed_ip_v4 = "192.168.199.23"
+    access_network = true
+  }
+}
+`, testAccComputeV2Instance_networkHotPlugNetwork)
+
+var testAccComputeV2Instance_multipleNetworks = fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  depends_on = ["telefonicaopencloud_networking_subnet_v2.subnet_1"]
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "${telefonicaopencloud_networking_network_v2.network_1.id}"
+  }
+  network {
+    uuid = "%s"
+  }
+}
+`, testAccComputeV2Instance_networkHotPlugNetwork, OS_NETWORK_ID)
+
 var testAccComputeV2Instance_changeFixedIP_1 = fmt.Sprintf(`
 resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
   name = "instance_1"
@@ -662,6 +1062,52 @@ resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
 }
 `, OS_NETWORK_ID)
 
+var testAccComputeV2Instance_powerState_1 = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  power_state = "shutoff"
+  locked = true
+  network {
+    uuid = "%s"
+  }
+}
+`, OS_NETWORK_ID)
+
+var testAccComputeV2Instance_powerState_2 = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  power_state = "paused"
+  locked = true
+  network {
+    uuid = "%s"
+  }
+}
+`, OS_NETWORK_ID)
+
+var testAccComputeV2Instance_powerState_3 = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  power_state = "suspended"
+  network {
+    uuid = "%s"
+  }
+}
+`, OS_NETWORK_ID)
+
+var testAccComputeV2Instance_powerState_4 = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  power_state = "active"
+  network {
+    uuid = "%s"
+  }
+}
+`, OS_NETWORK_ID)
+
 var testAccComputeV2Instance_metadataRemove_1 = fmt.Sprintf(`
 resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
   name = "instance_1"
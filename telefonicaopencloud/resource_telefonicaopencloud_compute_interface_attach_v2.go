@@ -0,0 +1,227 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/attachinterfaces"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceComputeInterfaceAttachV2() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceComputeInterfaceAttachV2Create,
+		Read:   resourceComputeInterfaceAttachV2Read,
+		Delete: resourceComputeInterfaceAttachV2Delete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"instance_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"port_id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"network_id", "fixed_ip"},
+			},
+
+			"network_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"fixed_ip": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func resourceComputeInterfaceAttachV2Create(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	instanceId := d.Get("instance_id").(string)
+	portId := d.Get("port_id").(string)
+	networkId := d.Get("network_id").(string)
+
+	if portId == "" && networkId == "" {
+		return fmt.Errorf("One of port_id or network_id must be set")
+	}
+
+	attachOpts := attachinterfaces.CreateOpts{
+		PortID:    portId,
+		NetworkID: networkId,
+	}
+
+	if v, ok := d.GetOk("fixed_ip"); ok {
+		attachOpts.FixedIPs = []attachinterfaces.FixedIP{
+			{IPAddress: v.(string)},
+		}
+	}
+
+	log.Printf("[DEBUG] Creating interface attachment: %#v", attachOpts)
+
+	attachment, err := attachinterfaces.Create(computeClient, instanceId, attachOpts).Extract()
+	if err != nil {
+		return fmt.Errorf("Error attaching interface to TelefonicaOpenCloud server (%s): %s", instanceId, err)
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"BUILD", "DOWN"},
+		Target:     []string{"ACTIVE"},
+		Refresh:    InterfaceAttachV2StateRefreshFunc(computeClient, instanceId, attachment.PortID),
+		Timeout:    d.Timeout(schema.TimeoutCreate),
+		Delay:      5 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, err = stateConf.WaitForState(); err != nil {
+		return fmt.Errorf("Error waiting for port (%s) to attach to instance (%s): %s", attachment.PortID, instanceId, err)
+	}
+
+	log.Printf("[DEBUG] Created interface attachment: %#v", attachment)
+
+	// Use the instance ID and port ID as the resource ID.
+	// This is because an interface attachment is looked up by both.
+	id := fmt.Sprintf("%s/%s", instanceId, attachment.PortID)
+
+	d.SetId(id)
+
+	return resourceComputeInterfaceAttachV2Read(d, meta)
+}
+
+func resourceComputeInterfaceAttachV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	instanceId, portId, err := parseComputeInterfaceAttachmentId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	attachment, err := attachinterfaces.Get(computeClient, instanceId, portId).Extract()
+	if err != nil {
+		return CheckDeleted(d, err, "compute_interface_attach")
+	}
+
+	log.Printf("[DEBUG] Retrieved interface attachment: %#v", attachment)
+
+	d.Set("instance_id", instanceId)
+	d.Set("port_id", attachment.PortID)
+	d.Set("network_id", attachment.NetID)
+	d.Set("region", GetRegion(d, config))
+
+	if len(attachment.FixedIPs) > 0 {
+		d.Set("fixed_ip", attachment.FixedIPs[0].IPAddress)
+	}
+
+	return nil
+}
+
+func resourceComputeInterfaceAttachV2Delete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	instanceId, portId, err := parseComputeInterfaceAttachmentId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{""},
+		Target:     []string{"DETACHED"},
+		Refresh:    resourceComputeInterfaceAttachV2DetachFunc(computeClient, instanceId, portId),
+		Timeout:    d.Timeout(schema.TimeoutDelete),
+		Delay:      5 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, err = stateConf.WaitForState(); err != nil {
+		return fmt.Errorf("Error detaching TelefonicaOpenCloud port (%s) from instance (%s): %s", portId, instanceId, err)
+	}
+
+	return nil
+}
+
+func resourceComputeInterfaceAttachV2DetachFunc(
+	computeClient *gophercloud.ServiceClient, instanceId, portId string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		log.Printf("[DEBUG] Attempting to detach TelefonicaOpenCloud port %s from instance %s",
+			portId, instanceId)
+
+		attachment, err := attachinterfaces.Get(computeClient, instanceId, portId).Extract()
+		if err != nil {
+			if _, ok := err.(gophercloud.ErrDefault404); ok {
+				return attachment, "DETACHED", nil
+			}
+			return attachment, "", err
+		}
+
+		err = attachinterfaces.Delete(computeClient, instanceId, portId).ExtractErr()
+		if err != nil {
+			if _, ok := err.(gophercloud.ErrDefault404); ok {
+				return attachment, "DETACHED", nil
+			}
+
+			if _, ok := err.(gophercloud.ErrDefault400); ok {
+				return nil, "", nil
+			}
+
+			return nil, "", err
+		}
+
+		log.Printf("[DEBUG] TelefonicaOpenCloud Interface Attachment (%s) is still active.", portId)
+		return nil, "", nil
+	}
+}
+
+func parseComputeInterfaceAttachmentId(id string) (string, string, error) {
+	idParts := strings.Split(id, "/")
+	if len(idParts) < 2 {
+		return "", "", fmt.Errorf("Unable to determine interface attachment ID")
+	}
+
+	instanceId := idParts[0]
+	portId := idParts[1]
+
+	return instanceId, portId, nil
+}
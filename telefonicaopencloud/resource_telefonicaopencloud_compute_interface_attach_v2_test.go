@@ -0,0 +1,213 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/attachinterfaces"
+)
+
+func TestAccComputeV2InterfaceAttach_basic(t *testing.T) {
+	var ai attachinterfaces.Interface
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InterfaceAttachDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2InterfaceAttach_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InterfaceAttachExists("telefonicaopencloud_compute_interface_attach_v2.ai_1", &ai),
+					resource.TestCheckResourceAttrPair(
+						"telefonicaopencloud_compute_interface_attach_v2.ai_1", "port_id",
+						"telefonicaopencloud_networking_port_v2.port_1", "id"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2InterfaceAttach_IP(t *testing.T) {
+	var ai attachinterfaces.Interface
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InterfaceAttachDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2InterfaceAttach_IP,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InterfaceAttachExists("telefonicaopencloud_compute_interface_attach_v2.ai_1", &ai),
+					testAccCheckComputeV2InterfaceAttachIP(&ai, "192.168.199.100"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccComputeV2InterfaceAttach_timeout(t *testing.T) {
+	var ai attachinterfaces.Interface
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2InterfaceAttachDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2InterfaceAttach_timeout,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2InterfaceAttachExists("telefonicaopencloud_compute_interface_attach_v2.ai_1", &ai),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckComputeV2InterfaceAttachDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Config)
+	computeClient, err := config.computeV2Client(OS_REGION_NAME)
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "telefonicaopencloud_compute_interface_attach_v2" {
+			continue
+		}
+
+		instanceId, portId, err := parseComputeInterfaceAttachmentId(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = attachinterfaces.Get(computeClient, instanceId, portId).Extract()
+		if err == nil {
+			return fmt.Errorf("Interface attachment still exists")
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckComputeV2InterfaceAttachExists(n string, ai *attachinterfaces.Interface) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		config := testAccProvider.Meta().(*Config)
+		computeClient, err := config.computeV2Client(OS_REGION_NAME)
+		if err != nil {
+			return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+		}
+
+		instanceId, portId, err := parseComputeInterfaceAttachmentId(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		found, err := attachinterfaces.Get(computeClient, instanceId, portId).Extract()
+		if err != nil {
+			return err
+		}
+
+		if found.PortID != portId {
+			return fmt.Errorf("InterfaceAttach not found")
+		}
+
+		*ai = *found
+
+		return nil
+	}
+}
+
+func testAccCheckComputeV2InterfaceAttachIP(
+	ai *attachinterfaces.Interface, ip string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, i := range ai.FixedIPs {
+			if i.IPAddress == ip {
+				return nil
+			}
+		}
+		return fmt.Errorf("Requested IP address (%s) was not attached to port", ip)
+	}
+}
+
+var testAccComputeV2InterfaceAttach_basic = fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_networking_port_v2" "port_1" {
+  name = "port_1"
+  admin_state_up = "true"
+  network_id = "${telefonicaopencloud_networking_network_v2.network_1.id}"
+
+  fixed_ip {
+    subnet_id =  "${telefonicaopencloud_networking_subnet_v2.subnet_1.id}"
+  }
+}
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "%s"
+  }
+}
+
+resource "telefonicaopencloud_compute_interface_attach_v2" "ai_1" {
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+  port_id = "${telefonicaopencloud_networking_port_v2.port_1.id}"
+}
+`, testAccComputeV2Instance_networkHotPlugNetwork, OS_NETWORK_ID)
+
+var testAccComputeV2InterfaceAttach_IP = fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "%s"
+  }
+}
+
+resource "telefonicaopencloud_compute_interface_attach_v2" "ai_1" {
+  depends_on = ["telefonicaopencloud_networking_subnet_v2.subnet_1"]
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+  network_id = "${telefonicaopencloud_networking_network_v2.network_1.id}"
+  fixed_ip = "192.168.199.100"
+}
+`, testAccComputeV2Instance_networkHotPlugNetwork, OS_NETWORK_ID)
+
+var testAccComputeV2InterfaceAttach_timeout = fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_compute_instance_v2" "instance_1" {
+  name = "instance_1"
+  security_groups = ["default"]
+  network {
+    uuid = "%s"
+  }
+}
+
+resource "telefonicaopencloud_compute_interface_attach_v2" "ai_1" {
+  depends_on = ["telefonicaopencloud_networking_subnet_v2.subnet_1"]
+  instance_id = "${telefonicaopencloud_compute_instance_v2.instance_1.id}"
+  network_id = "${telefonicaopencloud_networking_network_v2.network_1.id}"
+
+  timeouts {
+    create = "5m"
+    delete = "5m"
+  }
+}
+`, testAccComputeV2Instance_networkHotPlugNetwork, OS_NETWORK_ID)
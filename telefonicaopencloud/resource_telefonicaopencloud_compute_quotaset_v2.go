@@ -0,0 +1,188 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/quotasets"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// computeQuotasetV2Fields are the quotas the resource manages, by their
+// Nova names.
+var computeQuotasetV2Fields = []string{
+	"instances", "cores", "ram", "key_pairs", "server_groups", "server_group_members",
+	"floating_ips", "fixed_ips", "security_groups", "security_group_rules",
+	"metadata_items", "injected_files", "injected_file_content_bytes", "injected_file_path_bytes",
+}
+
+func resourceComputeQuotasetV2() *schema.Resource {
+	quotaSchema := map[string]*schema.Schema{
+		"region": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Computed: true,
+			ForceNew: true,
+		},
+
+		"project_id": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+	}
+
+	for _, k := range computeQuotasetV2Fields {
+		quotaSchema[k] = &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: resourceComputeQuotasetV2ValidateQuota,
+		}
+	}
+
+	return &schema.Resource{
+		Create: resourceComputeQuotasetV2Create,
+		Read:   resourceComputeQuotasetV2Read,
+		Update: resourceComputeQuotasetV2Update,
+		Delete: resourceComputeQuotasetV2Delete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: quotaSchema,
+	}
+}
+
+func resourceComputeQuotasetV2Create(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	projectId := d.Get("project_id").(string)
+	updateOpts := resourceComputeQuotasetV2UpdateOpts(d, false)
+
+	log.Printf("[DEBUG] Setting compute quotas of project %s: %#v", projectId, updateOpts)
+	if _, err := quotasets.Update(computeClient, projectId, updateOpts).Extract(); err != nil {
+		return fmt.Errorf("Error setting compute quotas of project %s: %s", projectId, err)
+	}
+
+	d.SetId(projectId)
+
+	return resourceComputeQuotasetV2Read(d, meta)
+}
+
+func resourceComputeQuotasetV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	q, err := quotasets.Get(computeClient, d.Id()).Extract()
+	if err != nil {
+		return CheckDeleted(d, err, "compute_quotaset")
+	}
+
+	log.Printf("[DEBUG] Retrieved compute quotas of project %s: %#v", d.Id(), q)
+
+	d.Set("project_id", d.Id())
+	d.Set("instances", q.Instances)
+	d.Set("cores", q.Cores)
+	d.Set("ram", q.RAM)
+	d.Set("key_pairs", q.KeyPairs)
+	d.Set("server_groups", q.ServerGroups)
+	d.Set("server_group_members", q.ServerGroupMembers)
+	d.Set("floating_ips", q.FloatingIPs)
+	d.Set("fixed_ips", q.FixedIPs)
+	d.Set("security_groups", q.SecurityGroups)
+	d.Set("security_group_rules", q.SecurityGroupRules)
+	d.Set("metadata_items", q.MetadataItems)
+	d.Set("injected_files", q.InjectedFiles)
+	d.Set("injected_file_content_bytes", q.InjectedFileContentBytes)
+	d.Set("injected_file_path_bytes", q.InjectedFilePathBytes)
+	d.Set("region", GetRegion(d, config))
+
+	return nil
+}
+
+func resourceComputeQuotasetV2Update(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	updateOpts := resourceComputeQuotasetV2UpdateOpts(d, true)
+
+	log.Printf("[DEBUG] Updating compute quotas of project %s: %#v", d.Id(), updateOpts)
+	if _, err := quotasets.Update(computeClient, d.Id(), updateOpts).Extract(); err != nil {
+		return fmt.Errorf("Error updating compute quotas of project %s: %s", d.Id(), err)
+	}
+
+	return resourceComputeQuotasetV2Read(d, meta)
+}
+
+// resourceComputeQuotasetV2Delete reverts the quotas of the project to the
+// defaults of the cloud.
+func resourceComputeQuotasetV2Delete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	computeClient, err := config.computeV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+	}
+
+	log.Printf("[DEBUG] Reverting compute quotas of project %s", d.Id())
+	if err := quotasets.Delete(computeClient, d.Id()).Err; err != nil {
+		return CheckDeleted(d, err, "compute_quotaset")
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceComputeQuotasetV2UpdateOpts returns the quotas to set, which are
+// the configured ones, or only the changed ones on update. Quotas of 0 are
+// valid, so they are sent as pointers.
+func resourceComputeQuotasetV2UpdateOpts(d *schema.ResourceData, changedOnly bool) quotasets.UpdateOpts {
+	var updateOpts quotasets.UpdateOpts
+	fields := map[string]**int{
+		"instances":                   &updateOpts.Instances,
+		"cores":                       &updateOpts.Cores,
+		"ram":                         &updateOpts.RAM,
+		"key_pairs":                   &updateOpts.KeyPairs,
+		"server_groups":               &updateOpts.ServerGroups,
+		"server_group_members":        &updateOpts.ServerGroupMembers,
+		"floating_ips":                &updateOpts.FloatingIPs,
+		"fixed_ips":                   &updateOpts.FixedIPs,
+		"security_groups":             &updateOpts.SecurityGroups,
+		"security_group_rules":        &updateOpts.SecurityGroupRules,
+		"metadata_items":              &updateOpts.MetadataItems,
+		"injected_files":              &updateOpts.InjectedFiles,
+		"injected_file_content_bytes": &updateOpts.InjectedFileContentBytes,
+		"injected_file_path_bytes":    &updateOpts.InjectedFilePathBytes,
+	}
+
+	for k, field := range fields {
+		if changedOnly && !d.HasChange(k) {
+			continue
+		}
+		if v, ok := d.GetOkExists(k); ok {
+			quota := v.(int)
+			*field = &quota
+		}
+	}
+
+	return updateOpts
+}
+
+// resourceComputeQuotasetV2ValidateQuota allows -1, which means unlimited.
+func resourceComputeQuotasetV2ValidateQuota(v interface{}, k string) (ws []string, errors []error) {
+	if v.(int) < -1 {
+		errors = append(errors, fmt.Errorf("%q must be -1 (unlimited) or more, got %d", k, v.(int)))
+	}
+	return
+}
@@ -0,0 +1,124 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/quotasets"
+)
+
+func TestAccComputeV2Quotaset_basic(t *testing.T) {
+	var quotaset quotasets.QuotaSet
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testAccPreCheckAdminOnly(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckComputeV2QuotasetDestroy(&quotaset),
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccComputeV2Quotaset_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2QuotasetExists("telefonicaopencloud_compute_quotaset_v2.quotaset_1", &quotaset),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_quotaset_v2.quotaset_1", "instances", "42"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_quotaset_v2.quotaset_1", "cores", "84"),
+					resource.TestCheckResourceAttrSet(
+						"telefonicaopencloud_compute_quotaset_v2.quotaset_1", "ram"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccComputeV2Quotaset_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckComputeV2QuotasetExists("telefonicaopencloud_compute_quotaset_v2.quotaset_1", &quotaset),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_quotaset_v2.quotaset_1", "instances", "42"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_quotaset_v2.quotaset_1", "cores", "-1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_compute_quotaset_v2.quotaset_1", "key_pairs", "0"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckComputeV2QuotasetDestroy checks that the quotas were reverted,
+// since a project always has quotas.
+func testAccCheckComputeV2QuotasetDestroy(quotaset *quotasets.QuotaSet) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config := testAccProvider.Meta().(*Config)
+		computeClient, err := config.computeV2Client(OS_REGION_NAME)
+		if err != nil {
+			return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+		}
+
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "telefonicaopencloud_compute_quotaset_v2" {
+				continue
+			}
+
+			q, err := quotasets.Get(computeClient, rs.Primary.ID).Extract()
+			if err != nil {
+				return err
+			}
+
+			if q.Instances == quotaset.Instances {
+				return fmt.Errorf("Compute quotas still set")
+			}
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckComputeV2QuotasetExists(n string, quotaset *quotasets.QuotaSet) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		config := testAccProvider.Meta().(*Config)
+		computeClient, err := config.computeV2Client(OS_REGION_NAME)
+		if err != nil {
+			return fmt.Errorf("Error creating TelefonicaOpenCloud compute client: %s", err)
+		}
+
+		found, err := quotasets.Get(computeClient, rs.Primary.ID).Extract()
+		if err != nil {
+			return err
+		}
+
+		*quotaset = *found
+
+		return nil
+	}
+}
+
+var testAccComputeV2Quotaset_basic = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_quotaset_v2" "quotaset_1" {
+  project_id = "%s"
+  instances = 42
+  cores = 84
+}
+`, OS_TENANT_ID)
+
+var testAccComputeV2Quotaset_update = fmt.Sprintf(`
+resource "telefonicaopencloud_compute_quotaset_v2" "quotaset_1" {
+  project_id = "%s"
+  instances = 42
+  cores = -1
+  key_pairs = 0
+}
+`, OS_TENANT_ID)
@@ -0,0 +1,200 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/members"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceImagesImageAccessAcceptV2() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceImagesImageAccessAcceptV2Create,
+		Read:   resourceImagesImageAccessAcceptV2Read,
+		Update: resourceImagesImageAccessAcceptV2Update,
+		Delete: resourceImagesImageAccessAcceptV2Delete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"image_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"member_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"status": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: resourceImagesImageAccessV2ValidateStatus,
+			},
+
+			"created_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"updated_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"schema": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceImagesImageAccessAcceptV2Create(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	imageId := d.Get("image_id").(string)
+	memberId := d.Get("member_id").(string)
+	if memberId == "" {
+		memberId, err = resourceImagesImageAccessAcceptV2DetectMemberId(imageClient, imageId)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The membership is created by the owner of the image, it can only be
+	// answered here.
+	updateOpts := members.UpdateOpts{
+		Status: d.Get("status").(string),
+	}
+	log.Printf("[DEBUG] Setting the status of member %s of image %s: %#v", memberId, imageId, updateOpts)
+	if _, err := members.Update(imageClient, imageId, memberId, updateOpts).Extract(); err != nil {
+		return fmt.Errorf("Error setting the status of member %s of image %s: %s", memberId, imageId, err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", imageId, memberId))
+
+	return resourceImagesImageAccessAcceptV2Read(d, meta)
+}
+
+func resourceImagesImageAccessAcceptV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	imageId, memberId, err := parseImagesImageAccessId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	member, err := members.Get(imageClient, imageId, memberId).Extract()
+	if err != nil {
+		return CheckDeleted(d, err, "image_access_accept")
+	}
+
+	log.Printf("[DEBUG] Retrieved image member: %#v", member)
+
+	d.Set("image_id", member.ImageID)
+	d.Set("member_id", member.MemberID)
+	d.Set("status", member.Status)
+	d.Set("schema", member.Schema)
+	d.Set("created_at", member.CreatedAt.Format(time.RFC3339))
+	d.Set("updated_at", member.UpdatedAt.Format(time.RFC3339))
+	d.Set("region", GetRegion(d, config))
+
+	return nil
+}
+
+func resourceImagesImageAccessAcceptV2Update(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	imageId, memberId, err := parseImagesImageAccessId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	if d.HasChange("status") {
+		updateOpts := members.UpdateOpts{
+			Status: d.Get("status").(string),
+		}
+		if _, err := members.Update(imageClient, imageId, memberId, updateOpts).Extract(); err != nil {
+			return fmt.Errorf("Error setting the status of member %s of image %s: %s", memberId, imageId, err)
+		}
+	}
+
+	return resourceImagesImageAccessAcceptV2Read(d, meta)
+}
+
+// resourceImagesImageAccessAcceptV2Delete rejects the image, since only its
+// owner can remove the membership.
+func resourceImagesImageAccessAcceptV2Delete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	imageId, memberId, err := parseImagesImageAccessId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	updateOpts := members.UpdateOpts{
+		Status: "rejected",
+	}
+	log.Printf("[DEBUG] Rejecting image %s for member %s", imageId, memberId)
+	if _, err := members.Update(imageClient, imageId, memberId, updateOpts).Extract(); err != nil {
+		return CheckDeleted(d, err, "image_access_accept")
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceImagesImageAccessAcceptV2DetectMemberId returns the member ID of
+// the current project. Glance only lists a project's own membership of an
+// image that it doesn't own.
+func resourceImagesImageAccessAcceptV2DetectMemberId(imageClient *gophercloud.ServiceClient, imageId string) (string, error) {
+	pages, err := members.List(imageClient, imageId).AllPages()
+	if err != nil {
+		return "", fmt.Errorf("Unable to retrieve the members of image %s: %s", imageId, err)
+	}
+
+	allMembers, err := members.ExtractMembers(pages)
+	if err != nil {
+		return "", fmt.Errorf("Unable to extract the members of image %s: %s", imageId, err)
+	}
+
+	if len(allMembers) != 1 {
+		return "", fmt.Errorf("Unable to determine the member ID of image %s, found %d members. "+
+			"Please set member_id.", imageId, len(allMembers))
+	}
+
+	return allMembers[0].MemberID, nil
+}
@@ -0,0 +1,67 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/members"
+)
+
+func TestAccImagesImageAccessAcceptV2_basic(t *testing.T) {
+	var member members.Member
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageAccessV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageAccessAcceptV2_basic(imageFile, "accepted"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageAccessV2Exists("telefonicaopencloud_images_image_access_accept_v2.accept_1", &member),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_access_accept_v2.accept_1", "member_id", OS_TENANT_ID),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_access_accept_v2.accept_1", "status", "accepted"),
+					testAccCheckImagesImageAccessAcceptV2Status(&member, "accepted"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccImagesImageAccessAcceptV2_basic(imageFile, "rejected"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageAccessV2Exists("telefonicaopencloud_images_image_access_accept_v2.accept_1", &member),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_access_accept_v2.accept_1", "status", "rejected"),
+					testAccCheckImagesImageAccessAcceptV2Status(&member, "rejected"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckImagesImageAccessAcceptV2Status(member *members.Member, status string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if member.Status != status {
+			return fmt.Errorf("Image member status is %s, not %s", member.Status, status)
+		}
+
+		return nil
+	}
+}
+
+func testAccImagesImageAccessAcceptV2_basic(imageFile, status string) string {
+	return fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_images_image_access_accept_v2" "accept_1" {
+  image_id = "${telefonicaopencloud_images_image_access_v2.access_1.image_id}"
+  status = "%s"
+}
+`, testAccImagesImageAccessV2_basic(imageFile), status)
+}
@@ -0,0 +1,192 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/members"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceImagesImageAccessV2() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceImagesImageAccessV2Create,
+		Read:   resourceImagesImageAccessV2Read,
+		Update: resourceImagesImageAccessV2Update,
+		Delete: resourceImagesImageAccessV2Delete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"image_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"member_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"status": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: resourceImagesImageAccessV2ValidateStatus,
+			},
+
+			"created_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"updated_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"schema": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceImagesImageAccessV2Create(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	imageId := d.Get("image_id").(string)
+	memberId := d.Get("member_id").(string)
+
+	log.Printf("[DEBUG] Sharing image %s with member %s", imageId, memberId)
+	member, err := members.Create(imageClient, imageId, memberId).Extract()
+	if err != nil {
+		return fmt.Errorf("Error sharing TelefonicaOpenCloud image %s with member %s: %s", imageId, memberId, err)
+	}
+
+	// Use the image ID and member ID as the resource ID.
+	// This is because a member is looked up by both.
+	d.SetId(fmt.Sprintf("%s/%s", imageId, memberId))
+
+	// Only the member itself, or an admin, can set the status of the
+	// membership, so it's left as created unless asked for.
+	if v, ok := d.GetOk("status"); ok && v.(string) != member.Status {
+		updateOpts := members.UpdateOpts{
+			Status: v.(string),
+		}
+		if _, err := members.Update(imageClient, imageId, memberId, updateOpts).Extract(); err != nil {
+			return fmt.Errorf("Error setting the status of member %s of image %s: %s", memberId, imageId, err)
+		}
+	}
+
+	return resourceImagesImageAccessV2Read(d, meta)
+}
+
+func resourceImagesImageAccessV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	imageId, memberId, err := parseImagesImageAccessId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	member, err := members.Get(imageClient, imageId, memberId).Extract()
+	if err != nil {
+		return CheckDeleted(d, err, "image_access")
+	}
+
+	log.Printf("[DEBUG] Retrieved image member: %#v", member)
+
+	d.Set("image_id", member.ImageID)
+	d.Set("member_id", member.MemberID)
+	d.Set("status", member.Status)
+	d.Set("schema", member.Schema)
+	d.Set("created_at", member.CreatedAt.Format(time.RFC3339))
+	d.Set("updated_at", member.UpdatedAt.Format(time.RFC3339))
+	d.Set("region", GetRegion(d, config))
+
+	return nil
+}
+
+func resourceImagesImageAccessV2Update(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	imageId, memberId, err := parseImagesImageAccessId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	if d.HasChange("status") {
+		updateOpts := members.UpdateOpts{
+			Status: d.Get("status").(string),
+		}
+		if _, err := members.Update(imageClient, imageId, memberId, updateOpts).Extract(); err != nil {
+			return fmt.Errorf("Error setting the status of member %s of image %s: %s", memberId, imageId, err)
+		}
+	}
+
+	return resourceImagesImageAccessV2Read(d, meta)
+}
+
+func resourceImagesImageAccessV2Delete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	imageId, memberId, err := parseImagesImageAccessId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Removing member %s from image %s", memberId, imageId)
+	if err := members.Delete(imageClient, imageId, memberId).ExtractErr(); err != nil {
+		return CheckDeleted(d, err, "image_access")
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func parseImagesImageAccessId(id string) (string, string, error) {
+	idParts := strings.Split(id, "/")
+	if len(idParts) < 2 {
+		return "", "", fmt.Errorf("Unable to determine image member ID")
+	}
+
+	imageId := idParts[0]
+	memberId := idParts[1]
+
+	return imageId, memberId, nil
+}
+
+func resourceImagesImageAccessV2ValidateStatus(v interface{}, k string) (ws []string, errors []error) {
+	return ValidateStringList(v, k, []string{"pending", "accepted", "rejected"})
+}
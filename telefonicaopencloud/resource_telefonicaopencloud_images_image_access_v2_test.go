@@ -0,0 +1,157 @@
+package telefonicaopencloud
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/members"
+)
+
+func TestAccImagesImageAccessV2_basic(t *testing.T) {
+	var member members.Member
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageAccessV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageAccessV2_basic(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageAccessV2Exists("telefonicaopencloud_images_image_access_v2.access_1", &member),
+					resource.TestCheckResourceAttrPair(
+						"telefonicaopencloud_images_image_access_v2.access_1", "image_id",
+						"telefonicaopencloud_images_image_v2.image_1", "id"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_access_v2.access_1", "member_id", OS_TENANT_ID),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_access_v2.access_1", "status", "pending"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImagesImageAccessV2_update(t *testing.T) {
+	var member members.Member
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageAccessV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageAccessV2_update(imageFile, "accepted"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageAccessV2Exists("telefonicaopencloud_images_image_access_v2.access_1", &member),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_access_v2.access_1", "status", "accepted"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccImagesImageAccessV2_update(imageFile, "rejected"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageAccessV2Exists("telefonicaopencloud_images_image_access_v2.access_1", &member),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_access_v2.access_1", "status", "rejected"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckImagesImageAccessV2Destroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Config)
+	imageClient, err := config.imageV2Client(OS_REGION_NAME)
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "telefonicaopencloud_images_image_access_v2" {
+			continue
+		}
+
+		imageId, memberId, err := parseImagesImageAccessId(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = members.Get(imageClient, imageId, memberId).Extract()
+		if err == nil {
+			return fmt.Errorf("Image member still exists")
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckImagesImageAccessV2Exists(n string, member *members.Member) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		config := testAccProvider.Meta().(*Config)
+		imageClient, err := config.imageV2Client(OS_REGION_NAME)
+		if err != nil {
+			return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+		}
+
+		imageId, memberId, err := parseImagesImageAccessId(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		found, err := members.Get(imageClient, imageId, memberId).Extract()
+		if err != nil {
+			return err
+		}
+
+		if found.ImageID != imageId || found.MemberID != memberId {
+			return fmt.Errorf("Image member not found")
+		}
+
+		*member = *found
+
+		return nil
+	}
+}
+
+// The image is shared with the project running the tests, so that it can
+// answer the membership too.
+func testAccImagesImageAccessV2_basic(imageFile string) string {
+	return fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_images_image_access_v2" "access_1" {
+  image_id = "${telefonicaopencloud_images_image_v2.image_1.id}"
+  member_id = "%s"
+}
+`, testAccImagesImageV2_basic(imageFile), OS_TENANT_ID)
+}
+
+func testAccImagesImageAccessV2_update(imageFile, status string) string {
+	return fmt.Sprintf(`
+%s
+
+resource "telefonicaopencloud_images_image_access_v2" "access_1" {
+  image_id = "${telefonicaopencloud_images_image_v2.image_1.id}"
+  member_id = "%s"
+  status = "%s"
+}
+`, testAccImagesImageV2_basic(imageFile), OS_TENANT_ID, status)
+}
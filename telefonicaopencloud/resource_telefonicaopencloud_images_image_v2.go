@@ -0,0 +1,545 @@
+package telefonicaopencloud
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/imagedata"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceImagesImageV2() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceImagesImageV2Create,
+		Read:   resourceImagesImageV2Read,
+		Update: resourceImagesImageV2Update,
+		Delete: resourceImagesImageV2Delete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"container_format": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: resourceImagesImageV2ValidateContainerFormat,
+			},
+
+			"disk_format": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: resourceImagesImageV2ValidateDiskFormat,
+			},
+
+			"local_file_path": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"image_source_url"},
+			},
+
+			"image_source_url": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"local_file_path"},
+			},
+
+			"image_cache_path": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  fmt.Sprintf("%s/.terraform/image_cache", os.Getenv("HOME")),
+			},
+
+			"verify_checksum": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"min_disk_gb": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validateNonNegativeInt,
+			},
+
+			"min_ram_mb": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validateNonNegativeInt,
+			},
+
+			"protected": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"visibility": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "private",
+				ValidateFunc: resourceImagesImageV2ValidateVisibility,
+			},
+
+			"tags": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+
+			"properties": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			// Computed values
+			"checksum": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"size_bytes": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"owner": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"file": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"schema": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"updated_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceImagesImageV2Create(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	protected := d.Get("protected").(bool)
+	visibility := images.ImageVisibility(d.Get("visibility").(string))
+	createOpts := &images.CreateOpts{
+		Name:            d.Get("name").(string),
+		ContainerFormat: d.Get("container_format").(string),
+		DiskFormat:      d.Get("disk_format").(string),
+		MinDisk:         d.Get("min_disk_gb").(int),
+		MinRAM:          d.Get("min_ram_mb").(int),
+		Protected:       &protected,
+		Visibility:      &visibility,
+		Tags:            resourceImagesImageV2Tags(d),
+		Properties:      resourceImagesImageV2Properties(d),
+	}
+
+	// Get the image data before creating the image, so that nothing is
+	// left behind if it can't be read.
+	imgFilePath, err := resourceImagesImageV2File(d)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Create Options: %#v", createOpts)
+	newImg, err := images.Create(imageClient, createOpts).Extract()
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image: %s", err)
+	}
+
+	d.SetId(newImg.ID)
+
+	imgFile, err := os.Open(imgFilePath)
+	if err != nil {
+		return fmt.Errorf("Error opening file %q: %s", imgFilePath, err)
+	}
+	defer imgFile.Close()
+
+	log.Printf("[DEBUG] Uploading image data from %q to image %s", imgFilePath, d.Id())
+	if err := imagedata.Upload(imageClient, d.Id(), imgFile).ExtractErr(); err != nil {
+		return fmt.Errorf("Error uploading the data of image %s: %s", d.Id(), err)
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{string(images.ImageStatusQueued), string(images.ImageStatusSaving)},
+		Target:     []string{string(images.ImageStatusActive)},
+		Refresh:    ImagesImageV2StateRefreshFunc(imageClient, d.Id()),
+		Timeout:    d.Timeout(schema.TimeoutCreate),
+		Delay:      5 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	img, err := stateConf.WaitForState()
+	if err != nil {
+		return fmt.Errorf("Error waiting for image %s to become active: %s", d.Id(), err)
+	}
+
+	if d.Get("verify_checksum").(bool) {
+		checksum, err := fileMD5Checksum(imgFilePath)
+		if err != nil {
+			return err
+		}
+		if image := img.(*images.Image); image.Checksum != checksum {
+			return fmt.Errorf("Error verifying image %s: its checksum %s doesn't match %s, the checksum of %q",
+				d.Id(), image.Checksum, checksum, imgFilePath)
+		}
+	}
+
+	return resourceImagesImageV2Read(d, meta)
+}
+
+func resourceImagesImageV2Read(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	img, err := images.Get(imageClient, d.Id()).Extract()
+	if err != nil {
+		return CheckDeleted(d, err, "image")
+	}
+
+	log.Printf("[DEBUG] Retrieved Image %s: %#v", d.Id(), img)
+
+	d.Set("name", img.Name)
+	d.Set("container_format", img.ContainerFormat)
+	d.Set("disk_format", img.DiskFormat)
+	d.Set("min_disk_gb", img.MinDiskGigabytes)
+	d.Set("min_ram_mb", img.MinRAMMegabytes)
+	d.Set("protected", img.Protected)
+	d.Set("visibility", string(img.Visibility))
+	d.Set("tags", img.Tags)
+	d.Set("checksum", img.Checksum)
+	d.Set("size_bytes", img.SizeBytes)
+	d.Set("status", string(img.Status))
+	d.Set("owner", img.Owner)
+	d.Set("file", img.File)
+	d.Set("schema", img.Schema)
+	d.Set("created_at", img.CreatedAt.Format(time.RFC3339))
+	d.Set("updated_at", img.UpdatedAt.Format(time.RFC3339))
+	d.Set("region", GetRegion(d, config))
+
+	// Glance returns its own properties alongside the ones set here, so
+	// only the configured properties are tracked.
+	properties := make(map[string]string)
+	for k := range d.Get("properties").(map[string]interface{}) {
+		if v, ok := img.Properties[k]; ok {
+			properties[k] = fmt.Sprint(v)
+		}
+	}
+	if err := d.Set("properties", properties); err != nil {
+		log.Printf("[DEBUG] Unable to set properties: %s", err)
+	}
+
+	return nil
+}
+
+func resourceImagesImageV2Update(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	var updateOpts images.UpdateOpts
+
+	if d.HasChange("name") {
+		updateOpts = append(updateOpts, images.ReplaceImageName{NewName: d.Get("name").(string)})
+	}
+
+	if d.HasChange("visibility") {
+		visibility := images.ImageVisibility(d.Get("visibility").(string))
+		updateOpts = append(updateOpts, images.UpdateVisibility{Visibility: visibility})
+	}
+
+	if d.HasChange("tags") {
+		updateOpts = append(updateOpts, images.ReplaceImageTags{NewTags: resourceImagesImageV2Tags(d)})
+	}
+
+	if d.HasChange("min_disk_gb") {
+		updateOpts = append(updateOpts, imagePatch{Op: "replace", Path: "/min_disk", Value: d.Get("min_disk_gb")})
+	}
+
+	if d.HasChange("min_ram_mb") {
+		updateOpts = append(updateOpts, imagePatch{Op: "replace", Path: "/min_ram", Value: d.Get("min_ram_mb")})
+	}
+
+	if d.HasChange("protected") {
+		updateOpts = append(updateOpts, imagePatch{Op: "replace", Path: "/protected", Value: d.Get("protected")})
+	}
+
+	if d.HasChange("properties") {
+		o, n := d.GetChange("properties")
+		oldProperties := o.(map[string]interface{})
+		newProperties := n.(map[string]interface{})
+
+		for k := range oldProperties {
+			if _, ok := newProperties[k]; !ok {
+				updateOpts = append(updateOpts, imagePatch{Op: "remove", Path: imagePropertyPath(k)})
+			}
+		}
+		for k, v := range newProperties {
+			if oldProperties[k] != v {
+				updateOpts = append(updateOpts, imagePatch{Op: "add", Path: imagePropertyPath(k), Value: v})
+			}
+		}
+	}
+
+	if len(updateOpts) > 0 {
+		log.Printf("[DEBUG] Update Options: %#v", updateOpts)
+		_, err = images.Update(imageClient, d.Id(), updateOpts).Extract()
+		if err != nil {
+			return fmt.Errorf("Error updating image %s: %s", d.Id(), err)
+		}
+	}
+
+	return resourceImagesImageV2Read(d, meta)
+}
+
+func resourceImagesImageV2Delete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	imageClient, err := config.imageV2Client(GetRegion(d, config))
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	log.Printf("[DEBUG] Deleting Image %s", d.Id())
+	if err := images.Delete(imageClient, d.Id()).ExtractErr(); err != nil {
+		return CheckDeleted(d, err, "image")
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// ImagesImageV2StateRefreshFunc returns a resource.StateRefreshFunc that is
+// used to watch an image while its data is uploaded.
+func ImagesImageV2StateRefreshFunc(client *gophercloud.ServiceClient, id string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		img, err := images.Get(client, id).Extract()
+		if err != nil {
+			return nil, "", err
+		}
+
+		if img.Status == images.ImageStatusKilled {
+			return img, string(img.Status), fmt.Errorf("Error uploading the data of image %s", id)
+		}
+
+		return img, string(img.Status), nil
+	}
+}
+
+// imagePatch is a JSON patch operation on an image, for the attributes the
+// images package has no patch of its own for.
+type imagePatch struct {
+	Op    string
+	Path  string
+	Value interface{}
+}
+
+func (p imagePatch) ToImagePatchMap() map[string]interface{} {
+	patch := map[string]interface{}{
+		"op":   p.Op,
+		"path": p.Path,
+	}
+	if p.Op != "remove" {
+		patch["value"] = p.Value
+	}
+	return patch
+}
+
+// imagePropertyPath returns the JSON pointer to an image property. Property
+// keys may contain "~" and "/", which have to be escaped.
+func imagePropertyPath(key string) string {
+	return "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func resourceImagesImageV2Tags(d *schema.ResourceData) []string {
+	var tags []string
+	for _, tag := range d.Get("tags").(*schema.Set).List() {
+		tags = append(tags, tag.(string))
+	}
+	return tags
+}
+
+func resourceImagesImageV2Properties(d *schema.ResourceData) map[string]string {
+	properties := make(map[string]string)
+	for k, v := range d.Get("properties").(map[string]interface{}) {
+		properties[k] = v.(string)
+	}
+	return properties
+}
+
+// resourceImagesImageV2File returns the path of the file to upload. Images
+// from a URL are downloaded to the image cache once, named by the checksum
+// of their URL. The checksum of the image data is kept next to it, so that a
+// cached image which was changed or cut short is downloaded again.
+func resourceImagesImageV2File(d *schema.ResourceData) (string, error) {
+	if v, ok := d.GetOk("local_file_path"); ok {
+		return v.(string), nil
+	}
+
+	url, ok := d.GetOk("image_source_url")
+	if !ok {
+		return "", fmt.Errorf("One of local_file_path or image_source_url must be set")
+	}
+
+	cachePath := d.Get("image_cache_path").(string)
+	if err := os.MkdirAll(cachePath, 0700); err != nil {
+		return "", fmt.Errorf("Unable to create the image cache %q: %s", cachePath, err)
+	}
+
+	sum := md5.Sum([]byte(url.(string)))
+	filePath := filepath.Join(cachePath, hex.EncodeToString(sum[:])+".img")
+	checksumPath := filePath + ".md5"
+	if cachedImageValid(filePath, checksumPath) {
+		log.Printf("[DEBUG] Using cached image %q for %s", filePath, url)
+		return filePath, nil
+	}
+
+	log.Printf("[DEBUG] Downloading image %s to %q", url, filePath)
+	resp, err := http.Get(url.(string))
+	if err != nil {
+		return "", fmt.Errorf("Error downloading image from %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Error downloading image from %s: %s", url, resp.Status)
+	}
+
+	// Download to a temporary file first, so that an interrupted download
+	// isn't taken for a cached image later on.
+	tmpPath := filePath + ".part"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("Error creating file %q: %s", tmpPath, err)
+	}
+
+	hash := md5.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), resp.Body)
+	file.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("Error downloading image from %s: %s", url, err)
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return "", fmt.Errorf("Error renaming file %q: %s", tmpPath, err)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if err := ioutil.WriteFile(checksumPath, []byte(checksum), 0600); err != nil {
+		return "", fmt.Errorf("Error writing file %q: %s", checksumPath, err)
+	}
+
+	return filePath, nil
+}
+
+// cachedImageValid reports whether a cached image matches the checksum it
+// was downloaded with.
+func cachedImageValid(filePath, checksumPath string) bool {
+	expected, err := ioutil.ReadFile(checksumPath)
+	if err != nil {
+		return false
+	}
+
+	checksum, err := fileMD5Checksum(filePath)
+	if err != nil {
+		return false
+	}
+
+	if checksum != strings.TrimSpace(string(expected)) {
+		log.Printf("[DEBUG] Cached image %q has checksum %s, expected %s", filePath, checksum, expected)
+		return false
+	}
+	return true
+}
+
+func fileMD5Checksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("Error opening file %q: %s", path, err)
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("Error reading file %q: %s", path, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func resourceImagesImageV2ValidateVisibility(v interface{}, k string) (ws []string, errors []error) {
+	return ValidateStringList(v, k, []string{"public", "private", "shared", "community"})
+}
+
+func resourceImagesImageV2ValidateContainerFormat(v interface{}, k string) (ws []string, errors []error) {
+	return ValidateStringList(v, k, []string{"ami", "ari", "aki", "bare", "ovf", "ova", "docker"})
+}
+
+func resourceImagesImageV2ValidateDiskFormat(v interface{}, k string) (ws []string, errors []error) {
+	return ValidateStringList(v, k, []string{
+		"ami", "ari", "aki", "vhd", "vhdx", "vmdk", "raw", "qcow2", "vdi", "iso", "zvhd", "zvhd2",
+	})
+}
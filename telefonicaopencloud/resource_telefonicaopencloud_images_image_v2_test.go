@@ -0,0 +1,378 @@
+package telefonicaopencloud
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+)
+
+// testAccImagesImageV2Data is uploaded as the image data by the tests.
+var testAccImagesImageV2Data = []byte("telefonicaopencloud acceptance test image")
+
+// testAccImagesImageV2File writes the image data to a temporary file, which
+// the caller must remove.
+func testAccImagesImageV2File(t *testing.T) string {
+	tmpFile, err := ioutil.TempFile("", "tf-acc-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmpFile.Close()
+
+	if _, err := tmpFile.Write(testAccImagesImageV2Data); err != nil {
+		t.Fatal(err)
+	}
+	return tmpFile.Name()
+}
+
+func TestAccImagesImageV2_basic(t *testing.T) {
+	var image images.Image
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageV2_basic(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_images_image_v2.image_1", &image),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "name", "image_1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "status", "active"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "visibility", "private"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "checksum",
+						fmt.Sprintf("%x", md5.Sum(testAccImagesImageV2Data))),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "size_bytes",
+						fmt.Sprint(len(testAccImagesImageV2Data))),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImagesImageV2_imageSourceURL(t *testing.T) {
+	var image images.Image
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testAccImagesImageV2Data)
+	}))
+	defer server.Close()
+
+	cachePath, err := ioutil.TempDir("", "tf-acc-image-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cachePath)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageV2_imageSourceURL(server.URL, cachePath),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_images_image_v2.image_1", &image),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "checksum",
+						fmt.Sprintf("%x", md5.Sum(testAccImagesImageV2Data))),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImagesImageV2_imageCacheInvalid(t *testing.T) {
+	var image images.Image
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testAccImagesImageV2Data)
+	}))
+	defer server.Close()
+
+	cachePath, err := ioutil.TempDir("", "tf-acc-image-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cachePath)
+
+	// A cached image which no longer matches its checksum, as if it was
+	// changed after it was downloaded.
+	filePath := filepath.Join(cachePath, fmt.Sprintf("%x.img", md5.Sum([]byte(server.URL))))
+	if err := ioutil.WriteFile(filePath, []byte("stale image"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	checksum := fmt.Sprintf("%x", md5.Sum(testAccImagesImageV2Data))
+	if err := ioutil.WriteFile(filePath+".md5", []byte(checksum), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageV2_imageSourceURL(server.URL, cachePath),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_images_image_v2.image_1", &image),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "checksum", checksum),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImagesImageV2_propertyEscaping(t *testing.T) {
+	var image images.Image
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageV2_propertyEscaping_1(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_images_image_v2.image_1", &image),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "properties.os/distro", "ubuntu"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "properties.build~id", "1"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccImagesImageV2_propertyEscaping_2(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_images_image_v2.image_1", &image),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "properties.%", "1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "properties.os/distro", "debian"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImagesImageV2_update(t *testing.T) {
+	var image images.Image
+	imageFile := testAccImagesImageV2File(t)
+	defer os.Remove(imageFile)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImagesImageV2Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccImagesImageV2_update_1(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_images_image_v2.image_1", &image),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "tags.#", "2"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "properties.os_type", "Linux"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "properties.foo", "bar"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "protected", "true"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccImagesImageV2_update_2(imageFile),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckImagesImageV2Exists("telefonicaopencloud_images_image_v2.image_1", &image),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "name", "image_2"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "tags.#", "1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "min_disk_gb", "20"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "properties.%", "1"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "properties.os_type", "Windows"),
+					resource.TestCheckResourceAttr(
+						"telefonicaopencloud_images_image_v2.image_1", "protected", "false"),
+					testAccCheckImagesImageV2Property(&image, "foo", ""),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckImagesImageV2Destroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Config)
+	imageClient, err := config.imageV2Client(OS_REGION_NAME)
+	if err != nil {
+		return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+	}
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "telefonicaopencloud_images_image_v2" {
+			continue
+		}
+
+		_, err := images.Get(imageClient, rs.Primary.ID).Extract()
+		if err == nil {
+			return fmt.Errorf("Image still exists")
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckImagesImageV2Exists(n string, image *images.Image) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		config := testAccProvider.Meta().(*Config)
+		imageClient, err := config.imageV2Client(OS_REGION_NAME)
+		if err != nil {
+			return fmt.Errorf("Error creating TelefonicaOpenCloud image client: %s", err)
+		}
+
+		found, err := images.Get(imageClient, rs.Primary.ID).Extract()
+		if err != nil {
+			return err
+		}
+
+		if found.ID != rs.Primary.ID {
+			return fmt.Errorf("Image not found")
+		}
+
+		*image = *found
+
+		return nil
+	}
+}
+
+// testAccCheckImagesImageV2Property checks a property of an image, which is
+// missing if value is empty.
+func testAccCheckImagesImageV2Property(image *images.Image, key, value string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		v, ok := image.Properties[key]
+		if value == "" && ok {
+			return fmt.Errorf("Image still has property %s", key)
+		}
+		if value != "" && fmt.Sprint(v) != value {
+			return fmt.Errorf("Property %s of image is %v, not %s", key, v, value)
+		}
+
+		return nil
+	}
+}
+
+func testAccImagesImageV2_basic(imageFile string) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_images_image_v2" "image_1" {
+  name = "image_1"
+  local_file_path = "%s"
+  container_format = "bare"
+  disk_format = "raw"
+}
+`, imageFile)
+}
+
+func testAccImagesImageV2_imageSourceURL(url, cachePath string) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_images_image_v2" "image_1" {
+  name = "image_1"
+  image_source_url = "%s"
+  image_cache_path = "%s"
+  container_format = "bare"
+  disk_format = "raw"
+}
+`, url, cachePath)
+}
+
+func testAccImagesImageV2_update_1(imageFile string) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_images_image_v2" "image_1" {
+  name = "image_1"
+  local_file_path = "%s"
+  container_format = "bare"
+  disk_format = "raw"
+  protected = true
+  tags = ["foo", "bar"]
+
+  properties {
+    os_type = "Linux"
+    foo = "bar"
+  }
+}
+`, imageFile)
+}
+
+func testAccImagesImageV2_update_2(imageFile string) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_images_image_v2" "image_1" {
+  name = "image_2"
+  local_file_path = "%s"
+  container_format = "bare"
+  disk_format = "raw"
+  min_disk_gb = 20
+  tags = ["foo"]
+
+  properties {
+    os_type = "Windows"
+  }
+}
+`, imageFile)
+}
+
+func testAccImagesImageV2_propertyEscaping_1(imageFile string) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_images_image_v2" "image_1" {
+  name = "image_1"
+  local_file_path = "%s"
+  container_format = "bare"
+  disk_format = "raw"
+
+  properties {
+    "os/distro" = "ubuntu"
+    "build~id" = "1"
+  }
+}
+`, imageFile)
+}
+
+func testAccImagesImageV2_propertyEscaping_2(imageFile string) string {
+	return fmt.Sprintf(`
+resource "telefonicaopencloud_images_image_v2" "image_1" {
+  name = "image_1"
+  local_file_path = "%s"
+  container_format = "bare"
+  disk_format = "raw"
+
+  properties {
+    "os/distro" = "debian"
+  }
+}
+`, imageFile)
+}
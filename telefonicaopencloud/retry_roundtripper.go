@@ -0,0 +1,158 @@
+package telefonicaopencloud
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// maxRetryBodySize is the largest request body which is kept in memory to be
+// sent again. Requests with larger bodies, such as image uploads, are
+// streamed and never retried.
+const maxRetryBodySize = 1 << 20
+
+// RetryRoundTripper satisfies the http.RoundTripper interface and retries
+// requests which were throttled, hit a gateway error or lost their connection.
+type RetryRoundTripper struct {
+	Rt         http.RoundTripper
+	MaxRetries int
+	MinDelay   time.Duration
+	MaxDelay   time.Duration
+}
+
+// RoundTrip performs a round-trip HTTP request and retries it with an
+// exponential backoff, honoring the Retry-After header of the response.
+func (rrt *RetryRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if rrt.MaxRetries <= 0 {
+		return rrt.Rt.RoundTrip(request)
+	}
+
+	// The body is consumed by every attempt, so it has to be replayable.
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		if request.ContentLength <= 0 || request.ContentLength > maxRetryBodySize {
+			return rrt.Rt.RoundTrip(request)
+		}
+
+		body, err := ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		request.Body, _ = request.GetBody()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			request.Body = body
+		}
+
+		response, err := rrt.Rt.RoundTrip(request)
+		if attempt >= rrt.MaxRetries || !isRetryableRequest(request, response, err) {
+			return response, err
+		}
+
+		delay := rrt.delay(attempt, response)
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = response.Status
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+		}
+		log.Printf("[DEBUG] Retrying TelefonicaOpenCloud request %s %s in %s (retry %d of %d): %s",
+			request.Method, request.URL, delay, attempt+1, rrt.MaxRetries, reason)
+
+		select {
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// delay returns how long to wait before the given retry. A Retry-After
+// header takes precedence over the exponential backoff, but neither may
+// exceed MaxDelay.
+func (rrt *RetryRoundTripper) delay(attempt int, response *http.Response) time.Duration {
+	delay := rrt.MinDelay << uint(attempt)
+	if delay <= 0 || delay > rrt.MaxDelay {
+		delay = rrt.MaxDelay
+	}
+
+	if response != nil {
+		if v := response.Header.Get("Retry-After"); v != "" {
+			if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+				delay = time.Duration(seconds) * time.Second
+			} else if date, err := http.ParseTime(v); err == nil {
+				delay = time.Until(date)
+			}
+		}
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > rrt.MaxDelay {
+		delay = rrt.MaxDelay
+	}
+
+	return delay
+}
+
+// isRetryableRequest reports whether a request can safely be sent again.
+// Throttled requests were never processed and can always be retried; other
+// failures are only retried for idempotent methods.
+func isRetryableRequest(request *http.Request, response *http.Response, err error) bool {
+	var idempotent bool
+	switch request.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		idempotent = true
+	}
+
+	if err != nil {
+		return idempotent && isConnectionReset(err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+
+	return false
+}
+
+// isConnectionReset reports whether err means the connection was closed by
+// the other side.
+func isConnectionReset(err error) bool {
+	for {
+		switch e := err.(type) {
+		case *url.Error:
+			err = e.Err
+		case *net.OpError:
+			err = e.Err
+		case *os.SyscallError:
+			err = e.Err
+		default:
+			return err == syscall.ECONNRESET || err == syscall.EPIPE ||
+				err == io.EOF || err == io.ErrUnexpectedEOF
+		}
+	}
+}
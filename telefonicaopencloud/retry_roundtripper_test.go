@@ -0,0 +1,184 @@
+package telefonicaopencloud
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// testRetryServer answers with the given status codes in turn and with 200
+// once they are used up.
+func testRetryServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1))
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method == "PUT" && string(body) != `{"name": "test"}` {
+			t.Errorf("Request %d has the wrong body: %q", n, body)
+		}
+
+		if n <= len(statuses) {
+			if statuses[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "3600")
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return server, &requests
+}
+
+func testRetryRoundTripper(maxRetries int) *http.Client {
+	return &http.Client{
+		Transport: &RetryRoundTripper{
+			Rt:         http.DefaultTransport,
+			MaxRetries: maxRetries,
+			MinDelay:   time.Millisecond,
+			MaxDelay:   10 * time.Millisecond,
+		},
+	}
+}
+
+func TestRetryRoundTripper_retryable(t *testing.T) {
+	server, requests := testRetryServer(t, 429, 503, 502)
+	defer server.Close()
+
+	request, _ := http.NewRequest("PUT", server.URL, strings.NewReader(`{"name": "test"}`))
+	response, err := testRetryRoundTripper(3).Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", response.StatusCode)
+	}
+	if *requests != 4 {
+		t.Fatalf("Expected 4 requests, got %d", *requests)
+	}
+}
+
+func TestRetryRoundTripper_exhausted(t *testing.T) {
+	server, requests := testRetryServer(t, 503, 503, 503)
+	defer server.Close()
+
+	response, err := testRetryRoundTripper(2).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", response.StatusCode)
+	}
+	if *requests != 3 {
+		t.Fatalf("Expected 3 requests, got %d", *requests)
+	}
+}
+
+func TestRetryRoundTripper_notIdempotent(t *testing.T) {
+	for _, status := range []int{429, 503} {
+		server, requests := testRetryServer(t, status)
+
+		response, err := testRetryRoundTripper(3).Post(server.URL, "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		server.Close()
+
+		// A throttled request was never processed, so it is safe to resend.
+		expected := int32(1)
+		if status == http.StatusTooManyRequests {
+			expected = 2
+		}
+		if *requests != expected {
+			t.Fatalf("Expected %d requests for a POST answered with %d, got %d", expected, status, *requests)
+		}
+	}
+}
+
+func TestRetryRoundTripper_streamedBody(t *testing.T) {
+	for _, streamed := range []bool{false, true} {
+		server, requests := testRetryServer(t, 503)
+
+		// Unlike a strings.Reader, a MultiReader can't be rewound, so its
+		// body is only buffered to be retried when it's known to be small.
+		request, _ := http.NewRequest("PUT", server.URL, io.MultiReader(strings.NewReader(`{"name": "test"}`)))
+		if !streamed {
+			request.ContentLength = 16
+		}
+		response, err := testRetryRoundTripper(3).Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		server.Close()
+
+		expected := int32(2)
+		if streamed {
+			expected = 1
+		}
+		if *requests != expected {
+			t.Fatalf("Expected %d requests for a streamed body (%t), got %d", expected, streamed, *requests)
+		}
+	}
+}
+
+func TestIsConnectionReset(t *testing.T) {
+	wrapped := &url.Error{
+		Op:  "Get",
+		URL: "https://ecs.example.com",
+		Err: &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+	}
+	if !isConnectionReset(wrapped) {
+		t.Fatalf("Expected %v to be a connection reset", wrapped)
+	}
+	if isConnectionReset(&url.Error{Op: "Get", URL: "https://ecs.example.com", Err: syscall.ECONNREFUSED}) {
+		t.Fatalf("Expected a refused connection not to be a connection reset")
+	}
+}
+
+func TestRetryRoundTripper_delay(t *testing.T) {
+	rrt := &RetryRoundTripper{
+		MinDelay: time.Second,
+		MaxDelay: 30 * time.Second,
+	}
+
+	cases := []struct {
+		attempt    int
+		retryAfter string
+		expected   time.Duration
+	}{
+		{0, "", time.Second},
+		{3, "", 8 * time.Second},
+		{10, "", 30 * time.Second},
+		{0, "5", 5 * time.Second},
+		{0, "3600", 30 * time.Second},
+		{0, time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+		{2, "invalid", 4 * time.Second},
+	}
+
+	for _, c := range cases {
+		response := &http.Response{Header: http.Header{}}
+		if c.retryAfter != "" {
+			response.Header.Set("Retry-After", c.retryAfter)
+		}
+
+		if delay := rrt.delay(c.attempt, response); delay != c.expected {
+			t.Fatalf("Expected a delay of %s for retry %d with Retry-After %q, got %s",
+				c.expected, c.attempt, c.retryAfter, delay)
+		}
+	}
+}
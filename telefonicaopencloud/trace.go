@@ -0,0 +1,281 @@
+package telefonicaopencloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceRequestIDHeaders are the response headers with which the services
+// identify a request in their own logs.
+var traceRequestIDHeaders = []string{
+	"X-Openstack-Request-Id",
+	"X-Compute-Request-Id",
+	"X-Request-Id",
+	"X-Trans-Id",
+}
+
+// traceEntry is the line written to a trace file for every request.
+type traceEntry struct {
+	Time            time.Time         `json:"time"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	Service         string            `json:"service"`
+	Status          int               `json:"status,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	LatencyMS       float64           `json:"latency_ms"`
+	RequestIDs      map[string]string `json:"request_ids,omitempty"`
+	RequestHeaders  []string          `json:"request_headers,omitempty"`
+	RequestBody     interface{}       `json:"request_body,omitempty"`
+	ResponseHeaders []string          `json:"response_headers,omitempty"`
+	ResponseBody    interface{}       `json:"response_body,omitempty"`
+}
+
+// traceSummary is the line written to a trace file for every service once
+// the provider exits.
+type traceSummary struct {
+	Summary      bool    `json:"summary"`
+	Service      string  `json:"service"`
+	Requests     int     `json:"requests"`
+	Errors       int     `json:"errors"`
+	LatencyMS    float64 `json:"latency_ms"`
+	MaxLatencyMS float64 `json:"max_latency_ms"`
+}
+
+// traceFile writes a JSON object per request to a file. All provider
+// configurations using the same file share it.
+type traceFile struct {
+	mu        sync.Mutex
+	file      *os.File
+	endpoints map[string]string
+	summaries map[string]*traceSummary
+}
+
+var (
+	traceFiles   = make(map[string]*traceFile)
+	traceFilesMu sync.Mutex
+)
+
+// openTraceFile opens the trace file at path for appending, or returns it if
+// it's open already.
+func openTraceFile(path string) (*traceFile, error) {
+	traceFilesMu.Lock()
+	defer traceFilesMu.Unlock()
+
+	if t, ok := traceFiles[path]; ok {
+		return t, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening trace file: %s", err)
+	}
+
+	t := &traceFile{
+		file:      file,
+		endpoints: make(map[string]string),
+		summaries: make(map[string]*traceSummary),
+	}
+	traceFiles[path] = t
+	return t, nil
+}
+
+// CloseTraceFiles writes the per service summary to every open trace file
+// and closes it. It's called once the plugin is shut down.
+func CloseTraceFiles() {
+	traceFilesMu.Lock()
+	defer traceFilesMu.Unlock()
+
+	for path, t := range traceFiles {
+		if err := t.close(); err != nil {
+			log.Printf("[WARN] Error closing trace file %s: %s", path, err)
+		}
+		delete(traceFiles, path)
+	}
+}
+
+// addEndpoint records the service requests to the endpoint belong to.
+func (t *traceFile) addEndpoint(endpoint, service string) {
+	if t == nil || endpoint == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endpoints[endpoint] = service
+}
+
+// service returns the service of the longest endpoint the URL starts with, or
+// the host of the URL if it doesn't belong to a known endpoint.
+func (t *traceFile) service(rawURL string) string {
+	var service, endpoint string
+	for e, s := range t.endpoints {
+		if strings.HasPrefix(rawURL, e) && len(e) > len(endpoint) {
+			service, endpoint = s, e
+		}
+	}
+
+	if service == "" {
+		if u, err := url.Parse(rawURL); err == nil {
+			service = u.Host
+		}
+	}
+	return service
+}
+
+func (t *traceFile) write(entry *traceEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry.Service = t.service(entry.URL)
+
+	summary, ok := t.summaries[entry.Service]
+	if !ok {
+		summary = &traceSummary{Summary: true, Service: entry.Service}
+		t.summaries[entry.Service] = summary
+	}
+	summary.Requests++
+	if entry.Error != "" || entry.Status >= 400 {
+		summary.Errors++
+	}
+	summary.LatencyMS += entry.LatencyMS
+	if entry.LatencyMS > summary.MaxLatencyMS {
+		summary.MaxLatencyMS = entry.LatencyMS
+	}
+
+	t.writeLine(entry)
+}
+
+// writeLine appends v as a single line. Tracing must never fail a request,
+// so errors are only logged.
+func (t *traceFile) writeLine(v interface{}) {
+	line, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("[WARN] Unable to marshal trace entry: %s", err)
+		return
+	}
+	if _, err := t.file.Write(append(line, '\n')); err != nil {
+		log.Printf("[WARN] Unable to write trace entry: %s", err)
+	}
+}
+
+func (t *traceFile) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	services := make([]string, 0, len(t.summaries))
+	for service := range t.summaries {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		t.writeLine(t.summaries[service])
+	}
+
+	return t.file.Close()
+}
+
+// TraceRoundTripper satisfies the http.RoundTripper interface and writes
+// every request, its response and how long it took to a trace file.
+type TraceRoundTripper struct {
+	Rt    http.RoundTripper
+	Trace *traceFile
+}
+
+// RoundTrip performs a round-trip HTTP request and traces it.
+func (trt *TraceRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	entry := &traceEntry{
+		Time:           time.Now().UTC(),
+		Method:         request.Method,
+		URL:            request.URL.String(),
+		RequestHeaders: traceHeaders(request.Header),
+	}
+
+	// Like responses, only JSON requests are traced, since anything else may
+	// be a large upload which must not be buffered.
+	if request.Body != nil && isTracedContentType(request.Header.Get("Content-Type")) {
+		body, err := ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		entry.RequestBody = traceBody(body, request.Header.Get("Content-Type"))
+	}
+
+	start := time.Now()
+	response, err := trt.Rt.RoundTrip(request)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if response != nil {
+		entry.Status = response.StatusCode
+		entry.ResponseHeaders = traceHeaders(response.Header)
+		for _, h := range traceRequestIDHeaders {
+			if v := response.Header.Get(h); v != "" {
+				if entry.RequestIDs == nil {
+					entry.RequestIDs = make(map[string]string)
+				}
+				entry.RequestIDs[h] = v
+			}
+		}
+
+		if isTracedContentType(response.Header.Get("Content-Type")) {
+			body, readErr := ioutil.ReadAll(response.Body)
+			response.Body.Close()
+			if readErr != nil {
+				return nil, readErr
+			}
+			response.Body = ioutil.NopCloser(bytes.NewReader(body))
+			entry.ResponseBody = traceBody(body, "application/json")
+		}
+	}
+
+	entry.LatencyMS = float64(time.Since(start)) / float64(time.Millisecond)
+	trt.Trace.write(entry)
+
+	return response, err
+}
+
+// isTracedContentType reports whether bodies of the content type are traced.
+func isTracedContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json")
+}
+
+// traceHeaders returns the headers sorted and with their secrets redacted.
+func traceHeaders(headers http.Header) []string {
+	redacted := RedactHeaders(headers)
+	sort.Strings(redacted)
+	return redacted
+}
+
+// traceBody returns a JSON body with its secrets masked the same way as in
+// the debug log. The service catalog is left out.
+func traceBody(body []byte, contentType string) interface{} {
+	if len(body) == 0 || !isTracedContentType(contentType) {
+		return nil
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return string(body)
+	}
+
+	redactJSON(data)
+	if v, ok := data["token"].(map[string]interface{}); ok {
+		delete(v, "catalog")
+	}
+
+	return data
+}
@@ -0,0 +1,167 @@
+package telefonicaopencloud
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+)
+
+func TestTraceRoundTripper(t *testing.T) {
+	server := newTestReauthServer(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &Config{
+		IdentityEndpoint: server.URL + "/v3/",
+		Username:         "user",
+		Password:         "password",
+		DomainName:       "domain",
+		Region:           "RegionOne",
+		TraceFile:        filepath.Join(dir, "trace.json"),
+	}
+	if err := config.LoadAndValidate(); err != nil {
+		t.Fatal(err)
+	}
+
+	computeClient, err := config.computeV2Client("RegionOne")
+	if err != nil {
+		t.Fatalf("Error creating compute client: %s", err)
+	}
+	if _, err := servers.Get(computeClient, "server-1").Extract(); err != nil {
+		t.Fatalf("Error retrieving server: %s", err)
+	}
+	computeClient.Get(computeClient.ServiceURL("missing"), nil, nil)
+
+	CloseTraceFiles()
+
+	file, err := os.Open(config.TraceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var entries []traceEntry
+	var summaries []traceSummary
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Invalid trace line %q: %s", scanner.Text(), err)
+		}
+		if line["summary"] == true {
+			var summary traceSummary
+			json.Unmarshal(scanner.Bytes(), &summary)
+			summary.LatencyMS, summary.MaxLatencyMS = 0, 0
+			summaries = append(summaries, summary)
+		} else {
+			var entry traceEntry
+			json.Unmarshal(scanner.Bytes(), &entry)
+			entries = append(entries, entry)
+		}
+	}
+
+	// One authentication per SDK. The stub only accepts the latest token,
+	// so the first compute request is rejected and authenticates again.
+	if len(entries) != 6 {
+		t.Fatalf("Expected 6 trace entries, got %d: %#v", len(entries), entries)
+	}
+
+	auth := entries[0]
+	if auth.Service != "identity" || auth.Method != "POST" || auth.Status != 201 {
+		t.Fatalf("Unexpected authentication entry: %#v", auth)
+	}
+	password := auth.RequestBody.(map[string]interface{})["auth"].(map[string]interface{})["identity"].(map[string]interface{})["password"].(map[string]interface{})["user"].(map[string]interface{})["password"]
+	if password != "***" {
+		t.Fatalf("Expected the password to be redacted, got %v", password)
+	}
+
+	get := entries[4]
+	if get.Service != "compute" || get.Status != 200 || get.RequestIDs["X-Openstack-Request-Id"] != "req-GET/compute/servers/server-1" {
+		t.Fatalf("Unexpected compute entry: %#v", get)
+	}
+	for _, h := range get.RequestHeaders {
+		if h == "X-Auth-Token: token-3" {
+			t.Fatalf("Expected the token to be redacted, got %v", get.RequestHeaders)
+		}
+	}
+
+	expected := []traceSummary{
+		{Summary: true, Service: "compute", Requests: 3, Errors: 2},
+		{Summary: true, Service: "identity", Requests: 3},
+	}
+	if !reflect.DeepEqual(expected, summaries) {
+		t.Fatalf("Expected summaries %#v, got %#v", expected, summaries)
+	}
+}
+
+func TestTraceBody_redacted(t *testing.T) {
+	bodies := []string{
+		`{"auth": {"identity": {"methods": ["password"], "password": {"user": {"name": "user", "password": "s3cr3t"}}}}}`,
+		`{"auth": {"identity": {"methods": ["token"], "token": {"id": "s3cr3t"}}}}`,
+		`{"auth": {"identity": {"methods": ["application_credential"], "application_credential": {"id": "app", "secret": "s3cr3t"}}}}`,
+		`{"server": {"name": "server-1", "adminPass": "s3cr3t"}}`,
+		`{"rebuild": {"imageRef": "image-1", "adminPass": "s3cr3t"}}`,
+		`{"changePassword": {"adminPass": "s3cr3t"}}`,
+		`{"keypair": {"name": "keypair-1", "private_key": "s3cr3t"}}`,
+	}
+
+	for _, body := range bodies {
+		traced, err := json.Marshal(traceBody([]byte(body), "application/json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(traced), "s3cr3t") || !strings.Contains(string(traced), `"***"`) {
+			t.Errorf("Expected the secret of %s to be redacted, got %s", body, traced)
+		}
+	}
+}
+
+// testBodyRoundTripper records the body of the request it's passed.
+type testBodyRoundTripper struct {
+	body io.ReadCloser
+}
+
+func (rt *testBodyRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	rt.body = request.Body
+	return &http.Response{StatusCode: http.StatusNoContent, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestTraceRoundTripper_upload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	trace, err := openTraceFile(filepath.Join(dir, "trace.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseTraceFiles()
+
+	rt := &testBodyRoundTripper{}
+	body := ioutil.NopCloser(strings.NewReader("image data"))
+	request, _ := http.NewRequest("PUT", "https://ims.example.com/v2/images/image-1/file", body)
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	if _, err := (&TraceRoundTripper{Rt: rt, Trace: trace}).RoundTrip(request); err != nil {
+		t.Fatal(err)
+	}
+	if rt.body != body {
+		t.Fatalf("Expected an upload to be streamed rather than buffered")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/keypairs"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/servergroups"
 	"github.com/gophercloud/gophercloud/openstack/dns/v2/recordsets"
@@ -124,16 +125,7 @@ func (lrt *LogRoundTripper) formatJSON(raw []byte) string {
 		return string(raw)
 	}
 
-	// Mask known password fields
-	if v, ok := data["auth"].(map[string]interface{}); ok {
-		if v, ok := v["identity"].(map[string]interface{}); ok {
-			if v, ok := v["password"].(map[string]interface{}); ok {
-				if v, ok := v["user"].(map[string]interface{}); ok {
-					v["password"] = "***"
-				}
-			}
-		}
-	}
+	redactJSON(data)
 
 	// Ignore the catalog
 	if v, ok := data["token"].(map[string]interface{}); ok {
@@ -151,6 +143,60 @@ func (lrt *LogRoundTripper) formatJSON(raw []byte) string {
 	return string(pretty)
 }
 
+// redactJSON masks known fields of a JSON body which contain sensitive
+// information.
+func redactJSON(data map[string]interface{}) {
+	// Mask the credentials of authentication requests
+	if v, ok := data["auth"].(map[string]interface{}); ok {
+		if v, ok := v["identity"].(map[string]interface{}); ok {
+			if v, ok := v["password"].(map[string]interface{}); ok {
+				redactField(v["user"], "password")
+			}
+			redactField(v["token"], "id")
+			redactField(v["application_credential"], "secret")
+		}
+	}
+
+	// Mask the admin password servers are created and rebuilt with, which
+	// is returned as well
+	for _, k := range []string{"server", "rebuild", "changePassword"} {
+		redactField(data[k], "adminPass")
+	}
+
+	// Mask the private key of generated keypairs
+	redactField(data["keypair"], "private_key")
+}
+
+// redactField masks a field of a JSON object, if it's set.
+func redactField(object interface{}, key string) {
+	if v, ok := object.(map[string]interface{}); ok {
+		if _, ok := v[key]; ok {
+			v[key] = "***"
+		}
+	}
+}
+
+// FixedTokenRoundTripper satisfies the http.RoundTripper interface and is used
+// to turn a rejected user supplied token into a descriptive error, since such
+// a token can't be renewed.
+type FixedTokenRoundTripper struct {
+	Rt http.RoundTripper
+}
+
+// RoundTrip performs a round-trip HTTP request and fails it if the token was rejected.
+func (frt *FixedTokenRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := frt.Rt.RoundTrip(request)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || request.Header.Get("X-Auth-Token") == "" {
+		return response, err
+	}
+
+	response.Body.Close()
+
+	return nil, fmt.Errorf("The authentication token was rejected, it has probably expired. " +
+		"A token set with the token argument or OS_AUTH_TOKEN is a single fixed token which can't be renewed, " +
+		"set user_name and password instead to re-authenticate automatically")
+}
+
 // Firewall is an TelefonicaOpenCloud firewall.
 type Firewall struct {
 	firewalls.Firewall
@@ -325,6 +371,32 @@ func (opts SubnetCreateOpts) ToSubnetCreateMap() (map[string]interface{}, error)
 	return b, nil
 }
 
+// VolumeUpdateOpts represents the attributes used when updating a volume.
+// Unlike volumes.UpdateOpts, it also sends an empty name or description, so
+// that they can be cleared.
+type VolumeUpdateOpts struct {
+	Name        *string           `json:"name,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// ToVolumeUpdateMap casts a VolumeUpdateOpts struct to a map.
+func (opts VolumeUpdateOpts) ToVolumeUpdateMap() (map[string]interface{}, error) {
+	return gophercloud.BuildRequestBody(opts, "volume")
+}
+
+// VolumeRetypeOpts represents the attributes used when changing the type of
+// a volume, which Gophercloud doesn't support yet.
+type VolumeRetypeOpts struct {
+	NewType         string `json:"new_type" required:"true"`
+	MigrationPolicy string `json:"migration_policy,omitempty"`
+}
+
+// ToVolumeRetypeMap casts a VolumeRetypeOpts struct to a map.
+func (opts VolumeRetypeOpts) ToVolumeRetypeMap() (map[string]interface{}, error) {
+	return gophercloud.BuildRequestBody(opts, "os-retype")
+}
+
 // ZoneCreateOpts represents the attributes used when creating a new DNS zone.
 type ZoneCreateOpts struct {
 	zones.CreateOpts
@@ -11,6 +11,7 @@ import (
 	"github.com/gophercloud/gophercloud"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/huaweicloud/golangsdk"
 )
 
 // BuildRequest takes an opts struct and builds a request body for
@@ -74,7 +75,7 @@ func MapValueSpecs(d *schema.ResourceData) map[string]string {
 var REDACT_HEADERS = []string{"x-auth-token", "x-auth-key", "x-service-token",
 	"x-storage-token", "x-account-meta-temp-url-key", "x-account-meta-temp-url-key-2",
 	"x-container-meta-temp-url-key", "x-container-meta-temp-url-key-2", "set-cookie",
-	"x-subject-token"}
+	"x-subject-token", "authorization"}
 
 // RedactHeaders processes a headers object, returning a redacted list
 func RedactHeaders(headers http.Header) (processedHeaders []string) {
@@ -100,15 +101,22 @@ func FormatHeaders(headers http.Header, seperator string) string {
 
 func checkForRetryableError(err error) *resource.RetryError {
 	switch errCode := err.(type) {
-	case gophercloud.ErrDefault500:
+	case gophercloud.ErrDefault429, gophercloud.ErrDefault500, gophercloud.ErrDefault503,
+		golangsdk.ErrDefault429, golangsdk.ErrDefault500, golangsdk.ErrDefault503:
 		return resource.RetryableError(err)
 	case gophercloud.ErrUnexpectedResponseCode:
-		switch errCode.Actual {
-		case 409, 503:
-			return resource.RetryableError(err)
-		default:
-			return resource.NonRetryableError(err)
-		}
+		return checkForRetryableResponseCode(err, errCode.Actual)
+	case golangsdk.ErrUnexpectedResponseCode:
+		return checkForRetryableResponseCode(err, errCode.Actual)
+	default:
+		return resource.NonRetryableError(err)
+	}
+}
+
+func checkForRetryableResponseCode(err error, code int) *resource.RetryError {
+	switch code {
+	case 409, 429, 502, 503, 504:
+		return resource.RetryableError(err)
 	default:
 		return resource.NonRetryableError(err)
 	}
@@ -2,6 +2,9 @@ package telefonicaopencloud
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
 	"time"
 )
 
@@ -47,6 +50,24 @@ func validateS3BucketLifecycleExpirationDays(v interface{}, k string) (ws []stri
 	return
 }
 
+func validateNonNegativeInt(v interface{}, k string) (ws []string, errors []error) {
+	if v.(int) < 0 {
+		errors = append(errors, fmt.Errorf(
+			"%q cannot be negative", k))
+	}
+
+	return
+}
+
+func validateRegexp(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := regexp.Compile(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf(
+			"%q is not a valid regular expression: %s", k, err))
+	}
+
+	return
+}
+
 func validateS3BucketLifecycleRuleId(v interface{}, k string) (ws []string, errors []error) {
 	value := v.(string)
 	if len(value) > 255 {
@@ -62,3 +83,31 @@ func validateJsonString(v interface{}, k string) (ws []string, errors []error) {
 	}
 	return
 }
+
+func validateEndpoints(v interface{}, k string) (ws []string, errors []error) {
+	var keys []string
+	for key := range endpointServiceTypes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for key, endpoint := range v.(map[string]interface{}) {
+		if _, ok := endpointServiceTypes[key]; !ok {
+			errors = append(errors, fmt.Errorf("%q contains an unknown service %q, must be one of %v", k, key, keys))
+			continue
+		}
+
+		u, err := url.Parse(endpoint.(string))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			errors = append(errors, fmt.Errorf("%q: %q must be an absolute URL, got %q", k, key, endpoint))
+		}
+	}
+	return
+}
+
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as \"500ms\" or \"30s\": %s", k, err))
+	}
+	return
+}